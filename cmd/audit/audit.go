@@ -0,0 +1,19 @@
+package audit
+
+import (
+	"github.com/spf13/cobra"
+
+	auditTailCmd "github.com/anyproto/anytype-cli/cmd/audit/tail"
+)
+
+func NewAuditCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "audit <command>",
+		Short: "Inspect the RPC audit log",
+		Long:  "Inspect the audit log of RPC calls made to the embedded server. Records include the calling process, method, status and the space and object Ids involved, but never request payloads.",
+	}
+
+	cmd.AddCommand(auditTailCmd.NewTailCmd())
+
+	return cmd
+}
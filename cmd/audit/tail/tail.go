@@ -0,0 +1,114 @@
+package tail
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/anyproto/anytype-cli/cmd/cmdutil"
+	"github.com/anyproto/anytype-cli/core/audit"
+	"github.com/anyproto/anytype-cli/core/output"
+)
+
+func NewTailCmd() *cobra.Command {
+	var lines int
+	var follow bool
+	var format string
+	var since time.Duration
+	var filter audit.Filter
+
+	cmd := &cobra.Command{
+		Use:   "tail",
+		Short: "Show recent audit records",
+		Long:  "Show the most recent audit records, optionally filtered by method, peer, process, space, object or status code. Use --follow to stream new records as they are written.",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := cmdutil.ResolveOutputFormat(cmd, &format); err != nil {
+				return output.Error("%w", err)
+			}
+			if since > 0 {
+				filter.Since = time.Now().Add(-since)
+			}
+
+			path := audit.GetFilePath()
+			records, err := audit.Tail(path, lines, filter)
+			if err != nil {
+				return output.Error("Failed to read audit log: %w", err)
+			}
+
+			printRecord := func(rec audit.Record) {
+				if format == cmdutil.OutputJSON {
+					data, _ := json.Marshal(rec)
+					output.Print("%s", data)
+					return
+				}
+				output.Print("%s", formatRecord(rec))
+			}
+
+			if len(records) == 0 && !follow {
+				output.Info("No audit records found in %s", path)
+				return nil
+			}
+			for _, rec := range records {
+				printRecord(rec)
+			}
+
+			if !follow {
+				return nil
+			}
+
+			stop := make(chan struct{})
+			sigCh := make(chan os.Signal, 1)
+			signal.Notify(sigCh, os.Interrupt)
+			defer signal.Stop(sigCh)
+			go func() {
+				<-sigCh
+				close(stop)
+			}()
+
+			if err := audit.Follow(path, filter, 500*time.Millisecond, stop, printRecord); err != nil {
+				return output.Error("Failed to follow audit log: %w", err)
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().IntVarP(&lines, "lines", "n", 20, "Number of records to show (0 for all)")
+	cmd.Flags().BoolVarP(&follow, "follow", "f", false, "Keep streaming new records")
+	cmdutil.AddOutputFlag(cmd, &format)
+	cmd.Flags().DurationVar(&since, "since", 0, "Only show records newer than this `duration` (e.g. 1h)")
+	cmd.Flags().StringVar(&filter.Method, "method", "", "Filter by method name (substring match)")
+	cmd.Flags().StringVar(&filter.Peer, "peer", "", "Filter by peer address or host")
+	cmd.Flags().StringVar(&filter.Process, "process", "", "Filter by client process name (substring match)")
+	cmd.Flags().StringVar(&filter.SpaceId, "space", "", "Filter by space Id")
+	cmd.Flags().StringVar(&filter.Object, "object", "", "Filter by object Id")
+	cmd.Flags().StringVar(&filter.Code, "code", "", "Filter by gRPC status code (e.g. OK, Unauthenticated)")
+
+	return cmd
+}
+
+func formatRecord(rec audit.Record) string {
+	method := rec.Method
+	if idx := strings.LastIndex(method, "/"); idx != -1 {
+		method = method[idx+1:]
+	}
+
+	process := "-"
+	if rec.Process != nil {
+		process = fmt.Sprintf("%s[%d]", rec.Process.Name, rec.Process.PID)
+	}
+
+	line := fmt.Sprintf("%s  %-32s %-16s %-24s %8.1fms  %s",
+		rec.Time.Local().Format("2006-01-02 15:04:05"), method, rec.Code, process, rec.DurationMs, rec.Peer)
+	if rec.SpaceId != "" {
+		line += "  space=" + rec.SpaceId
+	}
+	if len(rec.ObjectIds) > 0 {
+		line += "  objects=" + strings.Join(rec.ObjectIds, ",")
+	}
+	return line
+}
@@ -6,6 +6,7 @@ import (
 	apiKeyCreateCmd "github.com/anyproto/anytype-cli/cmd/auth/apikey/create"
 	apiKeyListCmd "github.com/anyproto/anytype-cli/cmd/auth/apikey/list"
 	apiKeyRevokeCmd "github.com/anyproto/anytype-cli/cmd/auth/apikey/revoke"
+	apiKeyRotateCmd "github.com/anyproto/anytype-cli/cmd/auth/apikey/rotate"
 )
 
 // NewApiKeyCmd creates the auth apikey command
@@ -13,13 +14,14 @@ func NewApiKeyCmd() *cobra.Command {
 	cmd := &cobra.Command{
 		Use:   "apikey <command>",
 		Short: "Manage API keys for programmatic access",
-		Long:  "Create, list, and revoke API keys that can be used for programmatic access to Anytype.",
+		Long:  "Create, list, rotate, and revoke API keys that can be used for programmatic access to Anytype.",
 	}
 
 	// Add subcommands
 	cmd.AddCommand(apiKeyCreateCmd.NewCreateCmd())
 	cmd.AddCommand(apiKeyListCmd.NewListCmd())
 	cmd.AddCommand(apiKeyRevokeCmd.NewRevokeCmd())
+	cmd.AddCommand(apiKeyRotateCmd.NewRotateCmd())
 
 	return cmd
 }
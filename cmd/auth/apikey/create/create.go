@@ -1,34 +1,159 @@
 package create
 
 import (
+	"time"
+
 	"github.com/spf13/cobra"
 
 	"github.com/anyproto/anytype-cli/cmd/cmdutil"
 	"github.com/anyproto/anytype-cli/core"
+	"github.com/anyproto/anytype-cli/core/apigateway"
 	"github.com/anyproto/anytype-cli/core/output"
 )
 
+// createResult is the --output json output. Key is omitted when it was written elsewhere.
+type createResult struct {
+	Name      string    `json:"name"`
+	Id        string    `json:"id"`
+	Key       string    `json:"key,omitempty"`
+	Scope     string    `json:"scope"`
+	Spaces    []string  `json:"spaces,omitempty"`
+	ExpiresAt time.Time `json:"expires_at,omitzero"`
+	Rate      string    `json:"rate,omitempty"`
+	Quota     string    `json:"quota,omitempty"`
+}
+
 func NewCreateCmd() *cobra.Command {
+	var (
+		rateFlag    string
+		quotaFlag   string
+		scopeFlag   string
+		expiresFlag string
+		spacesFlag  []string
+		secretOut   cmdutil.SecretOutput
+	)
+
 	cmd := &cobra.Command{
 		Use:   "create <name>",
 		Short: "Create a new API key",
-		Long:  "Create a new API key for programmatic access to Anytype",
-		Args:  cmdutil.ExactArgs(1, "cannot create API key: name argument required"),
+		Long: `Create a new API key for programmatic access to Anytype.
+
+Use --scope read-only to create a key that can only read and search, --space to
+restrict the key to specific spaces and --expires to have it revoked automatically.
+Use --rate to limit how fast the key may call the API (token bucket, e.g. 10/s)
+and --quota to cap the number of requests per period (e.g. 10000/day).
+Restrictions are enforced by the API server started with 'anytype serve'.
+
+The key is printed once. To keep it out of terminal scrollback and CI logs, write it
+to a private file with --out-file, pipe it to a secret manager with --credential-helper
+(the key is passed on stdin; ANYTYPE_API_KEY_NAME and ANYTYPE_API_KEY_ID are set), or
+use --output json or --quiet for machine-readable output.`,
+		Args: cmdutil.ExactArgs(1, "cannot create API key: name argument required"),
 		RunE: func(cmd *cobra.Command, args []string) error {
 			name := args[0]
 
-			resp, err := core.CreateAPIKey(name)
+			if err := secretOut.Resolve(cmd); err != nil {
+				return output.Error("Invalid flags: %w", err)
+			}
+
+			var policy apigateway.Policy
+			if rateFlag != "" {
+				r, err := apigateway.ParseRate(rateFlag)
+				if err != nil {
+					return output.Error("Invalid --rate: %w", err)
+				}
+				policy.Rate = r
+			}
+			if quotaFlag != "" {
+				q, err := apigateway.ParseRate(quotaFlag)
+				if err != nil {
+					return output.Error("Invalid --quota: %w", err)
+				}
+				policy.Quota = q
+			}
+
+			scope, err := apigateway.ParseScope(scopeFlag)
+			if err != nil {
+				return output.Error("Invalid --scope: %w", err)
+			}
+			if scope != apigateway.ScopeFull {
+				policy.Scope = scope
+			}
+
+			if expiresFlag != "" {
+				d, err := apigateway.ParseDuration(expiresFlag)
+				if err != nil {
+					return output.Error("Invalid --expires: %w", err)
+				}
+				policy.ExpiresAt = time.Now().Add(d).Truncate(time.Second)
+			}
+			policy.Spaces = spacesFlag
+
+			resp, appHash, err := core.CreateAPIKeyWithPolicy(name, policy, func(appKey, appHash string) error {
+				return secretOut.Deliver(appKey, map[string]string{
+					"ANYTYPE_API_KEY_NAME": name,
+					"ANYTYPE_API_KEY_ID":   appHash,
+				})
+			})
 			if err != nil {
 				return output.Error("Failed to create API key: %w", err)
 			}
 
+			switch {
+			case secretOut.Quiet:
+				secretOut.PrintSecret(resp.AppKey)
+				return nil
+			case secretOut.JSON:
+				result := createResult{
+					Name:      name,
+					Id:        appHash,
+					Scope:     scope,
+					Spaces:    policy.Spaces,
+					ExpiresAt: policy.ExpiresAt,
+					Rate:      policy.Rate.String(),
+					Quota:     policy.Quota.String(),
+				}
+				if !secretOut.Redirected() {
+					result.Key = resp.AppKey
+				}
+				return secretOut.PrintJSON(result)
+			}
+
 			output.Success("API key created successfully")
 			output.Info("Name: %s", name)
-			output.Info("Key: %s", resp.AppKey)
+			output.Info("Id: %s", appHash)
+			switch {
+			case secretOut.OutFile != "":
+				output.Info("Key: written to %s", secretOut.OutFile)
+			case secretOut.CredentialHelper != "":
+				output.Info("Key: passed to credential helper")
+			default:
+				output.Info("Key: %s", resp.AppKey)
+			}
+			output.Info("Scope: %s", scope)
+			if len(policy.Spaces) > 0 {
+				output.Info("Spaces: %v", policy.Spaces)
+			}
+			if !policy.ExpiresAt.IsZero() {
+				output.Info("Expires: %s", policy.ExpiresAt.Format("2006-01-02 15:04:05"))
+			}
+			if !policy.Rate.IsZero() {
+				output.Info("Rate limit: %s", policy.Rate)
+			}
+			if !policy.Quota.IsZero() {
+				output.Info("Quota: %s", policy.Quota)
+			}
 
 			return nil
 		},
 	}
 
+	cmd.Flags().StringVar(&scopeFlag, "scope", apigateway.ScopeFull, "Access scope: read-only or full")
+	cmd.Flags().StringVar(&expiresFlag, "expires", "", "Revoke the key automatically after this duration, e.g. 30d or 12h")
+	cmd.Flags().StringSliceVar(&spacesFlag, "space", nil, "Restrict the key to a space id (repeatable)")
+	cmd.Flags().StringVar(&rateFlag, "rate", "", "Rate limit for the key, e.g. 10/s or 600/m")
+	cmd.Flags().StringVar(&quotaFlag, "quota", "", "Request quota for the key, e.g. 10000/day")
+	secretOut.AddFlags(cmd, "API key")
+
 	return cmd
 }
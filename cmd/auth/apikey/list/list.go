@@ -4,12 +4,14 @@ import (
 	"fmt"
 	"os"
 	"sort"
+	"strings"
 	"text/tabwriter"
 	"time"
 
 	"github.com/spf13/cobra"
 
 	"github.com/anyproto/anytype-cli/core"
+	"github.com/anyproto/anytype-cli/core/apigateway"
 	"github.com/anyproto/anytype-cli/core/output"
 )
 
@@ -34,9 +36,14 @@ func NewListCmd() *cobra.Command {
 				return resp.App[i].CreatedAt > resp.App[j].CreatedAt
 			})
 
+			policies, err := apigateway.GetStore().Load()
+			if err != nil {
+				output.Warning("Failed to load API key policies: %v", err)
+			}
+
 			w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
-			fmt.Fprintln(w, "NAME\tID\tKEY\tCREATED")
-			fmt.Fprintln(w, "----\t--\t---\t----------")
+			fmt.Fprintln(w, "NAME\tID\tKEY\tCREATED\tSCOPE\tSPACES\tEXPIRES\tRATE\tQUOTA")
+			fmt.Fprintln(w, "----\t--\t---\t----------\t-----\t------\t-------\t----\t-----")
 
 			for _, app := range resp.App {
 				createdAt := time.Unix(app.CreatedAt, 0).Format("2006-01-02 15:04:05")
@@ -44,7 +51,29 @@ func NewListCmd() *cobra.Command {
 				if len(shortKey) > 8 {
 					shortKey = shortKey[:8] + "..."
 				}
-				fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", app.AppName, app.AppHash, shortKey, createdAt)
+
+				p := policies[app.AppHash]
+				scope, spaces, expires, rateLimit, quota := apigateway.ScopeFull, "all", "never", "-", "-"
+				if p.Scope != "" {
+					scope = p.Scope
+				}
+				if len(p.Spaces) > 0 {
+					spaces = strings.Join(p.Spaces, ",")
+				}
+				if !p.ExpiresAt.IsZero() {
+					expires = p.ExpiresAt.Local().Format("2006-01-02 15:04:05")
+					if p.Expired(time.Now()) {
+						expires += " (expired)"
+					}
+				}
+				if !p.Rate.IsZero() {
+					rateLimit = p.Rate.String()
+				}
+				if !p.Quota.IsZero() {
+					quota = p.Quota.String()
+				}
+				fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\n",
+					app.AppName, app.AppHash, shortKey, createdAt, scope, spaces, expires, rateLimit, quota)
 			}
 
 			w.Flush()
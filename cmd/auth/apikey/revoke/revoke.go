@@ -5,6 +5,7 @@ import (
 
 	"github.com/anyproto/anytype-cli/cmd/cmdutil"
 	"github.com/anyproto/anytype-cli/core"
+	"github.com/anyproto/anytype-cli/core/apigateway"
 	"github.com/anyproto/anytype-cli/core/output"
 )
 
@@ -22,6 +23,10 @@ func NewRevokeCmd() *cobra.Command {
 				return output.Error("Failed to revoke API key: %w", err)
 			}
 
+			if err := apigateway.GetStore().Delete(appId); err != nil {
+				output.Warning("Failed to remove limits for revoked key: %v", err)
+			}
+
 			output.Success("API key with Id '%s' revoked successfully", appId)
 			return nil
 		},
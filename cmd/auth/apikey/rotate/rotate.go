@@ -0,0 +1,122 @@
+package rotate
+
+import (
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/anyproto/anytype-cli/cmd/cmdutil"
+	"github.com/anyproto/anytype-cli/core"
+	"github.com/anyproto/anytype-cli/core/apigateway"
+	"github.com/anyproto/anytype-cli/core/output"
+)
+
+const defaultGracePeriod = 24 * time.Hour
+
+// rotateResult is the --output json output. Key is omitted when it was written elsewhere,
+// OldRevokeAt when the old key was revoked immediately.
+type rotateResult struct {
+	Id          string `json:"id"`
+	Key         string `json:"key,omitempty"`
+	OldId       string `json:"old_id"`
+	OldRevokeAt string `json:"old_revoke_at,omitempty"`
+}
+
+func NewRotateCmd() *cobra.Command {
+	var (
+		graceFlag   time.Duration
+		expiresFlag string
+		secretOut   cmdutil.SecretOutput
+	)
+
+	cmd := &cobra.Command{
+		Use:   "rotate <id>",
+		Short: "Replace an API key with a new one",
+		Long: `Issue a replacement for an API key and revoke the old key after a grace period.
+
+The new key keeps the name, scope, space restrictions and limits of the old one.
+It is printed only once. The old key keeps working until the grace period ends,
+then the running service revokes it. Use --grace 0 to revoke it immediately.
+
+The same delivery options as 'apikey create' are available (--out-file,
+--credential-helper, --output json, --quiet). The old key is only retired after the new
+key has been delivered.`,
+		Args: cmdutil.ExactArgs(1, "cannot rotate API key: id argument required"),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			appId := args[0]
+
+			if err := secretOut.Resolve(cmd); err != nil {
+				return output.Error("Invalid flags: %w", err)
+			}
+			if graceFlag < 0 {
+				return output.Error("Invalid --grace: cannot be negative")
+			}
+
+			var expiresIn time.Duration
+			if expiresFlag != "" {
+				d, err := apigateway.ParseDuration(expiresFlag)
+				if err != nil {
+					return output.Error("Invalid --expires: %w", err)
+				}
+				expiresIn = d
+			}
+
+			resp, newId, revokeAt, err := core.RotateAPIKey(appId, graceFlag, expiresIn, func(appKey, appHash string) error {
+				return secretOut.Deliver(appKey, map[string]string{
+					"ANYTYPE_API_KEY_ID":     appHash,
+					"ANYTYPE_API_KEY_OLD_ID": appId,
+				})
+			})
+			if resp == nil && err != nil {
+				return output.Error("Failed to rotate API key: %w", err)
+			}
+			retireErr := err
+
+			switch {
+			case secretOut.Quiet:
+				secretOut.PrintSecret(resp.AppKey)
+			case secretOut.JSON:
+				result := rotateResult{Id: newId, OldId: appId}
+				if !revokeAt.IsZero() {
+					result.OldRevokeAt = revokeAt.Format(time.RFC3339)
+				}
+				if !secretOut.Redirected() {
+					result.Key = resp.AppKey
+				}
+				if err := secretOut.PrintJSON(result); err != nil {
+					return err
+				}
+			default:
+				output.Success("API key rotated successfully")
+				output.Info("Id: %s", newId)
+				switch {
+				case secretOut.OutFile != "":
+					output.Info("Key: written to %s", secretOut.OutFile)
+				case secretOut.CredentialHelper != "":
+					output.Info("Key: passed to credential helper")
+				default:
+					output.Info("Key: %s", resp.AppKey)
+					output.Info("This key will not be shown again.")
+				}
+				if retireErr == nil {
+					if revokeAt.IsZero() {
+						output.Info("Old key '%s' has been revoked", appId)
+					} else {
+						output.Info("Old key '%s' will be revoked at %s", appId, revokeAt.Format("2006-01-02 15:04:05"))
+					}
+				}
+			}
+
+			if retireErr != nil {
+				return output.Error("Failed to retire the old key: %w", retireErr)
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().DurationVar(&graceFlag, "grace", defaultGracePeriod, "How long the old key keeps working")
+	cmd.Flags().StringVar(&expiresFlag, "expires", "", "Expiry for the new key, e.g. 30d (default: same lifetime as the old key)")
+	secretOut.AddFlags(cmd, "API key")
+
+	return cmd
+}
@@ -5,6 +5,8 @@ import (
 
 	authApiKeyCmd "github.com/anyproto/anytype-cli/cmd/auth/apikey"
 	authCreateCmd "github.com/anyproto/anytype-cli/cmd/auth/create"
+	authCredentialsCmd "github.com/anyproto/anytype-cli/cmd/auth/credentials"
+	authKeyCmd "github.com/anyproto/anytype-cli/cmd/auth/key"
 	authLoginCmd "github.com/anyproto/anytype-cli/cmd/auth/login"
 	authLogoutCmd "github.com/anyproto/anytype-cli/cmd/auth/logout"
 	authStatusCmd "github.com/anyproto/anytype-cli/cmd/auth/status"
@@ -22,6 +24,8 @@ func NewAuthCmd() *cobra.Command {
 	cmd.AddCommand(authStatusCmd.NewStatusCmd())
 	cmd.AddCommand(authCreateCmd.NewCreateCmd())
 	cmd.AddCommand(authApiKeyCmd.NewApiKeyCmd())
+	cmd.AddCommand(authCredentialsCmd.NewCredentialsCmd())
+	cmd.AddCommand(authKeyCmd.NewKeyCmd())
 
 	return cmd
 }
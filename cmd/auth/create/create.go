@@ -9,6 +9,7 @@ import (
 	"github.com/anyproto/anytype-cli/cmd/cmdutil"
 	"github.com/anyproto/anytype-cli/core"
 	"github.com/anyproto/anytype-cli/core/config"
+	"github.com/anyproto/anytype-cli/core/credentials"
 	"github.com/anyproto/anytype-cli/core/output"
 )
 
@@ -24,8 +25,11 @@ func NewCreateCmd() *cobra.Command {
 		Args:  cmdutil.ExactArgs(1, "cannot create account: name argument required"),
 		RunE: func(cmd *cobra.Command, args []string) error {
 			name := args[0]
+			if err := cmdutil.ConfigFlag(cmd, "listen-address", "listenAddress", &listenAddress); err != nil {
+				return output.Error("%w", err)
+			}
 
-			accountKey, accountId, savedToKeyring, err := core.CreateWallet(name, rootPath, listenAddress)
+			accountKey, accountId, backend, err := core.CreateWallet(name, rootPath, listenAddress)
 			if err != nil {
 				return output.Error("Failed to create account: %w", err)
 			}
@@ -64,10 +68,8 @@ func NewCreateCmd() *cobra.Command {
 
 			output.Print("")
 			output.Success("You are now logged in to your new bot account.")
-			if savedToKeyring {
-				output.Success("Account key saved to keychain.")
-			} else {
-				output.Success("Account key saved to config file.")
+			if backend != "" {
+				output.Success("Account key saved to %s.", credentials.Describe(backend))
 			}
 
 			return nil
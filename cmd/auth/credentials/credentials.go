@@ -0,0 +1,24 @@
+package credentials
+
+import (
+	"github.com/spf13/cobra"
+
+	credentialsMigrateCmd "github.com/anyproto/anytype-cli/cmd/auth/credentials/migrate"
+	credentialsPurgeCmd "github.com/anyproto/anytype-cli/cmd/auth/credentials/purge"
+	credentialsShowCmd "github.com/anyproto/anytype-cli/cmd/auth/credentials/show"
+)
+
+// NewCredentialsCmd creates the auth credentials command
+func NewCredentialsCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "credentials <command>",
+		Short: "Inspect and move stored credentials",
+		Long:  "Show where the account key and session token are stored, move them between storage backends, or remove them.",
+	}
+
+	cmd.AddCommand(credentialsShowCmd.NewShowCmd())
+	cmd.AddCommand(credentialsMigrateCmd.NewMigrateCmd())
+	cmd.AddCommand(credentialsPurgeCmd.NewPurgeCmd())
+
+	return cmd
+}
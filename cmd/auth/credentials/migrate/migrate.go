@@ -0,0 +1,65 @@
+package migrate
+
+import (
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/anyproto/anytype-cli/core"
+	"github.com/anyproto/anytype-cli/core/config"
+	"github.com/anyproto/anytype-cli/core/credentials"
+	"github.com/anyproto/anytype-cli/core/output"
+)
+
+func NewMigrateCmd() *cobra.Command {
+	var (
+		toFlag      string
+		keyFileFlag string
+		helperFlag  string
+	)
+
+	cmd := &cobra.Command{
+		Use:   "migrate --to <keyring|file|encrypted|helper>",
+		Short: "Move stored credentials to another backend",
+		Long: `Copy the stored account key and session token to another backend, make it the
+configured backend and remove them from the previous one. No new login is needed.
+
+The encrypted backend reads its passphrase from ANYTYPE_CREDENTIALS_PASSPHRASE or
+the file given with --key-file. The helper backend runs the command given with --helper.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			target, err := credentials.ParseBackend(toFlag)
+			if err != nil || target == credentials.BackendAuto {
+				return output.Error("--to must be one of keyring, file, encrypted, helper")
+			}
+
+			cfg, err := config.GetCredentialsConfig()
+			if err != nil {
+				return output.Error("Failed to load config: %w", err)
+			}
+			if keyFileFlag != "" {
+				cfg.KeyFile = keyFileFlag
+			}
+			if helperFlag != "" {
+				cfg.Helper = helperFlag
+			}
+
+			migrated, err := core.MigrateCredentials(target, cfg)
+			if err != nil {
+				return output.Error("Failed to migrate credentials: %w", err)
+			}
+
+			output.Success("Moved %s to %s", strings.Join(migrated, " and "), credentials.Describe(target))
+			if target == credentials.BackendFile {
+				output.Warning("Credentials are now stored in plain text in %s", config.GetConfigManager().GetFilePath())
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&toFlag, "to", "", "Target backend: keyring, file, encrypted or helper")
+	cmd.Flags().StringVar(&keyFileFlag, "key-file", "", "Passphrase file for the encrypted backend")
+	cmd.Flags().StringVar(&helperFlag, "helper", "", "Credential helper command for the helper backend")
+	_ = cmd.MarkFlagRequired("to")
+
+	return cmd
+}
@@ -0,0 +1,56 @@
+package purge
+
+import (
+	"bufio"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/anyproto/anytype-cli/core"
+	"github.com/anyproto/anytype-cli/core/credentials"
+	"github.com/anyproto/anytype-cli/core/output"
+)
+
+func NewPurgeCmd() *cobra.Command {
+	var yesFlag bool
+
+	cmd := &cobra.Command{
+		Use:   "purge",
+		Short: "Remove stored credentials from all backends",
+		Long: `Remove the account key and session token from every credentials backend.
+
+Without the account key you can only log in again if you still have it elsewhere.
+Environment variables are not touched.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if !yesFlag {
+				output.Print("This removes your stored account key and session token. Type 'yes' to continue: ")
+				answer, _ := bufio.NewReader(os.Stdin).ReadString('\n')
+				if strings.TrimSpace(answer) != "yes" {
+					output.Info("Aborted.")
+					return nil
+				}
+			}
+
+			purged, err := core.PurgeCredentials()
+			if err != nil {
+				return output.Error("Failed to purge credentials: %w", err)
+			}
+
+			if len(purged) == 0 {
+				output.Info("No stored credentials found.")
+				return nil
+			}
+			names := make([]string, len(purged))
+			for i, b := range purged {
+				names[i] = credentials.Describe(b)
+			}
+			output.Success("Removed credentials from %s", strings.Join(names, ", "))
+			return nil
+		},
+	}
+
+	cmd.Flags().BoolVarP(&yesFlag, "yes", "y", false, "Do not ask for confirmation")
+
+	return cmd
+}
@@ -0,0 +1,62 @@
+package show
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+
+	"github.com/anyproto/anytype-cli/core"
+	"github.com/anyproto/anytype-cli/core/credentials"
+	"github.com/anyproto/anytype-cli/core/output"
+)
+
+func NewShowCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "show",
+		Short: "Show which backend holds which credential",
+		Long:  "List the stored account key and session token in every credentials backend, with a fingerprint of each value instead of the value itself.",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			configured, locations, err := core.InspectCredentials()
+			if err != nil {
+				return output.Error("Failed to inspect credentials: %w", err)
+			}
+
+			output.Info("Configured backend: %s", configured)
+
+			if len(locations) == 0 {
+				output.Info("No stored credentials found.")
+				return nil
+			}
+
+			output.Print("")
+			w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+			fmt.Fprintln(w, "BACKEND\tCREDENTIAL\tVALUE")
+			fmt.Fprintln(w, "-------\t----------\t-----")
+			for _, loc := range locations {
+				name, value := loc.Name, mask(loc.Value)
+				if name == "" {
+					name = "-"
+				}
+				if loc.Err != nil {
+					value = "error: " + loc.Err.Error()
+				}
+				fmt.Fprintf(w, "%s\t%s\t%s\n", credentials.Describe(loc.Backend), name, value)
+			}
+			return w.Flush()
+		},
+	}
+}
+
+// mask returns a short fingerprint of a secret, enough to tell whether two backends hold the
+// same value without revealing any part of it.
+func mask(value string) string {
+	if value == "" {
+		return "****"
+	}
+	sum := sha256.Sum256([]byte(value))
+	return "sha256:" + hex.EncodeToString(sum[:4])
+}
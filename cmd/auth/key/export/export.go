@@ -0,0 +1,69 @@
+package export
+
+import (
+	"os"
+
+	"github.com/mdp/qrterminal/v3"
+	"github.com/spf13/cobra"
+
+	"github.com/anyproto/anytype-cli/cmd/cmdutil"
+	"github.com/anyproto/anytype-cli/core"
+	"github.com/anyproto/anytype-cli/core/output"
+)
+
+func NewExportCmd() *cobra.Command {
+	var (
+		outFlag string
+		qrFlag  bool
+	)
+	passphraseInput := cmdutil.SecretInput{EnvVar: core.KeyBackupPassphraseEnvVar, Confirm: true}
+
+	cmd := &cobra.Command{
+		Use:   "export --out <file>",
+		Short: "Export the account key as an encrypted backup",
+		Long: `Encrypt the stored account key with a passphrase and write it to a file (mode 0600).
+
+With --qr the encrypted backup is also rendered as a QR code in the terminal, so it can
+be moved to another device without a network. Restore it with 'anytype auth key import'.
+
+The passphrase is prompted for, or read from --passphrase-file, --passphrase-stdin
+or the ANYTYPE_KEY_PASSPHRASE environment variable.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if outFlag == "" && !qrFlag {
+				return output.Error("Nothing to do: use --out and/or --qr")
+			}
+
+			passphrase, err := passphraseInput.Read("")
+			if err != nil {
+				return output.Error("Failed to read passphrase: %w", err)
+			}
+
+			backup, err := core.ExportAccountKey(passphrase)
+			if err != nil {
+				return output.Error("Failed to export account key: %w", err)
+			}
+
+			if outFlag != "" {
+				secretOut := cmdutil.SecretOutput{OutFile: outFlag}
+				if err := secretOut.Deliver(string(backup), nil); err != nil {
+					return output.Error("Failed to write backup: %w", err)
+				}
+				output.Success("Encrypted account key written to %s", outFlag)
+			}
+
+			if qrFlag {
+				qrterminal.GenerateHalfBlock(string(backup), qrterminal.L, os.Stdout)
+				output.Info("Scan the code and save its text to a file, then run 'anytype auth key import --in <file>'.")
+			}
+
+			output.Warning("Keep the passphrase separate from the backup; anyone holding both can use the bot account.")
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&outFlag, "out", "", "Write the encrypted backup to this file")
+	cmd.Flags().BoolVar(&qrFlag, "qr", false, "Render the encrypted backup as a QR code in the terminal")
+	passphraseInput.AddFlags(cmd, "passphrase", "backup passphrase")
+
+	return cmd
+}
@@ -0,0 +1,81 @@
+package importcmd
+
+import (
+	"io"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/anyproto/anytype-cli/cmd/cmdutil"
+	"github.com/anyproto/anytype-cli/core"
+	"github.com/anyproto/anytype-cli/core/config"
+	"github.com/anyproto/anytype-cli/core/output"
+)
+
+func NewImportCmd() *cobra.Command {
+	var (
+		inFlag        string
+		rootPath      string
+		listenAddress string
+	)
+	passphraseInput := cmdutil.SecretInput{EnvVar: core.KeyBackupPassphraseEnvVar}
+
+	cmd := &cobra.Command{
+		Use:   "import --in <file>",
+		Short: "Restore the account key from an encrypted backup and log in",
+		Long: `Decrypt a backup created by 'anytype auth key export', log in with the account key
+and store it in the configured credentials backend. Use --in - to read the backup from stdin.
+
+The passphrase is prompted for, or read from --passphrase-file or the
+ANYTYPE_KEY_PASSPHRASE environment variable. With --in - it must come from
+the file or the environment variable.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if inFlag == "" {
+				return output.Error("--in is required")
+			}
+
+			var data []byte
+			var err error
+			if inFlag == "-" {
+				if err := passphraseInput.CheckStdinUnused("--in -"); err != nil {
+					return output.Error("%w", err)
+				}
+				data, err = io.ReadAll(os.Stdin)
+			} else {
+				data, err = os.ReadFile(inFlag)
+			}
+			if err != nil {
+				return output.Error("Failed to read backup: %w", err)
+			}
+
+			passphrase, err := passphraseInput.Read("")
+			if err != nil {
+				return output.Error("Failed to read passphrase: %w", err)
+			}
+
+			backup, err := core.ImportAccountKey(data, passphrase)
+			if err != nil {
+				return output.Error("Failed to import account key: %w", err)
+			}
+			if err := cmdutil.ConfigFlag(cmd, "listen-address", "listenAddress", &listenAddress); err != nil {
+				return output.Error("%w", err)
+			}
+			if backup.AccountId != "" {
+				output.Info("Restoring account %s", backup.AccountId)
+			}
+
+			if err := core.Login(backup.AccountKey, rootPath, listenAddress); err != nil {
+				return output.Error("Failed to log in: %w", err)
+			}
+			output.Success("Successfully logged in")
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&inFlag, "in", "", "Encrypted backup file, or - for stdin")
+	cmd.Flags().StringVar(&rootPath, "path", "", "Root path for account data")
+	cmd.Flags().StringVar(&listenAddress, "listen-address", config.DefaultAPIAddress, "API listen address in `host:port` format")
+	passphraseInput.AddFlags(cmd, "passphrase", "backup passphrase")
+
+	return cmd
+}
@@ -0,0 +1,22 @@
+package key
+
+import (
+	"github.com/spf13/cobra"
+
+	keyExportCmd "github.com/anyproto/anytype-cli/cmd/auth/key/export"
+	keyImportCmd "github.com/anyproto/anytype-cli/cmd/auth/key/import"
+)
+
+// NewKeyCmd creates the auth key command
+func NewKeyCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "key <command>",
+		Short: "Back up and restore the account key",
+		Long:  "Export the stored account key as a passphrase-encrypted backup and restore it on this or another machine.",
+	}
+
+	cmd.AddCommand(keyExportCmd.NewExportCmd())
+	cmd.AddCommand(keyImportCmd.NewImportCmd())
+
+	return cmd
+}
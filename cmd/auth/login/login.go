@@ -3,8 +3,10 @@ package login
 import (
 	"github.com/spf13/cobra"
 
+	"github.com/anyproto/anytype-cli/cmd/cmdutil"
 	"github.com/anyproto/anytype-cli/core"
 	"github.com/anyproto/anytype-cli/core/config"
+	"github.com/anyproto/anytype-cli/core/credentials"
 	"github.com/anyproto/anytype-cli/core/output"
 )
 
@@ -12,22 +14,37 @@ func NewLoginCmd() *cobra.Command {
 	var accountKey string
 	var rootPath string
 	var listenAddress string
+	keyInput := cmdutil.SecretInput{EnvVar: credentials.EnvVar(credentials.AccountKey)}
 
 	cmd := &cobra.Command{
 		Use:   "login",
 		Short: "Log in to your bot account",
-		Long:  "Authenticate using your account key to access your Anytype bot account and stored data.",
+		Long: `Authenticate using your account key to access your Anytype bot account and stored data.
+
+The account key is read from --account-key-file, from stdin with --account-key-stdin,
+or from the ANYTYPE_ACCOUNT_KEY environment variable. Otherwise it is prompted for
+without echoing it to the terminal. Passing it with --account-key exposes it in the
+process list and shell history.`,
 		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := cmdutil.ConfigFlag(cmd, "listen-address", "listenAddress", &listenAddress); err != nil {
+				return output.Error("%w", err)
+			}
+
+			accountKey, err := keyInput.Read(accountKey)
+			if err != nil {
+				return output.Error("Failed to read account key: %w", err)
+			}
+
 			if err := core.Login(accountKey, rootPath, listenAddress); err != nil {
 				return output.Error("Failed to log in: %w", err)
 			}
 			output.Success("Successfully logged in")
 			return nil
-
 		},
 	}
 
 	cmd.Flags().StringVar(&accountKey, "account-key", "", "Account key for authentication")
+	keyInput.AddFlags(cmd, "account-key", "account key")
 	cmd.Flags().StringVar(&rootPath, "path", "", "Root path for account data")
 	cmd.Flags().StringVar(&listenAddress, "listen-address", config.DefaultAPIAddress, "API listen address in `host:port` format")
 
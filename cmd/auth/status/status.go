@@ -9,7 +9,9 @@ import (
 
 	"github.com/anyproto/anytype-cli/core"
 	"github.com/anyproto/anytype-cli/core/config"
+	"github.com/anyproto/anytype-cli/core/credentials"
 	"github.com/anyproto/anytype-cli/core/output"
+	"github.com/anyproto/anytype-cli/core/serverstatus"
 )
 
 func NewStatusCmd() *cobra.Command {
@@ -20,20 +22,27 @@ func NewStatusCmd() *cobra.Command {
 		RunE: func(cmd *cobra.Command, args []string) error {
 			hasAccountKey := false
 			accountKey := ""
-			accountKeyInKeyring := false
-			if ak, inKeyring, err := core.GetStoredAccountKey(); err == nil && ak != "" {
+			accountKeyBackend := ""
+			if ak, backend, err := core.GetStoredAccountKey(); err == nil && ak != "" {
 				hasAccountKey = true
 				accountKey = ak
-				accountKeyInKeyring = inKeyring
+				accountKeyBackend = backend
 			}
 
 			hasToken := false
 			token := ""
-			tokenInKeyring := false
-			if t, inKeyring, err := core.GetStoredSessionToken(); err == nil {
+			tokenBackend := ""
+			if t, backend, err := core.GetStoredSessionToken(); err == nil {
 				hasToken = true
 				token = t
-				tokenInKeyring = inKeyring
+				tokenBackend = backend
+			}
+
+			storageLocation := credentials.Describe(credentials.BackendFile)
+			if tokenBackend != "" {
+				storageLocation = credentials.Describe(tokenBackend)
+			} else if accountKeyBackend != "" {
+				storageLocation = credentials.Describe(accountKeyBackend)
 			}
 
 			accountId, _ := config.GetAccountIdFromConfig()
@@ -45,17 +54,24 @@ func NewStatusCmd() *cobra.Command {
 			})
 			isServerRunning = err == nil
 
-			// If server is running and we have a token, we're logged in (server auto-logs in on restart using stored account key)
+			// The server reports its session state; servers that do not are assumed to have
+			// logged in with the stored key if a token exists
 			isLoggedIn := isServerRunning && hasToken
+			var serverAuth *serverstatus.Auth
+			if isServerRunning {
+				if st, err := serverstatus.Read(); err == nil {
+					serverAuth = &st.Auth
+					isLoggedIn = st.Auth.State == serverstatus.AuthLoggedIn
+					if st.Auth.AccountId != "" {
+						accountId = st.Auth.AccountId
+					}
+				}
+			}
 
 			// Display status based on priority: server -> credentials -> login
 			if !isServerRunning {
 				output.Print("Server is not running. Start it with 'anytype service start' or 'anytype serve' (foreground mode).")
 				if hasAccountKey || hasToken || accountId != "" {
-					storageLocation := "config file"
-					if accountKeyInKeyring || tokenInKeyring {
-						storageLocation = "keychain"
-					}
 					output.Print("Credentials are stored in %s.", storageLocation)
 				}
 				return nil
@@ -69,18 +85,12 @@ func NewStatusCmd() *cobra.Command {
 			output.Print("\033[1manytype\033[0m")
 
 			if isLoggedIn && accountId != "" {
-				storageLocation := "config file"
-				if tokenInKeyring {
-					storageLocation = "keychain"
-				}
 				output.Print("  ✓ Logged in to account \033[1m%s\033[0m (%s)", accountId, storageLocation)
 			} else if hasToken || hasAccountKey {
-				storageLocation := "config file"
-				if accountKeyInKeyring || tokenInKeyring {
-					storageLocation = "keychain"
-				}
 				output.Print("  ✗ Not logged in (credentials stored in %s)", storageLocation)
-				if !isLoggedIn && hasToken {
+				if serverAuth != nil {
+					output.Print("    Server: %s", serverAuth.Describe())
+				} else if hasToken {
 					output.Print("    Note: Server is not running or session expired. Start it with 'anytype service start' or 'anytype serve' (foreground mode).")
 				}
 			} else {
@@ -4,6 +4,8 @@ import (
 	"fmt"
 
 	"github.com/spf13/cobra"
+
+	"github.com/anyproto/anytype-cli/core/config"
 )
 
 func ExactArgs(n int, msg string) cobra.PositionalArgs {
@@ -17,3 +19,15 @@ func ExactArgs(n int, msg string) cobra.PositionalArgs {
 		return nil
 	}
 }
+
+// SpaceArg returns the space id given as the first argument, or the defaultSpace config key.
+// Use it with cobra.MaximumNArgs(1).
+func SpaceArg(args []string, msg string) (string, error) {
+	if len(args) > 0 {
+		return args[0], nil
+	}
+	if spaceId := config.Value("defaultSpace"); spaceId != "" {
+		return spaceId, nil
+	}
+	return "", fmt.Errorf("%s (or set one with: anytype config set defaultSpace <space-id>)", msg)
+}
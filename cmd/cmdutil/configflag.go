@@ -0,0 +1,23 @@
+package cmdutil
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/anyproto/anytype-cli/core/config"
+)
+
+// ConfigFlag resolves a flag that mirrors a config key. A value given on the command line
+// is validated and recorded as a flag override of the key; otherwise value is set to the
+// key's effective value from the environment, the config file or the default.
+func ConfigFlag(cmd *cobra.Command, flag, key string, value *string) error {
+	if cmd.Flags().Changed(flag) {
+		if err := config.SetFlagValue(key, *value); err != nil {
+			return fmt.Errorf("invalid --%s: %w", flag, err)
+		}
+		return nil
+	}
+	*value = config.Value(key)
+	return nil
+}
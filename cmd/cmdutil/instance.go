@@ -0,0 +1,21 @@
+package cmdutil
+
+import (
+	"github.com/spf13/cobra"
+
+	"github.com/anyproto/anytype-cli/core/serviceinfo"
+)
+
+// AddInstanceFlag registers --name for the service commands. Named instances serve the profile
+// of the same name; the default instance is the service installed without --name.
+func AddInstanceFlag(cmd *cobra.Command, name *string) {
+	cmd.Flags().StringVar(name, "name", serviceinfo.DefaultInstance, "Service `instance` to manage")
+}
+
+// InstanceHint returns the --name argument that selects instance in a suggested command.
+func InstanceHint(instance string) string {
+	if instance == serviceinfo.DefaultInstance {
+		return ""
+	}
+	return " --name " + instance
+}
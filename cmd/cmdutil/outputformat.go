@@ -0,0 +1,41 @@
+package cmdutil
+
+import (
+	"encoding/json"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/anyproto/anytype-cli/core/config"
+)
+
+const (
+	OutputText = config.OutputText
+	OutputJSON = config.OutputJSON
+)
+
+// AddOutputFlag registers --output (-o) for commands that can print machine-readable results,
+// and --json, the older spelling of --output json.
+func AddOutputFlag(cmd *cobra.Command, format *string) {
+	cmd.Flags().StringVarP(format, "output", "o", "", "Output format: text or json (default: the outputFormat config key, text)")
+	cmd.Flags().Bool("json", false, "Print the result as JSON")
+	cmd.Flags().MarkDeprecated("json", "use --output json instead")
+}
+
+// ResolveOutputFormat validates --output, or sets format from the outputFormat config key if
+// the flag was not given.
+func ResolveOutputFormat(cmd *cobra.Command, format *string) error {
+	if cmd.Flags().Changed("json") && !cmd.Flags().Changed("output") {
+		if err := cmd.Flags().Set("output", OutputJSON); err != nil {
+			return err
+		}
+	}
+	return ConfigFlag(cmd, "output", "outputFormat", format)
+}
+
+// PrintJSON prints v as indented JSON on stdout.
+func PrintJSON(v interface{}) error {
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
@@ -0,0 +1,106 @@
+package cmdutil
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/anyproto/anytype-cli/core/config"
+)
+
+// SecretOutput controls how a newly issued secret is handed to the user. By default it is
+// printed with the other command output; it can instead be written to a private file, piped
+// to a credential helper, printed alone (--quiet), or emitted as JSON for a secret manager.
+type SecretOutput struct {
+	OutFile          string
+	CredentialHelper string
+	Format           string
+	Quiet            bool
+	// JSON is set by Resolve when the result is to be printed as JSON
+	JSON bool
+}
+
+// AddFlags registers the secret delivery flags. what names the secret in help texts, e.g. "API key".
+func (o *SecretOutput) AddFlags(cmd *cobra.Command, what string) {
+	cmd.Flags().StringVar(&o.OutFile, "out-file", "", fmt.Sprintf("Write the %s to this file (mode 0600) instead of printing it", what))
+	cmd.Flags().StringVar(&o.CredentialHelper, "credential-helper", "", fmt.Sprintf("Pipe the %s to this command's stdin instead of printing it", what))
+	AddOutputFlag(cmd, &o.Format)
+	cmd.Flags().BoolVarP(&o.Quiet, "quiet", "q", false, fmt.Sprintf("Print only the %s", what))
+}
+
+// Resolve resolves the output format and checks the flags for conflicts. --quiet takes
+// precedence over a JSON format that only comes from the outputFormat config key.
+func (o *SecretOutput) Resolve(cmd *cobra.Command) error {
+	if err := ResolveOutputFormat(cmd, &o.Format); err != nil {
+		return err
+	}
+	explicit := cmd.Flags().Changed("output") || cmd.Flags().Changed("json")
+	o.JSON = o.Format == OutputJSON && (explicit || !o.Quiet)
+	if o.JSON && o.Quiet {
+		return fmt.Errorf("--output json and --quiet cannot be used together")
+	}
+	return nil
+}
+
+// Redirected reports whether the secret goes to a file or helper rather than stdout.
+func (o *SecretOutput) Redirected() bool {
+	return o.OutFile != "" || o.CredentialHelper != ""
+}
+
+// Deliver writes the secret to the configured file and credential helper. env is passed
+// to the helper in addition to the current environment, e.g. to identify the secret.
+func (o *SecretOutput) Deliver(secret string, env map[string]string) error {
+	if o.OutFile != "" {
+		if err := writePrivateFile(o.OutFile, []byte(secret+"\n")); err != nil {
+			return fmt.Errorf("failed to write %s: %w", o.OutFile, err)
+		}
+	}
+
+	if o.CredentialHelper != "" {
+		if err := runCredentialHelper(o.CredentialHelper, secret, env); err != nil {
+			return fmt.Errorf("credential helper failed: %w", err)
+		}
+	}
+	return nil
+}
+
+// PrintSecret prints the secret alone for --quiet, unless it was redirected.
+func (o *SecretOutput) PrintSecret(secret string) {
+	if !o.Redirected() {
+		fmt.Fprintln(os.Stdout, secret)
+	}
+}
+
+// PrintJSON prints v as indented JSON on stdout.
+func (o *SecretOutput) PrintJSON(v interface{}) error {
+	return PrintJSON(v)
+}
+
+// writePrivateFile replaces path atomically with a file only the current user can read.
+func writePrivateFile(path string, data []byte) error {
+	return config.WriteFileAtomic(path, data, 0600)
+}
+
+// runCredentialHelper runs helper through the shell, like git credential helpers, with the secret on stdin.
+// The helper's output goes to stderr so it cannot mix with the command's own output.
+func runCredentialHelper(helper, secret string, env map[string]string) error {
+	var c *exec.Cmd
+	if runtime.GOOS == "windows" {
+		c = exec.Command("cmd", "/C", helper)
+	} else {
+		c = exec.Command("sh", "-c", helper)
+	}
+
+	c.Stdin = strings.NewReader(secret + "\n")
+	c.Stdout = os.Stderr
+	c.Stderr = os.Stderr
+	c.Env = os.Environ()
+	for k, v := range env {
+		c.Env = append(c.Env, k+"="+v)
+	}
+	return c.Run()
+}
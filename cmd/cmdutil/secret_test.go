@@ -0,0 +1,118 @@
+package cmdutil
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/spf13/cobra"
+)
+
+func TestSecretOutputResolve(t *testing.T) {
+	// Keep a user's outputFormat setting out of the test
+	t.Setenv("ANYTYPE_OUTPUT_FORMAT", OutputText)
+
+	tests := []struct {
+		name     string
+		args     []string
+		wantJSON bool
+		wantErr  bool
+	}{
+		{name: "default", wantJSON: false},
+		{name: "output json", args: []string{"--output", "json"}, wantJSON: true},
+		{name: "deprecated json", args: []string{"--json"}, wantJSON: true},
+		{name: "json with out-file", args: []string{"-o", "json", "--out-file", "key"}, wantJSON: true},
+		{name: "json with quiet", args: []string{"-o", "json", "-q"}, wantErr: true},
+		{name: "invalid format", args: []string{"-o", "yaml"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cmd := &cobra.Command{Use: "test"}
+			var o SecretOutput
+			o.AddFlags(cmd, "API key")
+			if err := cmd.ParseFlags(tt.args); err != nil {
+				t.Fatal(err)
+			}
+
+			err := o.Resolve(cmd)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Resolve() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil && o.JSON != tt.wantJSON {
+				t.Errorf("JSON = %v, want %v", o.JSON, tt.wantJSON)
+			}
+		})
+	}
+}
+
+func TestSecretOutputFlags(t *testing.T) {
+	cmd := &cobra.Command{Use: "test"}
+	var o SecretOutput
+	o.AddFlags(cmd, "API key")
+
+	for _, name := range []string{"out-file", "credential-helper", "output", "json", "quiet"} {
+		if cmd.Flag(name) == nil {
+			t.Errorf("%s flag not found", name)
+		}
+	}
+}
+
+func TestSecretOutputDeliverFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "api.key")
+	if err := os.WriteFile(path, []byte("old"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	o := &SecretOutput{OutFile: path}
+	if !o.Redirected() {
+		t.Error("Redirected() should be true with --out-file")
+	}
+	if err := o.Deliver("secret", nil); err != nil {
+		t.Fatalf("Deliver failed: %v", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "secret\n" {
+		t.Errorf("file content = %q, want %q", data, "secret\n")
+	}
+
+	if runtime.GOOS != "windows" {
+		info, err := os.Stat(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if info.Mode().Perm() != 0600 {
+			t.Errorf("file mode = %v, want 0600", info.Mode().Perm())
+		}
+	}
+}
+
+func TestSecretOutputDeliverHelper(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("helper test uses sh")
+	}
+
+	path := filepath.Join(t.TempDir(), "helper.out")
+	o := &SecretOutput{CredentialHelper: `cat > "$OUT"; echo "$KEY_NAME" >> "$OUT"`}
+	if err := o.Deliver("secret", map[string]string{"OUT": path, "KEY_NAME": "bot"}); err != nil {
+		t.Fatalf("Deliver failed: %v", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "secret\nbot\n" {
+		t.Errorf("helper received %q, want %q", data, "secret\nbot\n")
+	}
+
+	o = &SecretOutput{CredentialHelper: "exit 3"}
+	if err := o.Deliver("secret", nil); err == nil {
+		t.Error("expected error from failing helper")
+	}
+}
@@ -0,0 +1,139 @@
+package cmdutil
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"golang.org/x/term"
+)
+
+// SecretInput controls where a secret such as an account key is read from: a flag value,
+// a file (--<name>-file), stdin (--<name>-stdin), an environment variable, or a hidden
+// prompt when running in a terminal.
+type SecretInput struct {
+	File   string
+	Stdin  bool
+	EnvVar string
+	// Confirm asks for the secret twice when prompting, e.g. for a new passphrase
+	Confirm bool
+
+	flagName string
+	what     string
+}
+
+// stdin is replaced in tests.
+var stdin io.Reader = os.Stdin
+
+// AddFlags registers --<name>-file and --<name>-stdin. what names the secret in help and error texts.
+func (i *SecretInput) AddFlags(cmd *cobra.Command, name, what string) {
+	i.flagName = name
+	i.what = what
+	cmd.Flags().StringVar(&i.File, name+"-file", "", fmt.Sprintf("Read the %s from this file", what))
+	cmd.Flags().BoolVar(&i.Stdin, name+"-stdin", false, fmt.Sprintf("Read the %s from stdin, e.g. piped from a secret manager", what))
+}
+
+// Read returns the secret. value is the plain flag value, if any. Only one explicit source may
+// be used; the environment variable is consulted next, and a hidden prompt is shown last.
+func (i *SecretInput) Read(value string) (string, error) {
+	sources := 0
+	for _, set := range []bool{value != "", i.File != "", i.Stdin} {
+		if set {
+			sources++
+		}
+	}
+	if sources > 1 {
+		return "", fmt.Errorf("use only one of --%s, --%s-file and --%s-stdin", i.flagName, i.flagName, i.flagName)
+	}
+
+	switch {
+	case value != "":
+		return strings.TrimSpace(value), nil
+	case i.File != "":
+		data, err := os.ReadFile(i.File)
+		if err != nil {
+			return "", fmt.Errorf("failed to read %s file: %w", i.what, err)
+		}
+		return strings.TrimSpace(string(data)), nil
+	case i.Stdin:
+		return i.readLine()
+	}
+
+	if i.EnvVar != "" {
+		if v := strings.TrimSpace(os.Getenv(i.EnvVar)); v != "" {
+			return v, nil
+		}
+	}
+
+	return i.prompt()
+}
+
+// CheckStdinUnused returns an error unless the secret comes from a file or the environment
+// variable, for commands that read other data from stdin; use names that data's flag. Call it
+// before reading stdin, since neither --<name>-stdin nor a prompt could read the secret afterwards.
+func (i *SecretInput) CheckStdinUnused(use string) error {
+	if i.Stdin {
+		return fmt.Errorf("%s and --%s-stdin cannot be used together", use, i.flagName)
+	}
+	if i.File != "" || (i.EnvVar != "" && strings.TrimSpace(os.Getenv(i.EnvVar)) != "") {
+		return nil
+	}
+	hint := fmt.Sprintf("--%s-file", i.flagName)
+	if i.EnvVar != "" {
+		hint += " or set " + i.EnvVar
+	}
+	return fmt.Errorf("%s reads stdin, so the %s cannot be read from it; use %s", use, i.what, hint)
+}
+
+func (i *SecretInput) readLine() (string, error) {
+	line, err := bufio.NewReader(stdin).ReadString('\n')
+	if err != nil && err != io.EOF {
+		return "", fmt.Errorf("failed to read %s from stdin: %w", i.what, err)
+	}
+	return strings.TrimSpace(line), nil
+}
+
+// prompt reads the secret from the terminal without echoing it. When stdin is not a terminal,
+// e.g. `echo key | anytype auth login`, a line is read from it as with --<name>-stdin.
+func (i *SecretInput) prompt() (string, error) {
+	fd := int(os.Stdin.Fd())
+	if !term.IsTerminal(fd) {
+		secret, err := i.readLine()
+		if err != nil || secret != "" {
+			return secret, err
+		}
+		hint := fmt.Sprintf("--%s-file or --%s-stdin", i.flagName, i.flagName)
+		if i.EnvVar != "" {
+			hint += " or set " + i.EnvVar
+		}
+		return "", fmt.Errorf("no %s provided and stdin is not a terminal; use %s", i.what, hint)
+	}
+
+	secret, err := readHidden(fd, fmt.Sprintf("Enter %s: ", i.what))
+	if err != nil {
+		return "", fmt.Errorf("failed to read %s: %w", i.what, err)
+	}
+	if i.Confirm {
+		again, err := readHidden(fd, fmt.Sprintf("Repeat %s: ", i.what))
+		if err != nil {
+			return "", fmt.Errorf("failed to read %s: %w", i.what, err)
+		}
+		if again != secret {
+			return "", fmt.Errorf("%ss do not match", i.what)
+		}
+	}
+	return secret, nil
+}
+
+func readHidden(fd int, prompt string) (string, error) {
+	fmt.Fprint(os.Stderr, prompt)
+	data, err := term.ReadPassword(fd)
+	fmt.Fprintln(os.Stderr)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(data)), nil
+}
@@ -0,0 +1,151 @@
+package cmdutil
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/spf13/cobra"
+)
+
+func newTestSecretInput(envVar string) *SecretInput {
+	cmd := &cobra.Command{Use: "test"}
+	i := &SecretInput{EnvVar: envVar}
+	i.AddFlags(cmd, "account-key", "account key")
+	return i
+}
+
+func TestSecretInputFlags(t *testing.T) {
+	cmd := &cobra.Command{Use: "test"}
+	var i SecretInput
+	i.AddFlags(cmd, "account-key", "account key")
+
+	for _, name := range []string{"account-key-file", "account-key-stdin"} {
+		if cmd.Flag(name) == nil {
+			t.Errorf("%s flag not found", name)
+		}
+	}
+}
+
+func TestSecretInputRead(t *testing.T) {
+	keyFile := filepath.Join(t.TempDir(), "account.key")
+	if err := os.WriteFile(keyFile, []byte("from-file\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("TEST_SECRET_INPUT", "from-env")
+
+	tests := []struct {
+		name    string
+		value   string
+		file    string
+		stdin   string
+		useIn   bool
+		want    string
+		wantErr string
+	}{
+		{name: "flag value", value: " from-flag ", want: "from-flag"},
+		{name: "file", file: keyFile, want: "from-file"},
+		{name: "stdin", stdin: "from-stdin\n", useIn: true, want: "from-stdin"},
+		{name: "stdin without newline", stdin: "from-stdin", useIn: true, want: "from-stdin"},
+		{name: "env fallback", want: "from-env"},
+		{name: "missing file", file: filepath.Join(t.TempDir(), "missing"), wantErr: "failed to read account key file"},
+		{name: "conflicting sources", value: "a", file: keyFile, wantErr: "use only one of"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			oldStdin := stdin
+			stdin = strings.NewReader(tt.stdin)
+			defer func() { stdin = oldStdin }()
+
+			i := newTestSecretInput("TEST_SECRET_INPUT")
+			i.File = tt.file
+			i.Stdin = tt.useIn
+
+			got, err := i.Read(tt.value)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("Read() error = %v, want to contain %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Read() unexpected error: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("Read() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSecretInputPipedStdin(t *testing.T) {
+	tests := []struct {
+		name    string
+		stdin   string
+		want    string
+		wantErr string
+	}{
+		{name: "piped line", stdin: "from-pipe\n", want: "from-pipe"},
+		{name: "empty stdin", stdin: "", wantErr: "stdin is not a terminal"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			oldStdin := stdin
+			stdin = strings.NewReader(tt.stdin)
+			defer func() { stdin = oldStdin }()
+
+			// Under go test os.Stdin is not a terminal, so Read falls back to the piped input
+			got, err := newTestSecretInput("").Read("")
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("Read() error = %v, want to contain %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Read() unexpected error: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("Read() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSecretInputCheckStdinUnused(t *testing.T) {
+	tests := []struct {
+		name    string
+		file    string
+		useIn   bool
+		env     string
+		wantErr string
+	}{
+		{name: "file", file: "passphrase.txt"},
+		{name: "env", env: "from-env"},
+		{name: "stdin flag", useIn: true, wantErr: "cannot be used together"},
+		{name: "prompt", wantErr: "use --account-key-file or set TEST_SECRET_INPUT"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("TEST_SECRET_INPUT", tt.env)
+			i := newTestSecretInput("TEST_SECRET_INPUT")
+			i.File = tt.file
+			i.Stdin = tt.useIn
+
+			err := i.CheckStdinUnused("--in -")
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("CheckStdinUnused() unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("CheckStdinUnused() error = %v, want to contain %q", err, tt.wantErr)
+			}
+		})
+	}
+}
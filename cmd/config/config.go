@@ -4,21 +4,27 @@ import (
 	"github.com/spf13/cobra"
 
 	configGetCmd "github.com/anyproto/anytype-cli/cmd/config/get"
+	configListCmd "github.com/anyproto/anytype-cli/cmd/config/list"
+	configMigrateCmd "github.com/anyproto/anytype-cli/cmd/config/migrate"
 	configResetCmd "github.com/anyproto/anytype-cli/cmd/config/reset"
 	configSetCmd "github.com/anyproto/anytype-cli/cmd/config/set"
 )
 
 func NewConfigCmd() *cobra.Command {
 	cmd := &cobra.Command{
-		Use:    "config <command>",
-		Short:  "Manage configuration",
-		Long:   `Manage Anytype CLI configuration settings`,
-		Hidden: true,
+		Use:   "config <command>",
+		Short: "Manage configuration",
+		Long: `Manage Anytype CLI configuration settings.
+
+Every key can be overridden with an ANYTYPE_<KEY> environment variable, e.g.
+ANYTYPE_LISTEN_ADDRESS for listenAddress.`,
 	}
 
+	cmd.AddCommand(configListCmd.NewListCmd())
 	cmd.AddCommand(configGetCmd.NewGetCmd())
 	cmd.AddCommand(configSetCmd.NewSetCmd())
 	cmd.AddCommand(configResetCmd.NewResetCmd())
+	cmd.AddCommand(configMigrateCmd.NewMigrateCmd())
 
 	return cmd
 }
@@ -10,35 +10,27 @@ func NewGetCmd() *cobra.Command {
 	return &cobra.Command{
 		Use:   "get [key]",
 		Short: "Get a configuration value",
-		Long:  `Get a specific configuration value or all values if no key is specified`,
+		Long:  `Get the effective value of a configuration key, or all values that are set if no key is specified`,
 		RunE: func(cmd *cobra.Command, args []string) error {
 			if len(args) == 0 {
-				accountId, _ := config.GetAccountIdFromConfig()
-				techSpaceId, _ := config.GetTechSpaceIdFromConfig()
-
-				if accountId != "" {
-					output.Info("accountId: %s", accountId)
-				}
-				if techSpaceId != "" {
-					output.Info("techSpaceId: %s", techSpaceId)
+				for _, key := range config.Keys {
+					value, source, err := config.Resolve(key.Name)
+					if err != nil {
+						return output.Error("Failed to read %s: %w", key.Name, err)
+					}
+					if value != "" && source != config.SourceDefault {
+						output.Info("%s: %s", key.Name, value)
+					}
 				}
 				return nil
 			}
 
-			key := args[0]
-			switch key {
-			case "accountId":
-				accountId, _ := config.GetAccountIdFromConfig()
-				if accountId != "" {
-					output.Info(accountId)
-				}
-			case "techSpaceId":
-				techSpaceId, _ := config.GetTechSpaceIdFromConfig()
-				if techSpaceId != "" {
-					output.Info(techSpaceId)
-				}
-			default:
-				return output.Error("unknown config key: %s", key)
+			value, _, err := config.Resolve(args[0])
+			if err != nil {
+				return output.Error("%w", err)
+			}
+			if value != "" {
+				output.Info(value)
 			}
 
 			return nil
@@ -0,0 +1,36 @@
+package list
+
+import (
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+
+	"github.com/anyproto/anytype-cli/core/config"
+	"github.com/anyproto/anytype-cli/core/output"
+)
+
+func NewListCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "list",
+		Short: "List all configuration keys",
+		Long: `List every configuration key with its effective value and where the value comes from:
+a command-line flag, an ANYTYPE_<KEY> environment variable, the config file or the default.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+			fmt.Fprintln(w, "KEY\tVALUE\tSOURCE\tENV\tDESCRIPTION")
+			for _, key := range config.Keys {
+				value, source, err := config.Resolve(key.Name)
+				if err != nil {
+					return output.Error("Failed to read %s: %w", key.Name, err)
+				}
+				if value == "" {
+					value = "-"
+				}
+				fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", key.Name, value, source, key.EnvVar(), key.Description)
+			}
+			return w.Flush()
+		},
+	}
+}
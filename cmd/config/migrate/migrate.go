@@ -0,0 +1,49 @@
+package migrate
+
+import (
+	"github.com/spf13/cobra"
+
+	"github.com/anyproto/anytype-cli/core/config"
+	"github.com/anyproto/anytype-cli/core/output"
+)
+
+func NewMigrateCmd() *cobra.Command {
+	var dryRun bool
+
+	cmd := &cobra.Command{
+		Use:   "migrate",
+		Short: "Upgrade the config file to the current version",
+		Long: `Upgrade the active profile's config file to the schema version of this build.
+
+Older files are also upgraded automatically the first time they are loaded. The original
+file is kept next to it as config.json.v<version>.bak. Use --dry-run to see what would change.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			result, err := config.MigrateConfigFile(config.GetConfigFilePath(), dryRun)
+			if err != nil {
+				return output.Error("Failed to migrate config: %w", err)
+			}
+
+			if !result.Needed() {
+				output.Success("%s is up to date (version %d)", result.Path, result.ToVersion)
+				return nil
+			}
+
+			if dryRun {
+				output.Info("Would upgrade %s from version %d to %d:", result.Path, result.FromVersion, result.ToVersion)
+			} else {
+				output.Success("Upgraded %s from version %d to %d:", result.Path, result.FromVersion, result.ToVersion)
+			}
+			for _, change := range result.Changes {
+				output.Print("  %s", change)
+			}
+			if result.BackupPath != "" {
+				output.Info("Original saved as %s", result.BackupPath)
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().BoolVar(&dryRun, "dry-run", false, "Show the changes without writing the file")
+
+	return cmd
+}
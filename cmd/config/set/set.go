@@ -12,26 +12,22 @@ func NewSetCmd() *cobra.Command {
 	return &cobra.Command{
 		Use:   "set <key> <value>",
 		Short: "Set a configuration value",
-		Long:  `Set a specific configuration value`,
-		Args:  cmdutil.ExactArgs(2, "cannot set config: key and value arguments required"),
+		Long: `Set a configuration value in the config file of the current profile. An empty value
+removes the key so its default applies again. Run 'anytype config list' to see all keys.`,
+		Args: cmdutil.ExactArgs(2, "cannot set config: key and value arguments required"),
 		RunE: func(cmd *cobra.Command, args []string) error {
 			key := args[0]
 			value := args[1]
 
-			switch key {
-			case "accountId":
-				if err := config.SetAccountIdToConfig(value); err != nil {
-					return output.Error("Failed to set account Id: %w", err)
-				}
-			case "techSpaceId":
-				if err := config.SetTechSpaceIdToConfig(value); err != nil {
-					return output.Error("Failed to set tech space Id: %w", err)
-				}
-			default:
-				return output.Error("unknown config key: %s", key)
+			if err := config.SetValue(key, value); err != nil {
+				return output.Error("Failed to set %s: %w", key, err)
 			}
 
 			output.Success("Set %s = %s", key, value)
+			if _, source, err := config.Resolve(key); err == nil && source == config.SourceEnv {
+				k, _ := config.LookupKey(key)
+				output.Warning("%s is set and overrides the config file", k.EnvVar())
+			}
 			return nil
 		},
 	}
@@ -0,0 +1,60 @@
+package create
+
+import (
+	"github.com/spf13/cobra"
+
+	"github.com/anyproto/anytype-cli/cmd/cmdutil"
+	"github.com/anyproto/anytype-cli/core/config"
+	"github.com/anyproto/anytype-cli/core/output"
+)
+
+func NewCreateCmd() *cobra.Command {
+	var (
+		profile config.Profile
+		useFlag bool
+	)
+
+	cmd := &cobra.Command{
+		Use:   "create <name>",
+		Short: "Create a profile",
+		Long: `Create a named profile. Listen addresses that are not given are set to the next
+ports not used by another profile, starting after 31010-31012.`,
+		Args: cmdutil.ExactArgs(1, "cannot create profile: name argument required"),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			name := args[0]
+
+			created, err := config.CreateProfile(name, profile)
+			if err != nil {
+				return output.Error("Failed to create profile: %w", err)
+			}
+
+			output.Success("Profile %s created", name)
+			output.Info("API: %s", created.APIAddress)
+			output.Info("gRPC: %s", created.GRPCAddress)
+			output.Info("gRPC-Web: %s", created.GRPCWebAddress)
+			if created.DataDir != "" {
+				output.Info("Data dir: %s", created.DataDir)
+			}
+			if created.DefaultSpace != "" {
+				output.Info("Default space: %s", created.DefaultSpace)
+			}
+
+			if useFlag {
+				if err := config.UseProfile(name); err != nil {
+					return output.Error("Failed to switch profile: %w", err)
+				}
+				output.Success("Switched to profile %s", name)
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&profile.APIAddress, "listen-address", "", "API listen address in `host:port` format")
+	cmd.Flags().StringVar(&profile.GRPCAddress, "grpc-listen-address", "", "gRPC listen address in `host:port` format")
+	cmd.Flags().StringVar(&profile.GRPCWebAddress, "grpc-web-listen-address", "", "gRPC-Web listen address in `host:port` format")
+	cmd.Flags().StringVar(&profile.DataDir, "data-dir", "", "Data directory (default: a directory of its own under the anytype work dir)")
+	cmd.Flags().StringVar(&profile.DefaultSpace, "default-space", "", "Default space id")
+	cmd.Flags().BoolVar(&useFlag, "use", false, "Switch to the new profile")
+
+	return cmd
+}
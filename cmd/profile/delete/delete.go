@@ -0,0 +1,60 @@
+package delete
+
+import (
+	"bufio"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/anyproto/anytype-cli/cmd/cmdutil"
+	"github.com/anyproto/anytype-cli/core"
+	"github.com/anyproto/anytype-cli/core/config"
+	"github.com/anyproto/anytype-cli/core/output"
+)
+
+func NewDeleteCmd() *cobra.Command {
+	var yesFlag bool
+
+	cmd := &cobra.Command{
+		Use:   "delete <name>",
+		Short: "Delete a profile",
+		Long: `Delete a profile together with its config, API key policies, logs and stored credentials.
+The profile's data directory is kept.`,
+		Args: cmdutil.ExactArgs(1, "cannot delete profile: name argument required"),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			name := args[0]
+			if _, err := config.GetProfile(name); err != nil {
+				return output.Error("Failed to delete profile: %w", err)
+			}
+
+			if !yesFlag {
+				output.Print("This removes profile %s and its stored credentials. Type 'yes' to continue: ", name)
+				answer, _ := bufio.NewReader(os.Stdin).ReadString('\n')
+				if strings.TrimSpace(answer) != "yes" {
+					output.Info("Aborted.")
+					return nil
+				}
+			}
+
+			// Credentials in the keyring or a helper live outside the profile directory
+			previous := config.ActiveProfile()
+			if err := config.SetActiveProfile(name); err == nil {
+				if _, err := core.PurgeCredentials(); err != nil {
+					output.Warning("Failed to remove stored credentials: %v", err)
+				}
+				_ = config.SetActiveProfile(previous)
+			}
+
+			if err := config.DeleteProfile(name); err != nil {
+				return output.Error("Failed to delete profile: %w", err)
+			}
+			output.Success("Profile %s deleted", name)
+			return nil
+		},
+	}
+
+	cmd.Flags().BoolVarP(&yesFlag, "yes", "y", false, "Do not ask for confirmation")
+
+	return cmd
+}
@@ -0,0 +1,56 @@
+package list
+
+import (
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+
+	"github.com/anyproto/anytype-cli/core/config"
+	"github.com/anyproto/anytype-cli/core/output"
+)
+
+func NewListCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "list",
+		Short: "List profiles",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			names, err := config.ListProfiles()
+			if err != nil {
+				return output.Error("Failed to list profiles: %w", err)
+			}
+			current, err := config.CurrentProfile()
+			if err != nil {
+				return output.Error("Failed to list profiles: %w", err)
+			}
+
+			w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+			fmt.Fprintln(w, "CURRENT\tNAME\tAPI\tGRPC\tGRPC-WEB\tDATA DIR\tDEFAULT SPACE")
+			for _, name := range names {
+				p, err := config.GetProfile(name)
+				if err != nil {
+					return output.Error("Failed to read profile %s: %w", name, err)
+				}
+				marker := ""
+				if name == current {
+					marker = "*"
+				}
+				fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\t%s\n", marker, name,
+					orDefault(p.APIAddress, config.DefaultAPIAddress),
+					orDefault(p.GRPCAddress, config.DefaultGRPCAddress),
+					orDefault(p.GRPCWebAddress, config.DefaultGRPCWebAddress),
+					orDefault(p.DataDir, "-"),
+					orDefault(p.DefaultSpace, "-"))
+			}
+			return w.Flush()
+		},
+	}
+}
+
+func orDefault(value, fallback string) string {
+	if value == "" {
+		return fallback
+	}
+	return value
+}
@@ -0,0 +1,35 @@
+package profile
+
+import (
+	"github.com/spf13/cobra"
+
+	profileCreateCmd "github.com/anyproto/anytype-cli/cmd/profile/create"
+	profileDeleteCmd "github.com/anyproto/anytype-cli/cmd/profile/delete"
+	profileListCmd "github.com/anyproto/anytype-cli/cmd/profile/list"
+	profileUseCmd "github.com/anyproto/anytype-cli/cmd/profile/use"
+)
+
+// NewProfileCmd creates the profile command
+func NewProfileCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "profile <command>",
+		Short: "Manage profiles for multiple instances",
+		Long: `Manage named profiles. Each profile has its own listen addresses, data directory,
+config, credentials and API keys, so several headless instances can run side by side.
+
+Select a profile for one command with --profile or ANYTYPE_PROFILE, or make it the
+default with 'anytype profile use'.`,
+		// Profile commands work on profiles.json directly and must keep working
+		// when the current profile is missing
+		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+			return nil
+		},
+	}
+
+	cmd.AddCommand(profileListCmd.NewListCmd())
+	cmd.AddCommand(profileUseCmd.NewUseCmd())
+	cmd.AddCommand(profileCreateCmd.NewCreateCmd())
+	cmd.AddCommand(profileDeleteCmd.NewDeleteCmd())
+
+	return cmd
+}
@@ -0,0 +1,25 @@
+package use
+
+import (
+	"github.com/spf13/cobra"
+
+	"github.com/anyproto/anytype-cli/cmd/cmdutil"
+	"github.com/anyproto/anytype-cli/core/config"
+	"github.com/anyproto/anytype-cli/core/output"
+)
+
+func NewUseCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "use <name>",
+		Short: "Switch the current profile",
+		Long:  "Make a profile the one used by all commands that are run without --profile or ANYTYPE_PROFILE.",
+		Args:  cmdutil.ExactArgs(1, "cannot switch profile: name argument required"),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := config.UseProfile(args[0]); err != nil {
+				return output.Error("Failed to switch profile: %w", err)
+			}
+			output.Success("Switched to profile %s", args[0])
+			return nil
+		},
+	}
+}
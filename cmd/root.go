@@ -5,11 +5,14 @@ import (
 	"os"
 
 	"github.com/anyproto/anytype-cli/core"
+	"github.com/anyproto/anytype-cli/core/config"
 	"github.com/anyproto/anytype-cli/core/output"
 	"github.com/spf13/cobra"
 
+	"github.com/anyproto/anytype-cli/cmd/audit"
 	"github.com/anyproto/anytype-cli/cmd/auth"
-	"github.com/anyproto/anytype-cli/cmd/config"
+	configCmd "github.com/anyproto/anytype-cli/cmd/config"
+	"github.com/anyproto/anytype-cli/cmd/profile"
 	"github.com/anyproto/anytype-cli/cmd/serve"
 	"github.com/anyproto/anytype-cli/cmd/service"
 	"github.com/anyproto/anytype-cli/cmd/shell"
@@ -20,10 +23,17 @@ import (
 
 var (
 	versionFlag bool
+	profileFlag string
 	rootCmd     = &cobra.Command{
 		Use:   "anytype <command> <subcommand> [flags]",
 		Short: "Command-line interface for Anytype",
 		Long:  "Command-line interface for Anytype",
+		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+			if err := config.SetActiveProfile(profileFlag); err != nil {
+				return output.Error("Failed to select profile: %w", err)
+			}
+			return nil
+		},
 		Run: func(cmd *cobra.Command, args []string) {
 			if versionFlag {
 				output.Print(core.GetVersionBrief())
@@ -47,10 +57,13 @@ func Execute() {
 func init() {
 	rootCmd.Flags().BoolVarP(&versionFlag, "version", "v", false, "Show version information")
 	rootCmd.Flags().BoolP("help", "h", false, "Show help for command")
+	rootCmd.PersistentFlags().StringVar(&profileFlag, "profile", "", "Profile to use (default: $ANYTYPE_PROFILE or the current profile)")
 
 	rootCmd.AddCommand(
+		audit.NewAuditCmd(),
 		auth.NewAuthCmd(),
-		config.NewConfigCmd(),
+		configCmd.NewConfigCmd(),
+		profile.NewProfileCmd(),
 		serve.NewServeCmd(),
 		service.NewServiceCmd(),
 		shell.NewShellCmd(rootCmd),
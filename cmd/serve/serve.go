@@ -1,35 +1,128 @@
 package serve
 
 import (
+	"errors"
+	"fmt"
+	"net"
+	"path/filepath"
+
 	"github.com/kardianos/service"
 	"github.com/spf13/cobra"
 
+	"github.com/anyproto/anytype-cli/cmd/cmdutil"
 	"github.com/anyproto/anytype-cli/core/config"
+	"github.com/anyproto/anytype-cli/core/datalock"
+	"github.com/anyproto/anytype-cli/core/grpcserver"
 	"github.com/anyproto/anytype-cli/core/output"
+	"github.com/anyproto/anytype-cli/core/portcheck"
+	"github.com/anyproto/anytype-cli/core/serverconfig"
 	"github.com/anyproto/anytype-cli/core/serviceprogram"
 )
 
 var listenAddress string
 var grpcListenAddress string
 var grpcWebListenAddress string
+var logLevel string
+var configFile string
+var autoPorts bool
+var serverOptions = grpcserver.DefaultOptions()
+var startupOptions = serviceprogram.DefaultStartupOptions()
 
 func NewServeCmd() *cobra.Command {
 	cmd := &cobra.Command{
 		Use:     "serve",
 		Aliases: []string{"start"},
 		Short:   "Run anytype in foreground",
-		Long:    "Run anytype in the foreground. Use Ctrl+C to stop. For background operation, use the service commands instead.",
-		RunE:    runServer,
+		Long: `Run anytype in the foreground. Use Ctrl+C to stop. For background operation, use the service commands instead.
+
+Use --config to read listen addresses, data directory, log level, gRPC limits, TLS,
+webhooks and auto-login settings from a YAML file. Flags take precedence over the file.
+On SIGHUP the file is re-read and the log level, TLS certificate, webhooks and
+auto-login settings are applied without a restart.
+
+Startup waits until the middleware answers requests and the API gateway accepts
+connections; --start-timeout and --ready-timeout bound the two phases. On stop,
+in-flight requests get --shutdown-timeout to finish before they are cut off.
+
+If a port is taken, the error names the process using it. With --auto-ports,
+busy ports are replaced by the next free ones, which are saved to the profile so
+that client commands find the server.`,
+		RunE: runServer,
 	}
 
 	cmd.Flags().StringVar(&listenAddress, "listen-address", config.DefaultAPIAddress, "API listen address in `host:port` format")
 	cmd.Flags().StringVar(&grpcListenAddress, "grpc-listen-address", config.DefaultGRPCAddress, "gRPC listen address in `host:port` format")
 	cmd.Flags().StringVar(&grpcWebListenAddress, "grpc-web-listen-address", config.DefaultGRPCWebAddress, "gRPC-Web listen address in `host:port` format")
 
+	cmd.Flags().StringVar(&configFile, "config", "", "Server config `file` (YAML)")
+	cmd.Flags().StringVar(&logLevel, "log-level", config.LogLevelError, "Log level: DEBUG, INFO, WARN, ERROR or FATAL")
+	cmd.Flags().BoolVar(&autoPorts, "auto-ports", false, "Listen on free ports if the configured ones are in use and save them to the profile")
+	serviceprogram.AddServerOptionFlags(cmd.Flags(), &serverOptions)
+	serviceprogram.AddStartupFlags(cmd.Flags(), &startupOptions)
+
 	return cmd
 }
 
 func runServer(cmd *cobra.Command, args []string) error {
+	configFlags := []struct {
+		flag, key string
+		value     *string
+	}{
+		{"listen-address", "listenAddress", &listenAddress},
+		{"grpc-listen-address", "grpcListenAddress", &grpcListenAddress},
+		{"grpc-web-listen-address", "grpcWebListenAddress", &grpcWebListenAddress},
+		{"log-level", "logLevel", &logLevel},
+	}
+
+	setByFlag := func(key string) bool {
+		for _, f := range configFlags {
+			if f.key == key {
+				return cmd.Flags().Changed(f.flag)
+			}
+		}
+		return false
+	}
+
+	serverCfg := serverconfig.Default()
+	if configFile != "" {
+		path, err := filepath.Abs(configFile)
+		if err != nil {
+			return output.Error("Invalid --config: %w", err)
+		}
+		configFile = path
+		if serverCfg, err = serverconfig.Load(configFile); err != nil {
+			return output.Error("%w", err)
+		}
+		// Flags given on the command line take precedence over the file
+		if err := serverCfg.Apply(setByFlag); err != nil {
+			return output.Error("%w", err)
+		}
+		serviceprogram.ApplyGRPCConfig(cmd.Flags(), &serverOptions, serverCfg.GRPC)
+		serviceprogram.ApplyStartupConfig(cmd.Flags(), &startupOptions, serverCfg.Startup)
+		serviceprogram.ApplyShutdownConfig(cmd.Flags(), &startupOptions, serverCfg.Shutdown)
+	}
+
+	if err := serverOptions.Validate(); err != nil {
+		return output.Error("Invalid server options: %w", err)
+	}
+	if err := startupOptions.Validate(); err != nil {
+		return output.Error("Invalid startup options: %w", err)
+	}
+
+	for _, f := range configFlags {
+		if err := cmdutil.ConfigFlag(cmd, f.flag, f.key, f.value); err != nil {
+			return output.Error("%w", err)
+		}
+	}
+
+	var listeners []net.Listener
+	if autoPorts {
+		var err error
+		if listeners, err = selectFreePorts(); err != nil {
+			return output.Error("%w", err)
+		}
+	}
+
 	svcConfig := &service.Config{
 		Name:        "anytype",
 		DisplayName: "Anytype",
@@ -37,6 +130,13 @@ func runServer(cmd *cobra.Command, args []string) error {
 	}
 
 	prg := serviceprogram.New(listenAddress, grpcListenAddress, grpcWebListenAddress)
+	prg.SetServerOptions(serverOptions)
+	prg.SetStartupOptions(startupOptions)
+	prg.SetServerConfig(configFile, serverCfg)
+	prg.SetFlagOverrides(setByFlag)
+	if listeners != nil {
+		prg.SetListeners(listeners[0], listeners[1], listeners[2])
+	}
 
 	s, err := service.New(prg, svcConfig)
 	if err != nil {
@@ -45,8 +145,115 @@ func runServer(cmd *cobra.Command, args []string) error {
 
 	err = s.Run()
 	if err != nil {
+		var inUse *portcheck.InUseError
+		if errors.As(err, &inUse) && !autoPorts {
+			output.Info("Stop the other process, choose other addresses or use --auto-ports to pick free ports")
+		}
 		return output.Error("service failed: %w", err)
 	}
 
 	return nil
 }
+
+// maxPortAttempts bounds how often selectFreePorts selects again when another process takes a
+// selected port before it is listened on.
+const maxPortAttempts = 5
+
+// selectFreePorts replaces the listen addresses whose ports are in use, listens on them and
+// saves the new addresses to the active profile. It returns the gRPC, gRPC-Web and API
+// listeners. Ports of other profiles and the desktop app are skipped, so that the profiles stay apart.
+func selectFreePorts() ([]net.Listener, error) {
+	// A server already using this profile's data would make a port change pointless
+	if err := datalock.Check(config.GetDataDir()); err != nil {
+		return nil, err
+	}
+
+	addrs := []struct {
+		key   string
+		value *string
+	}{
+		{"grpcListenAddress", &grpcListenAddress},
+		{"grpcWebListenAddress", &grpcWebListenAddress},
+		{"listenAddress", &listenAddress},
+	}
+	current := make([]string, len(addrs))
+	for i, a := range addrs {
+		current[i] = *a.value
+	}
+
+	var selected []string
+	var listeners []net.Listener
+	for attempt := 1; listeners == nil; attempt++ {
+		var err error
+		selected, err = portcheck.SelectFree(current, reservedAddresses())
+		if err != nil {
+			return nil, fmt.Errorf("failed to select free ports: %w", err)
+		}
+		// A port can be taken between the check and the listen; select again if it was
+		listeners, err = listenAll(selected)
+		var inUse *portcheck.InUseError
+		if errors.As(err, &inUse) && attempt < maxPortAttempts {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	for i, a := range addrs {
+		if selected[i] == current[i] {
+			continue
+		}
+		output.Info("%s is in use, listening on %s instead", current[i], selected[i])
+		*a.value = selected[i]
+		err := config.SetFlagValue(a.key, selected[i])
+		if err == nil {
+			// Client commands only find the server on the new port through the profile
+			err = config.SetValue(a.key, selected[i])
+		}
+		if err != nil {
+			closeAll(listeners)
+			return nil, fmt.Errorf("failed to save %s to profile %s: %w", a.key, config.ActiveProfile(), err)
+		}
+	}
+	return listeners, nil
+}
+
+// listenAll listens on every address, or on none if one of them fails.
+func listenAll(addrs []string) ([]net.Listener, error) {
+	listeners := make([]net.Listener, 0, len(addrs))
+	for _, addr := range addrs {
+		ln, err := portcheck.Listen(addr)
+		if err != nil {
+			closeAll(listeners)
+			return nil, err
+		}
+		listeners = append(listeners, ln)
+	}
+	return listeners, nil
+}
+
+func closeAll(listeners []net.Listener) {
+	for _, ln := range listeners {
+		ln.Close()
+	}
+}
+
+// reservedAddresses returns the addresses of the desktop app and of the other profiles.
+func reservedAddresses() []string {
+	reserved := []string{
+		net.JoinHostPort(config.LocalhostIP, config.DesktopGRPCPort),
+		net.JoinHostPort(config.LocalhostIP, config.DesktopGRPCWebPort),
+		net.JoinHostPort(config.LocalhostIP, config.DesktopAPIPort),
+	}
+	profiles, err := config.LoadProfiles()
+	if err != nil {
+		return reserved
+	}
+	for name, p := range profiles.Profiles {
+		if name != config.ActiveProfile() {
+			reserved = append(reserved, p.GRPCAddress, p.GRPCWebAddress, p.APIAddress)
+		}
+	}
+	return reserved
+}
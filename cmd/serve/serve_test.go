@@ -117,3 +117,40 @@ func TestServeCmd_GRPCWebListenAddressFlagCustomValue(t *testing.T) {
 		t.Errorf("grpc-web-listen-address value = %v, want %v", flag.Value.String(), customAddr)
 	}
 }
+
+func TestServeCmd_ServerOptionFlags(t *testing.T) {
+	cmd := NewServeCmd()
+
+	for _, name := range []string{
+		"grpc-max-recv-msg-size",
+		"grpc-max-send-msg-size",
+		"grpc-max-concurrent-streams",
+		"grpc-keepalive-time",
+		"grpc-keepalive-timeout",
+		"grpc-keepalive-min-time",
+		"grpc-keepalive-permit-without-stream",
+		"grpc-max-connection-idle",
+		"grpc-web-idle-timeout",
+	} {
+		if cmd.Flag(name) == nil {
+			t.Errorf("%s flag not found", name)
+		}
+	}
+
+	flag := cmd.Flag("grpc-max-recv-msg-size")
+	if flag.DefValue != "20971520" {
+		t.Errorf("grpc-max-recv-msg-size default = %v, want 20971520", flag.DefValue)
+	}
+}
+
+func TestServeCmd_AutoPortsFlag(t *testing.T) {
+	cmd := NewServeCmd()
+
+	flag := cmd.Flag("auto-ports")
+	if flag == nil {
+		t.Fatal("auto-ports flag not found")
+	}
+	if flag.DefValue != "false" {
+		t.Errorf("auto-ports default = %v, want false", flag.DefValue)
+	}
+}
@@ -1,10 +1,21 @@
 package install
 
 import (
+	"errors"
+	"fmt"
+	"maps"
+	"path/filepath"
+	"slices"
+	"strings"
+
 	"github.com/spf13/cobra"
 
+	"github.com/anyproto/anytype-cli/cmd/cmdutil"
 	"github.com/anyproto/anytype-cli/core/config"
+	"github.com/anyproto/anytype-cli/core/grpcserver"
 	"github.com/anyproto/anytype-cli/core/output"
+	"github.com/anyproto/anytype-cli/core/serverconfig"
+	"github.com/anyproto/anytype-cli/core/serviceinfo"
 	"github.com/anyproto/anytype-cli/core/serviceprogram"
 )
 
@@ -12,12 +23,108 @@ func NewInstallCmd() *cobra.Command {
 	var listenAddress string
 	var grpcListenAddress string
 	var grpcWebListenAddress string
+	var configFile string
+	var name string
+	serverOptions := grpcserver.DefaultOptions()
+	startupOptions := serviceprogram.DefaultStartupOptions()
+	installOptions := serviceprogram.DefaultInstallOptions()
 
 	cmd := &cobra.Command{
 		Use:   "install",
 		Short: "Install as a user service",
+		Long: `Install anytype as a user service.
+
+With --config the service runs 'serve --config <file>', so later changes to the file
+apply on restart, or on SIGHUP for the settings that can be reloaded.
+
+With --name the service is installed as anytype-<name> and serves the profile of the same
+name, which keeps its own account, data, logs and ports. The profile is created if needed,
+on ports no other instance or the Anytype desktop app (31007-31009) uses.
+
+--env, --working-dir, --restart and --restart-delay are written into the service definition.
+On systemd --memory-max, --memory-high and --hardening add resource limits and sandboxing
+to the unit.`,
 		RunE: func(cmd *cobra.Command, args []string) error {
-			s, err := serviceprogram.GetServiceWithAddresses(listenAddress, grpcListenAddress, grpcWebListenAddress)
+			if err := serviceinfo.ValidateInstanceName(name); err != nil {
+				return output.Error("%w", err)
+			}
+			instances, err := serviceinfo.ListInstances()
+			if err != nil && !errors.Is(err, serviceinfo.ErrUnsupported) {
+				return output.Error("Failed to list installed services: %w", err)
+			}
+			serverCfg := serverconfig.Default()
+			if configFile != "" {
+				path, err := filepath.Abs(configFile)
+				if err != nil {
+					return output.Error("Invalid --config: %w", err)
+				}
+				cfg, err := serverconfig.Load(path)
+				if err != nil {
+					return output.Error("%w", err)
+				}
+				configFile = path
+				serverCfg = cfg
+			}
+
+			if err := serverOptions.Validate(); err != nil {
+				return output.Error("Invalid server options: %w", err)
+			}
+			if err := startupOptions.Validate(); err != nil {
+				return output.Error("Invalid startup options: %w", err)
+			}
+			if installOptions.WorkingDir != "" {
+				dir, err := filepath.Abs(installOptions.WorkingDir)
+				if err != nil {
+					return output.Error("Invalid --working-dir: %w", err)
+				}
+				installOptions.WorkingDir = dir
+			}
+			if err := installOptions.Validate(); err != nil {
+				return output.Error("Invalid service options: %w", err)
+			}
+			for _, flag := range installOptions.Unsupported() {
+				output.Warning("%s is not supported by the service manager on this platform and is ignored", flag)
+			}
+			for _, warning := range installOptions.Warnings() {
+				output.Warning("%s", warning)
+			}
+			if name != serviceinfo.DefaultInstance {
+				if err := selectInstanceProfile(cmd, name, instances); err != nil {
+					return output.Error("%w", err)
+				}
+			}
+			for _, f := range []struct {
+				flag, key string
+				value     *string
+			}{
+				{"listen-address", "listenAddress", &listenAddress},
+				{"grpc-listen-address", "grpcListenAddress", &grpcListenAddress},
+				{"grpc-web-listen-address", "grpcWebListenAddress", &grpcWebListenAddress},
+			} {
+				if err := cmdutil.ConfigFlag(cmd, f.flag, f.key, f.value); err != nil {
+					return output.Error("%w", err)
+				}
+			}
+
+			addrs := []string{grpcListenAddress, grpcWebListenAddress, listenAddress}
+			if configFile != "" {
+				addrs = configAddresses(cmd, serverCfg, addrs)
+			}
+			if err := serviceinfo.CheckPorts(addrs, instances, name); err != nil {
+				return output.Error("Cannot install %s: %w", serviceinfo.ServiceName(name), err)
+			}
+
+			s, err := serviceprogram.GetServiceFromOptions(serviceprogram.ServiceOptions{
+				Instance:      name,
+				APIAddr:       listenAddress,
+				GRPCAddr:      grpcListenAddress,
+				GRPCWebAddr:   grpcWebListenAddress,
+				ServerOptions: serverOptions,
+				ConfigFile:    configFile,
+				Watchdog:      serverCfg.Watchdog,
+				Startup:       startupOptions,
+				Install:       installOptions,
+			})
 			if err != nil {
 				return output.Error("Failed to create service: %w", err)
 			}
@@ -26,8 +133,22 @@ func NewInstallCmd() *cobra.Command {
 			if err != nil {
 				return output.Error("Failed to install service: %w", err)
 			}
+			if err := installOptions.WriteEnvFile(name); err != nil {
+				return output.Error("Service installed, but %w; reinstall it to retry", err)
+			}
 
-			output.Success("anytype service installed successfully")
+			output.Success("%s service installed successfully", serviceinfo.ServiceName(name))
+			if profile := config.ActiveProfile(); profile != config.DefaultProfile {
+				output.Info("Profile: %s", profile)
+			}
+			if configFile != "" {
+				output.Info("Server config: %s", configFile)
+			}
+			if vars, _ := installOptions.EnvVars(); len(vars) > 0 {
+				// Values may be secrets, so only the names are shown
+				names := slices.Sorted(maps.Keys(vars))
+				output.Info("Environment: %s", strings.Join(names, ", "))
+			}
 			if listenAddress != config.DefaultAPIAddress {
 				output.Info("API will listen on %s", listenAddress)
 			}
@@ -37,11 +158,12 @@ func NewInstallCmd() *cobra.Command {
 			if grpcWebListenAddress != config.DefaultGRPCWebAddress {
 				output.Info("gRPC-Web will listen on %s", grpcWebListenAddress)
 			}
+			hint := cmdutil.InstanceHint(name)
 			output.Print("\nTo manage the service:")
-			output.Print("  Start:   anytype service start")
-			output.Print("  Stop:    anytype service stop")
-			output.Print("  Restart: anytype service restart")
-			output.Print("  Status:  anytype service status")
+			output.Print("  Start:   anytype service start%s", hint)
+			output.Print("  Stop:    anytype service stop%s", hint)
+			output.Print("  Restart: anytype service restart%s", hint)
+			output.Print("  Status:  anytype service status%s", hint)
 
 			return nil
 		},
@@ -50,6 +172,75 @@ func NewInstallCmd() *cobra.Command {
 	cmd.Flags().StringVar(&listenAddress, "listen-address", config.DefaultAPIAddress, "API listen address in `host:port` format")
 	cmd.Flags().StringVar(&grpcListenAddress, "grpc-listen-address", config.DefaultGRPCAddress, "gRPC listen address in `host:port` format")
 	cmd.Flags().StringVar(&grpcWebListenAddress, "grpc-web-listen-address", config.DefaultGRPCWebAddress, "gRPC-Web listen address in `host:port` format")
+	cmdutil.AddInstanceFlag(cmd, &name)
+	cmd.Flags().StringVar(&configFile, "config", "", "Server config `file` (YAML) the service is started with")
+	serviceprogram.AddServerOptionFlags(cmd.Flags(), &serverOptions)
+	serviceprogram.AddStartupFlags(cmd.Flags(), &startupOptions)
+	serviceprogram.AddInstallFlags(cmd.Flags(), &installOptions)
 
 	return cmd
 }
+
+// selectInstanceProfile makes the profile named like the instance active, creating it on ports
+// that the installed instances do not use. Addresses given as flags become the profile's.
+func selectInstanceProfile(cmd *cobra.Command, name string, instances []serviceinfo.Instance) error {
+	if f := cmd.Flag("profile"); f != nil && f.Changed && f.Value.String() != name {
+		return fmt.Errorf("--name %s serves profile %s and cannot be combined with --profile %s", name, name, f.Value.String())
+	}
+
+	if _, err := config.GetProfile(name); err != nil {
+		var profile config.Profile
+		for _, f := range []struct {
+			flag, key string
+			addr      *string
+		}{
+			{"listen-address", "listenAddress", &profile.APIAddress},
+			{"grpc-listen-address", "grpcListenAddress", &profile.GRPCAddress},
+			{"grpc-web-listen-address", "grpcWebListenAddress", &profile.GRPCWebAddress},
+		} {
+			if !cmd.Flags().Changed(f.flag) {
+				continue
+			}
+			value := cmd.Flag(f.flag).Value.String()
+			if key, err := config.LookupKey(f.key); err == nil && key.Validate != nil {
+				if err := key.Validate(value); err != nil {
+					return fmt.Errorf("invalid --%s: %w", f.flag, err)
+				}
+			}
+			*f.addr = value
+		}
+		var avoid []string
+		for _, inst := range instances {
+			avoid = append(avoid, inst.Addresses()...)
+		}
+
+		created, err := config.CreateProfile(name, profile, avoid...)
+		if err != nil {
+			return fmt.Errorf("failed to create profile: %w", err)
+		}
+		output.Info("Created profile %s (gRPC %s, gRPC-Web %s, API %s)", name, created.GRPCAddress, created.GRPCWebAddress, created.APIAddress)
+	}
+
+	if err := config.SetActiveProfile(name); err != nil {
+		return fmt.Errorf("failed to select profile: %w", err)
+	}
+	return nil
+}
+
+// configAddresses returns the gRPC, gRPC-Web and API addresses the service will listen on when
+// the config file sets some of them; flags take precedence over the file.
+func configAddresses(cmd *cobra.Command, cfg *serverconfig.Config, addrs []string) []string {
+	result := append([]string(nil), addrs...)
+	for i, f := range []struct {
+		flag, value string
+	}{
+		{"grpc-listen-address", cfg.GRPCListenAddress},
+		{"grpc-web-listen-address", cfg.GRPCWebListenAddress},
+		{"listen-address", cfg.ListenAddress},
+	} {
+		if f.value != "" && !cmd.Flags().Changed(f.flag) {
+			result[i] = f.value
+		}
+	}
+	return result
+}
@@ -0,0 +1,94 @@
+package list
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/kardianos/service"
+	"github.com/spf13/cobra"
+
+	"github.com/anyproto/anytype-cli/cmd/cmdutil"
+	"github.com/anyproto/anytype-cli/core/output"
+	"github.com/anyproto/anytype-cli/core/serviceinfo"
+	"github.com/anyproto/anytype-cli/core/serviceprogram"
+)
+
+// Entry is an installed instance and whether it runs.
+type Entry struct {
+	serviceinfo.Instance
+	// Status is running, stopped or unknown
+	Status string `json:"status"`
+}
+
+func NewListCmd() *cobra.Command {
+	var format string
+
+	cmd := &cobra.Command{
+		Use:   "list",
+		Short: "List installed service instances",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := cmdutil.ResolveOutputFormat(cmd, &format); err != nil {
+				return output.Error("%w", err)
+			}
+
+			instances, err := serviceinfo.ListInstances()
+			if err != nil {
+				return output.Error("Failed to list installed services: %w", err)
+			}
+
+			entries := make([]Entry, 0, len(instances))
+			for _, inst := range instances {
+				entries = append(entries, Entry{Instance: inst, Status: instanceStatus(inst.Name)})
+			}
+
+			if format == cmdutil.OutputJSON {
+				return cmdutil.PrintJSON(entries)
+			}
+			if len(entries) == 0 {
+				output.Info("No anytype service is installed")
+				output.Info("Run 'anytype service install' to install one")
+				return nil
+			}
+
+			w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+			fmt.Fprintln(w, "NAME\tSERVICE\tSTATUS\tPROFILE\tAPI\tGRPC\tGRPC-WEB")
+			for _, e := range entries {
+				fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\t%s\n", e.Name, e.ServiceName, e.Status,
+					orUnknown(e.Profile), orUnknown(e.APIAddress), orUnknown(e.GRPCAddress), orUnknown(e.GRPCWebAddress))
+			}
+			return w.Flush()
+		},
+	}
+
+	cmdutil.AddOutputFlag(cmd, &format)
+	return cmd
+}
+
+func instanceStatus(name string) string {
+	s, err := serviceprogram.GetInstanceService(name)
+	if err != nil {
+		return "unknown"
+	}
+	status, err := s.Status()
+	switch {
+	case errors.Is(err, service.ErrNotInstalled):
+		return "not-installed"
+	case err != nil:
+		return "unknown"
+	case status == service.StatusRunning:
+		return "running"
+	case status == service.StatusStopped:
+		return "stopped"
+	default:
+		return "unknown"
+	}
+}
+
+func orUnknown(value string) string {
+	if value == "" {
+		return "-"
+	}
+	return value
+}
@@ -0,0 +1,135 @@
+package logs
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"regexp"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/anyproto/anytype-cli/cmd/cmdutil"
+	"github.com/anyproto/anytype-cli/core/config"
+	"github.com/anyproto/anytype-cli/core/output"
+	"github.com/anyproto/anytype-cli/core/serviceinfo"
+	"github.com/anyproto/anytype-cli/core/servicelog"
+)
+
+const (
+	sourceAuto    = "auto"
+	sourceFile    = "file"
+	sourceJournal = "journal"
+)
+
+func NewLogsCmd() *cobra.Command {
+	var lines int
+	var follow bool
+	var since time.Duration
+	var level string
+	var grep string
+	var source string
+	var name string
+
+	cmd := &cobra.Command{
+		Use:   "logs",
+		Short: "Show service logs",
+		Long: `Show the output of the anytype service, optionally filtered by age, level or a pattern.
+
+On Linux the service logs to the systemd journal, which is read with journalctl. Elsewhere
+the service writes a rotating log file to the logs directory of its profile.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := serviceinfo.ValidateInstanceName(name); err != nil {
+				return output.Error("%w", err)
+			}
+			serviceName := serviceinfo.ServiceName(name)
+
+			var filter servicelog.Filter
+			if since > 0 {
+				filter.Since = time.Now().Add(-since)
+			}
+			if level != "" {
+				l, err := servicelog.ParseLevel(level)
+				if err != nil {
+					return output.Error("%w", err)
+				}
+				filter.Level = l
+			}
+			if grep != "" {
+				re, err := regexp.Compile(grep)
+				if err != nil {
+					return output.Error("Invalid --grep pattern: %w", err)
+				}
+				filter.Grep = re
+			}
+
+			switch source {
+			case sourceAuto:
+				source = sourceFile
+				if servicelog.JournalAvailable() {
+					source = sourceJournal
+				}
+			case sourceFile, sourceJournal:
+			default:
+				return output.Error("invalid --source %q: expected auto, file or journal", source)
+			}
+
+			// The service may have been installed for another profile than the current one
+			if installed, err := serviceinfo.InstalledArguments(serviceName); err == nil {
+				if profile, ok := serviceinfo.FlagValue(installed, "profile"); ok {
+					if err := config.SetActiveProfile(profile); err != nil {
+						return output.Error("Failed to select the service's profile: %w", err)
+					}
+				}
+			} else if name != serviceinfo.DefaultInstance {
+				if err := config.SetActiveProfile(name); err != nil {
+					return output.Error("Failed to select the instance's profile: %w", err)
+				}
+			}
+
+			ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+			defer stop()
+
+			if source == sourceJournal {
+				if err := servicelog.ReadJournal(ctx, serviceName+".service", lines, follow, filter, printEntry); err != nil {
+					return output.Error("Failed to read the journal: %w", err)
+				}
+				return nil
+			}
+
+			path := servicelog.GetFilePath()
+			entries, err := servicelog.Tail(path, lines, filter)
+			if err != nil {
+				return output.Error("Failed to read service log: %w", err)
+			}
+			if len(entries) == 0 && !follow {
+				output.Info("No log entries found in %s", path)
+				return nil
+			}
+			for _, e := range entries {
+				printEntry(e)
+			}
+
+			if follow {
+				if err := servicelog.Follow(path, filter, 500*time.Millisecond, ctx.Done(), printEntry); err != nil {
+					return output.Error("Failed to follow service log: %w", err)
+				}
+			}
+			return nil
+		},
+	}
+
+	cmdutil.AddInstanceFlag(cmd, &name)
+	cmd.Flags().IntVarP(&lines, "lines", "n", 50, "Number of entries to show (0 for all)")
+	cmd.Flags().BoolVarP(&follow, "follow", "f", false, "Keep streaming new entries")
+	cmd.Flags().DurationVar(&since, "since", 0, "Only show entries newer than this `duration` (e.g. 1h)")
+	cmd.Flags().StringVar(&level, "level", "", "Only show entries at this level or above: DEBUG, INFO, WARN, ERROR or FATAL")
+	cmd.Flags().StringVar(&grep, "grep", "", "Only show entries matching this regular `expression`")
+	cmd.Flags().StringVar(&source, "source", sourceAuto, "Where to read logs from: auto, file or journal")
+
+	return cmd
+}
+
+func printEntry(e servicelog.Entry) {
+	output.Print("%s %-5s %s", e.Time.Local().Format("2006-01-02 15:04:05"), e.Level, e.Message)
+}
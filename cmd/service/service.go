@@ -4,6 +4,8 @@ import (
 	"github.com/spf13/cobra"
 
 	serviceInstallCmd "github.com/anyproto/anytype-cli/cmd/service/install"
+	serviceListCmd "github.com/anyproto/anytype-cli/cmd/service/list"
+	serviceLogsCmd "github.com/anyproto/anytype-cli/cmd/service/logs"
 	serviceRestartCmd "github.com/anyproto/anytype-cli/cmd/service/restart"
 	serviceStartCmd "github.com/anyproto/anytype-cli/cmd/service/start"
 	serviceStatusCmd "github.com/anyproto/anytype-cli/cmd/service/status"
@@ -15,7 +17,10 @@ func NewServiceCmd() *cobra.Command {
 	cmd := &cobra.Command{
 		Use:   "service <command>",
 		Short: "Manage anytype as a user service",
-		Long:  "Install, uninstall, start, stop, and check status of anytype running as a user service.",
+		Long: `Install, uninstall, start, stop, check status and read logs of anytype running as a user service.
+
+Several instances can be installed side by side with --name; each serves the profile of the same
+name. 'anytype service list' shows the installed instances.`,
 	}
 
 	cmd.AddCommand(serviceInstallCmd.NewInstallCmd())
@@ -24,6 +29,8 @@ func NewServiceCmd() *cobra.Command {
 	cmd.AddCommand(serviceStopCmd.NewStopCmd())
 	cmd.AddCommand(serviceRestartCmd.NewRestartCmd())
 	cmd.AddCommand(serviceStatusCmd.NewStatusCmd())
+	cmd.AddCommand(serviceLogsCmd.NewLogsCmd())
+	cmd.AddCommand(serviceListCmd.NewListCmd())
 
 	return cmd
 }
@@ -26,6 +26,8 @@ func TestServiceCmd_HasAllSubcommands(t *testing.T) {
 		"stop",
 		"restart",
 		"status",
+		"logs",
+		"list",
 	}
 
 	subcommands := cmd.Commands()
@@ -2,45 +2,267 @@ package status
 
 import (
 	"errors"
+	"fmt"
+	"time"
 
 	"github.com/kardianos/service"
 	"github.com/spf13/cobra"
 
+	"github.com/anyproto/anytype-cli/cmd/cmdutil"
+	"github.com/anyproto/anytype-cli/core"
+	"github.com/anyproto/anytype-cli/core/config"
 	"github.com/anyproto/anytype-cli/core/output"
+	"github.com/anyproto/anytype-cli/core/serverconfig"
+	"github.com/anyproto/anytype-cli/core/serverstatus"
+	"github.com/anyproto/anytype-cli/core/serviceinfo"
 	"github.com/anyproto/anytype-cli/core/serviceprogram"
 )
 
+const portTimeout = 500 * time.Millisecond
+
+// Report is the service status printed by `service status`.
+type Report struct {
+	Instance    string `json:"instance"`
+	ServiceName string `json:"serviceName"`
+	// Status is running, stopped, not-installed or unknown
+	Status        string     `json:"status"`
+	Profile       string     `json:"profile"`
+	ConfigFile    string     `json:"configFile,omitempty"`
+	PID           int        `json:"pid,omitempty"`
+	StartedAt     time.Time  `json:"startedAt,omitzero"`
+	UptimeSeconds int64      `json:"uptimeSeconds,omitempty"`
+	Listeners     []Listener `json:"listeners"`
+	CLIVersion    string     `json:"cliVersion"`
+	HeartVersion  string     `json:"heartVersion"`
+	// Auth is the server's login state; missing if the server did not report one
+	Auth *serverstatus.Auth `json:"auth,omitempty"`
+	// SpaceCount is set when the account is logged in and the spaces could be listed
+	SpaceCount     *int                     `json:"spaceCount,omitempty"`
+	LastLoginError *serverstatus.LoginError `json:"lastLoginError,omitempty"`
+	// Restarts is the history of unplanned restarts, oldest first
+	Restarts []serverstatus.Restart `json:"restarts,omitempty"`
+}
+
+// Listener is a configured listen address and whether it accepts connections.
+type Listener struct {
+	Name      string `json:"name"`
+	Address   string `json:"address"`
+	Accepting bool   `json:"accepting"`
+}
+
 func NewStatusCmd() *cobra.Command {
-	return &cobra.Command{
+	var format string
+	var name string
+
+	cmd := &cobra.Command{
 		Use:   "status",
 		Short: "Check service status",
+		Long: `Show whether the service is running, its PID and uptime, the addresses it was
+installed with and whether they accept connections, versions and the account state.`,
 		RunE: func(cmd *cobra.Command, args []string) error {
-			s, err := serviceprogram.GetService()
+			if err := cmdutil.ResolveOutputFormat(cmd, &format); err != nil {
+				return output.Error("%w", err)
+			}
+			if err := serviceinfo.ValidateInstanceName(name); err != nil {
+				return output.Error("%w", err)
+			}
+
+			s, err := serviceprogram.GetInstanceService(name)
 			if err != nil {
 				return output.Error("Failed to create service: %w", err)
 			}
 
+			report := &Report{
+				Instance:     name,
+				ServiceName:  serviceinfo.ServiceName(name),
+				CLIVersion:   core.GetVersion(),
+				HeartVersion: core.GetHeartVersion(),
+			}
 			status, err := s.Status()
-			if err != nil {
-				if errors.Is(err, service.ErrNotInstalled) {
-					output.Info("anytype service is not installed")
-					output.Info("Run 'anytype service install' to install it")
-					return nil
-				}
+			switch {
+			case errors.Is(err, service.ErrNotInstalled):
+				report.Status = "not-installed"
+			case err != nil:
 				return output.Error("Failed to get service status: %w", err)
+			case status == service.StatusRunning:
+				report.Status = "running"
+			case status == service.StatusStopped:
+				report.Status = "stopped"
+			default:
+				report.Status = "unknown"
 			}
 
-			switch status {
-			case service.StatusRunning:
-				output.Success("anytype service is running")
-			case service.StatusStopped:
-				output.Info("anytype service is stopped")
-				output.Info("Run 'anytype service start' to start it")
-			default:
-				output.Info("anytype service status: %v", status)
+			if report.Status != "not-installed" {
+				if err := applyInstalledArguments(report); err != nil {
+					output.Warning("Could not read the installed service arguments, showing the current profile's settings: %v", err)
+				}
+			}
+			report.Profile = config.ActiveProfile()
+			report.Listeners = []Listener{
+				{Name: "grpc", Address: config.GetGRPCAddress()},
+				{Name: "grpc-web", Address: config.GetGRPCWebAddress()},
+				{Name: "api", Address: config.GetAPIAddress()},
+			}
+
+			if report.Status == "running" {
+				collectRuntime(report)
+			}
+			if report.Status != "not-installed" {
+				if restarts, err := serverstatus.ReadRestarts(); err == nil {
+					report.Restarts = restarts
+				}
 			}
 
+			if format == cmdutil.OutputJSON {
+				return cmdutil.PrintJSON(report)
+			}
+			printReport(report)
 			return nil
 		},
 	}
+
+	cmdutil.AddInstanceFlag(cmd, &name)
+	cmdutil.AddOutputFlag(cmd, &format)
+	return cmd
+}
+
+// applyInstalledArguments switches to the profile, config file and addresses the service
+// was installed with, so the report describes the service rather than the current shell.
+func applyInstalledArguments(report *Report) error {
+	args, err := serviceinfo.InstalledArguments(report.ServiceName)
+	if err != nil {
+		return err
+	}
+
+	if profile, ok := serviceinfo.FlagValue(args, "profile"); ok {
+		if err := config.SetActiveProfile(profile); err != nil {
+			return err
+		}
+	}
+
+	flags := map[string]string{
+		"listen-address":          "listenAddress",
+		"grpc-listen-address":     "grpcListenAddress",
+		"grpc-web-listen-address": "grpcWebListenAddress",
+	}
+	if path, ok := serviceinfo.FlagValue(args, "config"); ok {
+		report.ConfigFile = path
+		cfg, err := serverconfig.Load(path)
+		if err != nil {
+			return err
+		}
+		err = cfg.Apply(func(key string) bool {
+			for flag, k := range flags {
+				if k == key {
+					_, given := serviceinfo.FlagValue(args, flag)
+					return given
+				}
+			}
+			return false
+		})
+		if err != nil {
+			return err
+		}
+	}
+	for flag, key := range flags {
+		if value, ok := serviceinfo.FlagValue(args, flag); ok {
+			if err := config.SetFlagValue(key, value); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// collectRuntime fills in what the running server reports about itself.
+func collectRuntime(report *Report) {
+	for i := range report.Listeners {
+		report.Listeners[i].Accepting = serviceinfo.PortOpen(report.Listeners[i].Address, portTimeout)
+	}
+
+	st, err := serverstatus.Read()
+	if err != nil {
+		return
+	}
+	report.PID = st.PID
+	report.StartedAt = st.StartedAt
+	report.UptimeSeconds = int64(time.Since(st.StartedAt).Seconds())
+	if st.HeartVersion != "" {
+		report.HeartVersion = st.HeartVersion
+	}
+	report.Auth = &st.Auth
+	report.LastLoginError = st.LastLoginError
+
+	if st.Auth.State == serverstatus.AuthLoggedIn && report.Listeners[0].Accepting {
+		if spaces, err := core.ListSpaces(); err == nil {
+			count := len(spaces)
+			report.SpaceCount = &count
+		}
+	}
+}
+
+func printReport(r *Report) {
+	switch r.Status {
+	case "not-installed":
+		output.Info("%s service is not installed", r.ServiceName)
+		output.Info("Run 'anytype service install%s' to install it", cmdutil.InstanceHint(r.Instance))
+		return
+	case "running":
+		output.Success("%s service is running", r.ServiceName)
+	case "stopped":
+		output.Info("%s service is stopped", r.ServiceName)
+	default:
+		output.Info("%s service status: %s", r.ServiceName, r.Status)
+	}
+
+	output.Info("Profile:    %s", r.Profile)
+	if r.ConfigFile != "" {
+		output.Info("Config:     %s", r.ConfigFile)
+	}
+	if r.PID != 0 {
+		output.Info("PID:        %d", r.PID)
+		output.Info("Uptime:     %s (since %s)", time.Duration(r.UptimeSeconds)*time.Second, r.StartedAt.Local().Format(time.DateTime))
+	}
+	for _, l := range r.Listeners {
+		state := ""
+		if r.Status == "running" {
+			state = "  ✗ not accepting connections"
+			if l.Accepting {
+				state = "  ✓ accepting connections"
+			}
+		}
+		output.Info("%-11s %s%s", fmt.Sprintf("%s:", listenerLabel(l.Name)), l.Address, state)
+	}
+	output.Info("Version:    anytype-cli %s, anytype-heart %s", r.CLIVersion, r.HeartVersion)
+	if r.Auth != nil {
+		output.Info("Account:    %s", r.Auth.Describe())
+	}
+	if r.SpaceCount != nil {
+		output.Info("Spaces:     %d", *r.SpaceCount)
+	}
+	if r.LastLoginError != nil {
+		output.Info("Last login error: %s (%s)", r.LastLoginError.Reason, r.LastLoginError.At.Local().Format(time.DateTime))
+	}
+	if n := len(r.Restarts); n > 0 {
+		last := r.Restarts[n-1]
+		output.Info("Restarts:   %d recorded, last at %s: %s", n, last.At.Local().Format(time.DateTime), last.Cause)
+		for _, dump := range last.Dumps {
+			output.Info("            goroutine dump: %s", dump)
+		}
+	}
+
+	if r.Status == "stopped" {
+		output.Info("Run 'anytype service start%s' to start it", cmdutil.InstanceHint(r.Instance))
+	}
+}
+
+func listenerLabel(name string) string {
+	switch name {
+	case "grpc":
+		return "gRPC"
+	case "grpc-web":
+		return "gRPC-Web"
+	default:
+		return "API"
+	}
 }
@@ -6,24 +6,33 @@ import (
 	"github.com/kardianos/service"
 	"github.com/spf13/cobra"
 
+	"github.com/anyproto/anytype-cli/cmd/cmdutil"
 	"github.com/anyproto/anytype-cli/core/output"
+	"github.com/anyproto/anytype-cli/core/serviceinfo"
 	"github.com/anyproto/anytype-cli/core/serviceprogram"
 )
 
 func NewStopCmd() *cobra.Command {
-	return &cobra.Command{
+	var name string
+
+	cmd := &cobra.Command{
 		Use:   "stop",
 		Short: "Stop the service",
 		RunE: func(cmd *cobra.Command, args []string) error {
-			s, err := serviceprogram.GetService()
+			if err := serviceinfo.ValidateInstanceName(name); err != nil {
+				return output.Error("%w", err)
+			}
+			serviceName := serviceinfo.ServiceName(name)
+
+			s, err := serviceprogram.GetInstanceService(name)
 			if err != nil {
 				return output.Error("Failed to create service: %w", err)
 			}
 
 			_, err = s.Status()
 			if err != nil && errors.Is(err, service.ErrNotInstalled) {
-				output.Warning("anytype service is not installed")
-				output.Info("Run 'anytype service install' to install it first")
+				output.Warning("%s service is not installed", serviceName)
+				output.Info("Run 'anytype service install%s' to install it first", cmdutil.InstanceHint(name))
 				return nil
 			}
 
@@ -32,8 +41,11 @@ func NewStopCmd() *cobra.Command {
 				return output.Error("Failed to stop service: %w", err)
 			}
 
-			output.Success("anytype service stopped")
+			output.Success("%s service stopped", serviceName)
 			return nil
 		},
 	}
+
+	cmdutil.AddInstanceFlag(cmd, &name)
+	return cmd
 }
@@ -3,16 +3,26 @@ package uninstall
 import (
 	"github.com/spf13/cobra"
 
+	"github.com/anyproto/anytype-cli/cmd/cmdutil"
 	"github.com/anyproto/anytype-cli/core/output"
+	"github.com/anyproto/anytype-cli/core/serviceinfo"
 	"github.com/anyproto/anytype-cli/core/serviceprogram"
 )
 
 func NewUninstallCmd() *cobra.Command {
-	return &cobra.Command{
+	var name string
+
+	cmd := &cobra.Command{
 		Use:   "uninstall",
 		Short: "Uninstall the user service",
+		Long: `Uninstall the user service. For a named instance the profile of the same name, with its
+account, data and logs, is kept; remove it with 'anytype profile delete'.`,
 		RunE: func(cmd *cobra.Command, args []string) error {
-			s, err := serviceprogram.GetService()
+			if err := serviceinfo.ValidateInstanceName(name); err != nil {
+				return output.Error("%w", err)
+			}
+
+			s, err := serviceprogram.GetInstanceService(name)
 			if err != nil {
 				return output.Error("Failed to create service: %w", err)
 			}
@@ -22,8 +32,18 @@ func NewUninstallCmd() *cobra.Command {
 				return output.Error("Failed to uninstall service: %w", err)
 			}
 
-			output.Success("anytype service uninstalled successfully")
+			if err := serviceprogram.RemoveEnvFile(name); err != nil {
+				output.Warning("%v", err)
+			}
+
+			output.Success("%s service uninstalled successfully", serviceinfo.ServiceName(name))
+			if name != serviceinfo.DefaultInstance {
+				output.Info("Profile %s was kept; remove it with 'anytype profile delete %s'", name, name)
+			}
 			return nil
 		},
 	}
+
+	cmdutil.AddInstanceFlag(cmd, &name)
+	return cmd
 }
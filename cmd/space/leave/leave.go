@@ -10,12 +10,15 @@ import (
 
 func NewLeaveCmd() *cobra.Command {
 	cmd := &cobra.Command{
-		Use:   "leave <space-id>",
+		Use:   "leave [space-id]",
 		Short: "Leave a space",
-		Long:  "Leave a space and stop sharing it",
-		Args:  cmdutil.ExactArgs(1, "cannot leave space: space-id argument required"),
+		Long:  "Leave a space and stop sharing it. Without a space id, the defaultSpace config key is used.",
+		Args:  cobra.MaximumNArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
-			spaceId := args[0]
+			spaceId, err := cmdutil.SpaceArg(args, "cannot leave space: space-id argument required")
+			if err != nil {
+				return err
+			}
 
 			if err := core.LeaveSpace(spaceId); err != nil {
 				return output.Error("Failed to leave space: %w", err)
@@ -0,0 +1,181 @@
+package apigateway
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"sync/atomic"
+	"time"
+
+	"github.com/anyproto/anytype-cli/core/output"
+	"github.com/anyproto/anytype-cli/core/portcheck"
+)
+
+const readHeaderTimeout = 30 * time.Second
+
+// unavailableHeader marks the gateway's own answer while the middleware's API is not available
+const unavailableHeader = "X-Anytype-Api-Unavailable"
+
+// Gateway owns the public JSON API address and forwards requests to the middleware's API server.
+// Every request passes the per-key limiter.
+type Gateway struct {
+	publicAddr string
+
+	listener net.Listener
+	server   *http.Server
+	cert     atomic.Pointer[tls.Certificate]
+}
+
+// Upstream returns the handler of the middleware's API, or nil while it is not available,
+// e.g. before an account is logged in.
+type Upstream func() http.Handler
+
+// New reserves the public listen address.
+func New(publicAddr string, upstream Upstream, limiter *Limiter) (*Gateway, error) {
+	ln, err := net.Listen("tcp", publicAddr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to listen on %s: %w", publicAddr, portcheck.Diagnose(publicAddr, err))
+	}
+	return NewWithListener(publicAddr, ln, upstream, limiter), nil
+}
+
+// NewWithListener is New for a listener opened elsewhere, e.g. passed in by systemd socket activation.
+// publicAddr is the address clients are configured with.
+func NewWithListener(publicAddr string, ln net.Listener, upstream Upstream, limiter *Limiter) *Gateway {
+	api := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		h := upstream()
+		if h == nil {
+			writeUnavailable(w)
+			return
+		}
+		h.ServeHTTP(w, r)
+	})
+
+	return &Gateway{
+		publicAddr: publicAddr,
+		listener:   ln,
+		server: &http.Server{
+			Handler:           limiter.Middleware(api),
+			ReadHeaderTimeout: readHeaderTimeout,
+		},
+	}
+}
+
+// NewProxy returns a handler that forwards requests to the API server listening on addr. While
+// that server does not accept connections, e.g. while an account starts, the gateway answers
+// that the API is not available.
+func NewProxy(addr string) http.Handler {
+	proxy := httputil.NewSingleHostReverseProxy(&url.URL{Scheme: "http", Host: addr})
+	proxy.ErrorHandler = func(w http.ResponseWriter, r *http.Request, err error) {
+		writeUnavailable(w)
+	}
+	return proxy
+}
+
+func writeUnavailable(w http.ResponseWriter) {
+	w.Header().Set(unavailableHeader, "1")
+	writeAPIError(w, http.StatusServiceUnavailable, "service_unavailable", "API server is not available. Log in with: anytype auth login")
+}
+
+// LoadCertificate reads a TLS certificate and key. If called before Serve, the gateway serves
+// HTTPS; calling it again later replaces the certificate for new connections.
+func (g *Gateway) LoadCertificate(certFile, keyFile string) error {
+	cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+	if err != nil {
+		return fmt.Errorf("failed to load TLS certificate: %w", err)
+	}
+	g.cert.Store(&cert)
+	return nil
+}
+
+// Serve starts accepting connections in the background.
+func (g *Gateway) Serve() {
+	ln := g.listener
+	if g.cert.Load() != nil {
+		ln = tls.NewListener(ln, &tls.Config{
+			MinVersion: tls.VersionTLS12,
+			GetCertificate: func(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+				return g.cert.Load(), nil
+			},
+		})
+	}
+	go func() {
+		if err := g.server.Serve(ln); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			output.Warning("API gateway error: %v", err)
+		}
+	}()
+}
+
+// Probe sends a request through the gateway's listener, the way clients reach it, and reports
+// whether the middleware's API answered it. An error means the gateway itself did not answer.
+func (g *Gateway) Probe(ctx context.Context) (upstream bool, err error) {
+	scheme := "http"
+	transport := &http.Transport{DisableKeepAlives: true}
+	if g.cert.Load() != nil {
+		scheme = "https"
+		// The certificate is issued for the name clients use, not necessarily for the loopback
+		// address probed here; no credentials are sent
+		transport.TLSClientConfig = &tls.Config{InsecureSkipVerify: true}
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, scheme+"://"+portcheck.DialAddr(g.PublicAddr())+"/", nil)
+	if err != nil {
+		return false, err
+	}
+	resp, err := (&http.Client{Transport: transport}).Do(req)
+	if err != nil {
+		return false, err
+	}
+	resp.Body.Close()
+	if resp.Header.Get(unavailableHeader) != "" {
+		return false, nil
+	}
+	if resp.StatusCode >= http.StatusInternalServerError {
+		return false, fmt.Errorf("API answered %s", resp.Status)
+	}
+	return true, nil
+}
+
+// PublicAddr returns the address clients connect to.
+func (g *Gateway) PublicAddr() string {
+	return g.listener.Addr().String()
+}
+
+// Owns reports whether addr refers to the address the gateway listens on.
+func (g *Gateway) Owns(addr string) bool {
+	if addr == "" {
+		return false
+	}
+	return addr == g.publicAddr || sameTCPAddr(addr, g.listener.Addr().String())
+}
+
+func sameTCPAddr(a, b string) bool {
+	ta, err := net.ResolveTCPAddr("tcp", a)
+	if err != nil {
+		return false
+	}
+	tb, err := net.ResolveTCPAddr("tcp", b)
+	if err != nil {
+		return false
+	}
+	return ta.Port == tb.Port && ta.IP.Equal(tb.IP)
+}
+
+// Shutdown stops accepting connections and waits for in-flight requests until ctx expires.
+func (g *Gateway) Shutdown(ctx context.Context) error {
+	err := g.server.Shutdown(ctx)
+	// The server only closes listeners it serves on
+	g.listener.Close()
+	return err
+}
+
+// Close closes the listener and all connections at once, cutting off running requests.
+func (g *Gateway) Close() error {
+	err := g.server.Close()
+	g.listener.Close()
+	return err
+}
@@ -0,0 +1,249 @@
+package apigateway
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// noUpstream is the upstream before an account is logged in.
+func noUpstream() http.Handler { return nil }
+
+func TestGatewayOwns(t *testing.T) {
+	g, err := New("127.0.0.1:0", noUpstream, NewLimiter(NewStore(filepath.Join(t.TempDir(), "apikeys.json"))))
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	defer g.Shutdown(context.Background())
+
+	tests := []struct {
+		addr string
+		want bool
+	}{
+		{"", false},
+		{g.PublicAddr(), true},
+		{"127.0.0.1:1", false},
+	}
+	for _, tt := range tests {
+		if got := g.Owns(tt.addr); got != tt.want {
+			t.Errorf("Owns(%q) = %v, want %v", tt.addr, got, tt.want)
+		}
+	}
+}
+
+func TestGatewayShutdownTimeout(t *testing.T) {
+	// An upstream request that does not finish on its own
+	release := make(chan struct{})
+	defer close(release)
+	received := make(chan struct{})
+	upstream := func() http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			close(received)
+			<-release
+		})
+	}
+
+	g, err := New("127.0.0.1:0", upstream, NewLimiter(NewStore(filepath.Join(t.TempDir(), "apikeys.json"))))
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	g.Serve()
+
+	requestErr := make(chan error, 1)
+	go func() {
+		resp, err := http.Get("http://" + g.PublicAddr() + "/v1/spaces")
+		if err == nil {
+			resp.Body.Close()
+		}
+		requestErr <- err
+	}()
+	<-received
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if err := g.Shutdown(ctx); err == nil {
+		t.Fatal("Shutdown returned before the request finished")
+	}
+	g.Close()
+
+	select {
+	case err := <-requestErr:
+		if err == nil {
+			t.Error("request succeeded although the gateway was closed")
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("request still running after Close")
+	}
+}
+
+func TestGatewayUpstreamUnavailable(t *testing.T) {
+	g, err := New("127.0.0.1:0", noUpstream, NewLimiter(NewStore(filepath.Join(t.TempDir(), "apikeys.json"))))
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	defer g.Shutdown(context.Background())
+	g.Serve()
+
+	resp, err := http.Get("http://" + g.PublicAddr() + "/v1/spaces")
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusServiceUnavailable {
+		t.Errorf("status = %d, want 503", resp.StatusCode)
+	}
+}
+
+func TestGatewayProxy(t *testing.T) {
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, r.URL.Path)
+	}))
+	addr := api.Listener.Addr().String()
+	proxy := NewProxy(addr)
+	g, err := New("127.0.0.1:0", func() http.Handler { return proxy }, NewLimiter(NewStore(filepath.Join(t.TempDir(), "apikeys.json"))))
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	defer g.Shutdown(context.Background())
+	g.Serve()
+
+	if upstream, err := g.Probe(context.Background()); err != nil || !upstream {
+		t.Errorf("Probe with the API server running = %v, %v, want true", upstream, err)
+	}
+
+	// A stopped API server reads as not available, like no upstream at all
+	api.Close()
+	if upstream, err := g.Probe(context.Background()); err != nil || upstream {
+		t.Errorf("Probe with the API server stopped = %v, %v, want false", upstream, err)
+	}
+}
+
+func TestGatewayProbe(t *testing.T) {
+	tests := []struct {
+		name         string
+		upstream     Upstream
+		wantUpstream bool
+		wantErr      bool
+	}{
+		{name: "no upstream", upstream: noUpstream},
+		{name: "upstream answers", upstream: func() http.Handler { return http.NotFoundHandler() }, wantUpstream: true},
+		{name: "upstream fails", upstream: func() http.Handler {
+			return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusBadGateway) })
+		}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g, err := New("127.0.0.1:0", tt.upstream, NewLimiter(NewStore(filepath.Join(t.TempDir(), "apikeys.json"))))
+			if err != nil {
+				t.Fatalf("New failed: %v", err)
+			}
+			defer g.Shutdown(context.Background())
+			g.Serve()
+
+			upstream, err := g.Probe(context.Background())
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Probe() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if upstream != tt.wantUpstream {
+				t.Errorf("Probe() upstream = %v, want %v", upstream, tt.wantUpstream)
+			}
+		})
+	}
+
+	g, err := New("127.0.0.1:0", noUpstream, NewLimiter(NewStore(filepath.Join(t.TempDir(), "apikeys.json"))))
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	g.Close()
+	if _, err := g.Probe(context.Background()); err == nil {
+		t.Error("Probe() should fail when the gateway does not serve")
+	}
+}
+
+func writeTestCertificate(t *testing.T, dir string, serial int64) (string, string) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(serial),
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDer, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	certFile := filepath.Join(dir, fmt.Sprintf("cert%d.pem", serial))
+	keyFile := filepath.Join(dir, fmt.Sprintf("cert%d.key", serial))
+	if err := os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDer}), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return certFile, keyFile
+}
+
+func TestGatewayTLS(t *testing.T) {
+	dir := t.TempDir()
+	g, err := New("127.0.0.1:0", noUpstream, NewLimiter(NewStore(filepath.Join(dir, "apikeys.json"))))
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	defer g.Shutdown(context.Background())
+
+	if err := g.LoadCertificate(filepath.Join(dir, "missing.pem"), filepath.Join(dir, "missing.key")); err == nil {
+		t.Fatal("LoadCertificate should fail for missing files")
+	}
+
+	certFile, keyFile := writeTestCertificate(t, dir, 1)
+	if err := g.LoadCertificate(certFile, keyFile); err != nil {
+		t.Fatalf("LoadCertificate failed: %v", err)
+	}
+	g.Serve()
+
+	peerSerial := func() int64 {
+		conn, err := tls.Dial("tcp", g.PublicAddr(), &tls.Config{InsecureSkipVerify: true})
+		if err != nil {
+			t.Fatalf("TLS dial failed: %v", err)
+		}
+		defer conn.Close()
+		return conn.ConnectionState().PeerCertificates[0].SerialNumber.Int64()
+	}
+	if got := peerSerial(); got != 1 {
+		t.Errorf("certificate serial = %d, want 1", got)
+	}
+	if _, err := g.Probe(context.Background()); err != nil {
+		t.Errorf("Probe over TLS failed: %v", err)
+	}
+
+	certFile, keyFile = writeTestCertificate(t, dir, 2)
+	if err := g.LoadCertificate(certFile, keyFile); err != nil {
+		t.Fatalf("reloading the certificate failed: %v", err)
+	}
+	if got := peerSerial(); got != 2 {
+		t.Errorf("certificate serial after reload = %d, want 2", got)
+	}
+}
@@ -0,0 +1,286 @@
+package apigateway
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/time/rate"
+
+	"github.com/anyproto/anytype-cli/core/output"
+)
+
+const (
+	policyReloadInterval = time.Second
+	maxCachedKeyHashes   = 1024
+)
+
+type keyState struct {
+	rate    Rate
+	quota   Rate
+	limiter *rate.Limiter
+
+	windowStart time.Time
+	used        int
+}
+
+// Limiter enforces per-key scopes, space restrictions, expiry, token-bucket rate limits
+// and fixed-window quotas.
+// Policies are re-read from the store at most once per second, so keys created
+// or changed while the server is running take effect without a restart.
+type Limiter struct {
+	store *Store
+	now   func() time.Time
+
+	mu         sync.Mutex
+	policies   map[string]Policy
+	loadedAt   time.Time
+	fileMod    time.Time
+	keys       map[string]*keyState
+	hashByKey  map[string]string
+	lastErrLog time.Time
+}
+
+func NewLimiter(store *Store) *Limiter {
+	return &Limiter{
+		store:     store,
+		now:       time.Now,
+		policies:  make(map[string]Policy),
+		keys:      make(map[string]*keyState),
+		hashByKey: make(map[string]string),
+	}
+}
+
+// decision is the outcome of checking one request against its key's policy.
+type decision struct {
+	allowed    bool
+	status     int
+	code       string
+	reason     string
+	retryAfter time.Duration
+	quota      int
+	remaining  int
+}
+
+// allow records a request for appKey and reports whether it may proceed.
+// Keys without a policy, or that are not valid API keys, are always allowed.
+func (l *Limiter) allow(appKey, method, urlPath string) decision {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	l.reloadLocked(now)
+
+	hash, ok := l.hashByKey[appKey]
+	if !ok {
+		var err error
+		if hash, err = HashAppKey(appKey); err != nil {
+			return decision{allowed: true}
+		}
+		if len(l.hashByKey) >= maxCachedKeyHashes {
+			l.hashByKey = make(map[string]string)
+		}
+		l.hashByKey[appKey] = hash
+	}
+
+	policy, ok := l.policies[hash]
+	if !ok {
+		delete(l.keys, hash)
+		return decision{allowed: true}
+	}
+
+	if d, denied := checkAccess(policy, now, method, urlPath); denied {
+		return d
+	}
+
+	st := l.keys[hash]
+	if st == nil || st.rate != policy.Rate || st.quota != policy.Quota {
+		st = newKeyState(policy, st)
+		l.keys[hash] = st
+	}
+
+	d := decision{allowed: true, quota: policy.Quota.Count}
+
+	if !policy.Quota.IsZero() {
+		windowStart := now.UTC().Truncate(policy.Quota.Per)
+		if !st.windowStart.Equal(windowStart) {
+			st.windowStart = windowStart
+			st.used = 0
+		}
+		if st.used >= policy.Quota.Count {
+			return decision{
+				status:     http.StatusTooManyRequests,
+				code:       "rate_limit_exceeded",
+				reason:     fmt.Sprintf("Quota of %s exceeded for this API key", policy.Quota),
+				retryAfter: windowStart.Add(policy.Quota.Per).Sub(now),
+				quota:      policy.Quota.Count,
+			}
+		}
+	}
+
+	if st.limiter != nil {
+		r := st.limiter.ReserveN(now, 1)
+		if delay := r.DelayFrom(now); delay > 0 {
+			r.CancelAt(now)
+			return decision{
+				status:     http.StatusTooManyRequests,
+				code:       "rate_limit_exceeded",
+				reason:     fmt.Sprintf("Rate limit of %s exceeded for this API key", policy.Rate),
+				retryAfter: delay,
+				quota:      d.quota,
+				remaining:  policy.Quota.Count - st.used,
+			}
+		}
+	}
+
+	if !policy.Quota.IsZero() {
+		st.used++
+		d.remaining = policy.Quota.Count - st.used
+	}
+	return d
+}
+
+// checkAccess applies the key's expiry, scope and space restrictions to a request.
+func checkAccess(policy Policy, now time.Time, method, urlPath string) (decision, bool) {
+	if policy.Expired(now) {
+		return decision{status: http.StatusUnauthorized, code: "unauthorized", reason: "API key has expired"}, true
+	}
+
+	if policy.Scope == ScopeReadOnly && !isReadRequest(method, urlPath) {
+		return decision{status: http.StatusForbidden, code: "forbidden", reason: "API key is read-only"}, true
+	}
+
+	if len(policy.Spaces) > 0 && strings.HasPrefix(urlPath, "/v1/") {
+		// Reject paths the router could resolve differently from how they read, e.g. with ".."
+		if path.Clean(urlPath) != strings.TrimSuffix(urlPath, "/") {
+			return decision{status: http.StatusBadRequest, code: "bad_request", reason: "Invalid request path"}, true
+		}
+		if !policy.AllowsSpace(spaceFromPath(urlPath)) {
+			return decision{status: http.StatusForbidden, code: "forbidden", reason: "API key is not allowed to access this space"}, true
+		}
+	}
+
+	return decision{}, false
+}
+
+// isReadRequest reports whether a request only reads data. Search endpoints take the query in a POST body.
+func isReadRequest(method, urlPath string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return true
+	case http.MethodPost:
+		if urlPath == "/v1/search" {
+			return true
+		}
+		rest, ok := strings.CutPrefix(urlPath, "/v1/spaces/")
+		return ok && strings.Count(rest, "/") == 1 && strings.HasSuffix(rest, "/search")
+	}
+	return false
+}
+
+// spaceFromPath extracts the space id from /v1/spaces/<id>/... paths.
+func spaceFromPath(urlPath string) string {
+	rest, ok := strings.CutPrefix(urlPath, "/v1/spaces/")
+	if !ok {
+		return ""
+	}
+	id, _, _ := strings.Cut(rest, "/")
+	return id
+}
+
+func newKeyState(policy Policy, prev *keyState) *keyState {
+	st := &keyState{rate: policy.Rate, quota: policy.Quota}
+	if !policy.Rate.IsZero() {
+		perSecond := float64(policy.Rate.Count) / policy.Rate.Per.Seconds()
+		st.limiter = rate.NewLimiter(rate.Limit(perSecond), policy.Rate.Count)
+	}
+	// Keep quota usage when only the rate changed, so editing a policy can't reset a quota
+	if prev != nil && prev.quota == policy.Quota {
+		st.windowStart = prev.windowStart
+		st.used = prev.used
+	}
+	return st
+}
+
+func (l *Limiter) reloadLocked(now time.Time) {
+	if now.Sub(l.loadedAt) < policyReloadInterval {
+		return
+	}
+	l.loadedAt = now
+
+	var modTime time.Time
+	if info, err := os.Stat(l.store.FilePath()); err == nil {
+		modTime = info.ModTime()
+	}
+	if !modTime.IsZero() && modTime.Equal(l.fileMod) {
+		return
+	}
+
+	policies, err := l.store.Load()
+	if err != nil {
+		// Keep enforcing the last good policies rather than failing open
+		if now.Sub(l.lastErrLog) > time.Minute {
+			output.Warning("Failed to reload API key policies: %v", err)
+			l.lastErrLog = now
+		}
+		return
+	}
+	l.policies = policies
+	l.fileMod = modTime
+}
+
+// Middleware wraps next and rejects requests over their key's limits with 429.
+func (l *Limiter) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key, ok := bearerToken(r)
+		if !ok {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		d := l.allow(key, r.Method, r.URL.Path)
+		if d.quota > 0 {
+			w.Header().Set("X-RateLimit-Quota", strconv.Itoa(d.quota))
+			w.Header().Set("X-RateLimit-Quota-Remaining", strconv.Itoa(d.remaining))
+		}
+		if !d.allowed {
+			if d.retryAfter > 0 {
+				retry := int(math.Ceil(d.retryAfter.Seconds()))
+				if retry < 1 {
+					retry = 1
+				}
+				w.Header().Set("Retry-After", strconv.Itoa(retry))
+			}
+			writeAPIError(w, d.status, d.code, d.reason)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func bearerToken(r *http.Request) (string, bool) {
+	auth := r.Header.Get("Authorization")
+	if !strings.HasPrefix(auth, "Bearer ") {
+		return "", false
+	}
+	return strings.TrimPrefix(auth, "Bearer "), true
+}
+
+// writeAPIError writes an error in the same shape as the middleware's JSON API errors.
+func writeAPIError(w http.ResponseWriter, status int, code, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(map[string]interface{}{
+		"object":  "error",
+		"status":  status,
+		"code":    code,
+		"message": message,
+	})
+}
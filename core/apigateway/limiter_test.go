@@ -0,0 +1,175 @@
+package apigateway
+
+import (
+	"encoding/base64"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func newTestLimiter(t *testing.T, policy Policy) (*Limiter, string, *time.Time) {
+	t.Helper()
+
+	key := base64.StdEncoding.EncodeToString([]byte("limited-key"))
+	hash, err := HashAppKey(key)
+	if err != nil {
+		t.Fatalf("HashAppKey failed: %v", err)
+	}
+
+	store := NewStore(filepath.Join(t.TempDir(), "apikeys.json"))
+	if err := store.Set(hash, policy); err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	l := NewLimiter(store)
+	l.now = func() time.Time { return now }
+	return l, key, &now
+}
+
+func TestLimiterRate(t *testing.T) {
+	l, key, now := newTestLimiter(t, Policy{Rate: Rate{Count: 2, Per: time.Second}})
+
+	for i := 0; i < 2; i++ {
+		if d := l.allow(key, http.MethodGet, "/v1/spaces"); !d.allowed {
+			t.Fatalf("request %d should be allowed", i+1)
+		}
+	}
+	d := l.allow(key, http.MethodGet, "/v1/spaces")
+	if d.allowed {
+		t.Fatal("third request within a second should be rejected")
+	}
+	if d.retryAfter <= 0 {
+		t.Errorf("expected positive retry-after, got %v", d.retryAfter)
+	}
+
+	*now = now.Add(time.Second)
+	if d := l.allow(key, http.MethodGet, "/v1/spaces"); !d.allowed {
+		t.Error("request after refill should be allowed")
+	}
+}
+
+func TestLimiterQuota(t *testing.T) {
+	l, key, now := newTestLimiter(t, Policy{Quota: Rate{Count: 3, Per: 24 * time.Hour}})
+
+	for i := 0; i < 3; i++ {
+		d := l.allow(key, http.MethodGet, "/v1/spaces")
+		if !d.allowed {
+			t.Fatalf("request %d should be allowed", i+1)
+		}
+		if d.remaining != 2-i {
+			t.Errorf("remaining = %d, want %d", d.remaining, 2-i)
+		}
+	}
+
+	d := l.allow(key, http.MethodGet, "/v1/spaces")
+	if d.allowed {
+		t.Fatal("request over quota should be rejected")
+	}
+	if d.retryAfter != 12*time.Hour {
+		t.Errorf("retryAfter = %v, want 12h", d.retryAfter)
+	}
+
+	*now = now.Add(12 * time.Hour)
+	if d := l.allow(key, http.MethodGet, "/v1/spaces"); !d.allowed {
+		t.Error("request in the next window should be allowed")
+	}
+}
+
+func TestLimiterUnknownKey(t *testing.T) {
+	l, _, _ := newTestLimiter(t, Policy{Rate: Rate{Count: 1, Per: time.Hour}})
+
+	other := base64.StdEncoding.EncodeToString([]byte("other-key"))
+	for i := 0; i < 5; i++ {
+		if d := l.allow(other, http.MethodGet, "/v1/spaces"); !d.allowed {
+			t.Fatal("keys without a policy should not be limited")
+		}
+	}
+	if d := l.allow("not base64!", http.MethodGet, "/v1/spaces"); !d.allowed {
+		t.Error("malformed keys should be passed through to the API server")
+	}
+}
+
+func TestLimiterMiddleware(t *testing.T) {
+	l, key, _ := newTestLimiter(t, Policy{
+		Rate:  Rate{Count: 10, Per: time.Second},
+		Quota: Rate{Count: 1, Per: 24 * time.Hour},
+	})
+
+	handler := l.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	do := func() *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/v1/spaces", nil)
+		req.Header.Set("Authorization", "Bearer "+key)
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+
+	rec := do()
+	if rec.Code != http.StatusOK {
+		t.Fatalf("first request status = %d, want 200", rec.Code)
+	}
+	if got := rec.Header().Get("X-RateLimit-Quota-Remaining"); got != "0" {
+		t.Errorf("X-RateLimit-Quota-Remaining = %q, want 0", got)
+	}
+
+	rec = do()
+	if rec.Code != http.StatusTooManyRequests {
+		t.Fatalf("second request status = %d, want 429", rec.Code)
+	}
+	if rec.Header().Get("Retry-After") == "" {
+		t.Error("expected Retry-After header")
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/v1/spaces", nil)
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Errorf("unauthenticated request status = %d, want 200", rec.Code)
+	}
+}
+
+func TestCheckAccess(t *testing.T) {
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name       string
+		policy     Policy
+		method     string
+		path       string
+		wantStatus int
+	}{
+		{"no restrictions", Policy{}, http.MethodDelete, "/v1/spaces/a/objects/o", 0},
+		{"expired", Policy{ExpiresAt: now}, http.MethodGet, "/v1/spaces", http.StatusUnauthorized},
+		{"not yet expired", Policy{ExpiresAt: now.Add(time.Second)}, http.MethodGet, "/v1/spaces", 0},
+		{"read-only get", Policy{Scope: ScopeReadOnly}, http.MethodGet, "/v1/spaces/a/objects", 0},
+		{"read-only search", Policy{Scope: ScopeReadOnly}, http.MethodPost, "/v1/search", 0},
+		{"read-only space search", Policy{Scope: ScopeReadOnly}, http.MethodPost, "/v1/spaces/a/search", 0},
+		{"read-only create", Policy{Scope: ScopeReadOnly}, http.MethodPost, "/v1/spaces/a/objects", http.StatusForbidden},
+		{"read-only delete", Policy{Scope: ScopeReadOnly}, http.MethodDelete, "/v1/spaces/a/objects/o", http.StatusForbidden},
+		{"allowed space", Policy{Spaces: []string{"a"}}, http.MethodGet, "/v1/spaces/a/objects", 0},
+		{"allowed space root", Policy{Spaces: []string{"a"}}, http.MethodGet, "/v1/spaces/a", 0},
+		{"other space", Policy{Spaces: []string{"a"}}, http.MethodGet, "/v1/spaces/b/objects", http.StatusForbidden},
+		{"space list", Policy{Spaces: []string{"a"}}, http.MethodGet, "/v1/spaces", http.StatusForbidden},
+		{"global search", Policy{Spaces: []string{"a"}}, http.MethodPost, "/v1/search", http.StatusForbidden},
+		{"path traversal", Policy{Spaces: []string{"a"}}, http.MethodGet, "/v1/spaces/a/../b/objects", http.StatusBadRequest},
+		{"docs", Policy{Spaces: []string{"a"}}, http.MethodGet, "/docs/openapi.json", 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d, denied := checkAccess(tt.policy, now, tt.method, tt.path)
+			if denied != (tt.wantStatus != 0) {
+				t.Fatalf("denied = %v, want status %d", denied, tt.wantStatus)
+			}
+			if denied && d.status != tt.wantStatus {
+				t.Errorf("status = %d, want %d", d.status, tt.wantStatus)
+			}
+		})
+	}
+}
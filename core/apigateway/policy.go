@@ -0,0 +1,305 @@
+package apigateway
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/anyproto/anytype-cli/core/config"
+)
+
+// Rate is a number of requests allowed per period, written as "10/s", "600/m", "10000/day".
+type Rate struct {
+	Count int
+	Per   time.Duration
+}
+
+var ratePeriods = map[string]time.Duration{
+	"s":      time.Second,
+	"sec":    time.Second,
+	"second": time.Second,
+	"m":      time.Minute,
+	"min":    time.Minute,
+	"minute": time.Minute,
+	"h":      time.Hour,
+	"hour":   time.Hour,
+	"d":      24 * time.Hour,
+	"day":    24 * time.Hour,
+}
+
+// ParseRate parses a rate in `<count>/<period>` format. Period is one of s, m, h or day.
+func ParseRate(s string) (Rate, error) {
+	countStr, period, ok := strings.Cut(strings.TrimSpace(s), "/")
+	if !ok {
+		return Rate{}, fmt.Errorf("invalid rate %q: expected <count>/<period>, e.g. 10/s", s)
+	}
+
+	count, err := strconv.Atoi(strings.TrimSpace(countStr))
+	if err != nil || count <= 0 {
+		return Rate{}, fmt.Errorf("invalid rate %q: count must be a positive integer", s)
+	}
+
+	per, ok := ratePeriods[strings.ToLower(strings.TrimSpace(period))]
+	if !ok {
+		return Rate{}, fmt.Errorf("invalid rate %q: period must be one of s, m, h, day", s)
+	}
+
+	return Rate{Count: count, Per: per}, nil
+}
+
+func (r Rate) IsZero() bool {
+	return r.Count == 0
+}
+
+func (r Rate) String() string {
+	if r.IsZero() {
+		return ""
+	}
+	switch r.Per {
+	case time.Second:
+		return fmt.Sprintf("%d/s", r.Count)
+	case time.Minute:
+		return fmt.Sprintf("%d/m", r.Count)
+	case time.Hour:
+		return fmt.Sprintf("%d/h", r.Count)
+	case 24 * time.Hour:
+		return fmt.Sprintf("%d/day", r.Count)
+	default:
+		return fmt.Sprintf("%d/%s", r.Count, r.Per)
+	}
+}
+
+func (r Rate) MarshalJSON() ([]byte, error) {
+	return json.Marshal(r.String())
+}
+
+func (r *Rate) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return err
+	}
+	if s == "" {
+		*r = Rate{}
+		return nil
+	}
+	parsed, err := ParseRate(s)
+	if err != nil {
+		return err
+	}
+	*r = parsed
+	return nil
+}
+
+// Access scopes for API keys. Read-only keys may only read and search.
+const (
+	ScopeFull     = "full"
+	ScopeReadOnly = "read-only"
+)
+
+// ParseScope normalizes a user-supplied scope name.
+func ParseScope(s string) (string, error) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "", ScopeFull:
+		return ScopeFull, nil
+	case ScopeReadOnly, "readonly", "read":
+		return ScopeReadOnly, nil
+	default:
+		return "", fmt.Errorf("invalid scope %q: must be %s or %s", s, ScopeReadOnly, ScopeFull)
+	}
+}
+
+// ParseDuration parses a Go duration, additionally accepting whole days ("30d") and weeks ("2w").
+func ParseDuration(s string) (time.Duration, error) {
+	s = strings.TrimSpace(s)
+	var unit time.Duration
+	switch {
+	case strings.HasSuffix(s, "d"):
+		unit = 24 * time.Hour
+	case strings.HasSuffix(s, "w"):
+		unit = 7 * 24 * time.Hour
+	}
+
+	var d time.Duration
+	if unit != 0 {
+		n, err := strconv.Atoi(s[:len(s)-1])
+		if err != nil {
+			return 0, fmt.Errorf("invalid duration %q", s)
+		}
+		d = time.Duration(n) * unit
+	} else {
+		var err error
+		if d, err = time.ParseDuration(s); err != nil {
+			return 0, fmt.Errorf("invalid duration %q", s)
+		}
+	}
+
+	if d <= 0 {
+		return 0, fmt.Errorf("invalid duration %q: must be positive", s)
+	}
+	return d, nil
+}
+
+// Policy holds the limits and restrictions enforced by the gateway for one API key.
+type Policy struct {
+	Rate      Rate      `json:"rate,omitzero"`
+	Quota     Rate      `json:"quota,omitzero"`
+	Scope     string    `json:"scope,omitempty"`
+	Spaces    []string  `json:"spaces,omitempty"`
+	ExpiresAt time.Time `json:"expires_at,omitzero"`
+}
+
+// IsZero reports whether the policy imposes no limits.
+func (p Policy) IsZero() bool {
+	return p.Rate.IsZero() && p.Quota.IsZero() && (p.Scope == "" || p.Scope == ScopeFull) &&
+		len(p.Spaces) == 0 && p.ExpiresAt.IsZero()
+}
+
+// Expired reports whether the key has passed its expiry time.
+func (p Policy) Expired(now time.Time) bool {
+	return !p.ExpiresAt.IsZero() && !now.Before(p.ExpiresAt)
+}
+
+// AllowsSpace reports whether the key may access the given space.
+func (p Policy) AllowsSpace(spaceId string) bool {
+	if len(p.Spaces) == 0 {
+		return true
+	}
+	for _, id := range p.Spaces {
+		if id == spaceId {
+			return true
+		}
+	}
+	return false
+}
+
+// HashAppKey returns the app hash the middleware uses to identify an API key.
+func HashAppKey(appKey string) (string, error) {
+	raw, err := base64.StdEncoding.DecodeString(appKey)
+	if err != nil {
+		return "", fmt.Errorf("invalid API key format: %w", err)
+	}
+	return fmt.Sprintf("%x", sha256.Sum256(raw)), nil
+}
+
+// Store persists key policies by app hash. Keys themselves are never written.
+type Store struct {
+	mu       sync.Mutex
+	filePath string
+}
+
+func NewStore(filePath string) *Store {
+	return &Store{filePath: filePath}
+}
+
+// GetStore returns the store backed by the default API keys file.
+func GetStore() *Store {
+	return NewStore(config.GetAPIKeysFilePath())
+}
+
+func (s *Store) FilePath() string {
+	return s.filePath
+}
+
+// Load returns all stored policies. A missing file yields an empty map.
+func (s *Store) Load() (map[string]Policy, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.load()
+}
+
+func (s *Store) load() (map[string]Policy, error) {
+	policies := make(map[string]Policy)
+
+	data, err := os.ReadFile(s.filePath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return policies, nil
+		}
+		return nil, fmt.Errorf("failed to read API key policies: %w", err)
+	}
+
+	if err := json.Unmarshal(data, &policies); err != nil {
+		return nil, fmt.Errorf("failed to parse API key policies: %w", err)
+	}
+	return policies, nil
+}
+
+func (s *Store) save(policies map[string]Policy) error {
+	if err := os.MkdirAll(filepath.Dir(s.filePath), 0755); err != nil {
+		return fmt.Errorf("failed to create config directory: %w", err)
+	}
+
+	data, err := json.MarshalIndent(policies, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal API key policies: %w", err)
+	}
+
+	if err := config.WriteFileAtomic(s.filePath, data, 0600); err != nil {
+		return fmt.Errorf("failed to write API key policies: %w", err)
+	}
+	return nil
+}
+
+// Set stores the policy for an app hash, removing the entry if the policy is empty.
+func (s *Store) Set(appHash string, policy Policy) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// The server and CLI commands in other processes update the same file
+	unlock, err := config.LockFile(s.filePath)
+	if err != nil {
+		return fmt.Errorf("failed to lock API key policies: %w", err)
+	}
+	defer unlock()
+
+	policies, err := s.load()
+	if err != nil {
+		return err
+	}
+
+	if policy.IsZero() {
+		delete(policies, appHash)
+	} else {
+		policies[appHash] = policy
+	}
+	return s.save(policies)
+}
+
+// Get returns the stored policy for an app hash.
+func (s *Store) Get(appHash string) (Policy, bool, error) {
+	policies, err := s.Load()
+	if err != nil {
+		return Policy{}, false, err
+	}
+	p, ok := policies[appHash]
+	return p, ok, nil
+}
+
+// Expired returns the app hashes of keys whose expiry time has passed.
+func (s *Store) Expired(now time.Time) ([]string, error) {
+	policies, err := s.Load()
+	if err != nil {
+		return nil, err
+	}
+	var hashes []string
+	for hash, p := range policies {
+		if p.Expired(now) {
+			hashes = append(hashes, hash)
+		}
+	}
+	sort.Strings(hashes)
+	return hashes, nil
+}
+
+// Delete removes the policy for an app hash.
+func (s *Store) Delete(appHash string) error {
+	return s.Set(appHash, Policy{})
+}
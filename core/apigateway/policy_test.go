@@ -0,0 +1,178 @@
+package apigateway
+
+import (
+	"encoding/base64"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestParseRate(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    Rate
+		wantErr bool
+	}{
+		{"10/s", Rate{Count: 10, Per: time.Second}, false},
+		{"600/m", Rate{Count: 600, Per: time.Minute}, false},
+		{"1000/h", Rate{Count: 1000, Per: time.Hour}, false},
+		{"10000/day", Rate{Count: 10000, Per: 24 * time.Hour}, false},
+		{" 5 / Min ", Rate{Count: 5, Per: time.Minute}, false},
+		{"10", Rate{}, true},
+		{"0/s", Rate{}, true},
+		{"-1/s", Rate{}, true},
+		{"abc/s", Rate{}, true},
+		{"10/week", Rate{}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, err := ParseRate(tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseRate(%q) error = %v, wantErr %v", tt.input, err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("ParseRate(%q) = %+v, want %+v", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRateString(t *testing.T) {
+	for _, s := range []string{"10/s", "600/m", "1000/h", "10000/day"} {
+		r, err := ParseRate(s)
+		if err != nil {
+			t.Fatalf("ParseRate(%q) failed: %v", s, err)
+		}
+		if r.String() != s {
+			t.Errorf("String() = %q, want %q", r.String(), s)
+		}
+	}
+}
+
+func TestParseScope(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    string
+		wantErr bool
+	}{
+		{"", ScopeFull, false},
+		{"full", ScopeFull, false},
+		{"read-only", ScopeReadOnly, false},
+		{"ReadOnly", ScopeReadOnly, false},
+		{"write", "", true},
+	}
+
+	for _, tt := range tests {
+		got, err := ParseScope(tt.input)
+		if (err != nil) != tt.wantErr {
+			t.Fatalf("ParseScope(%q) error = %v, wantErr %v", tt.input, err, tt.wantErr)
+		}
+		if got != tt.want {
+			t.Errorf("ParseScope(%q) = %q, want %q", tt.input, got, tt.want)
+		}
+	}
+}
+
+func TestParseDuration(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    time.Duration
+		wantErr bool
+	}{
+		{"30d", 30 * 24 * time.Hour, false},
+		{"2w", 14 * 24 * time.Hour, false},
+		{"12h", 12 * time.Hour, false},
+		{"90m", 90 * time.Minute, false},
+		{"0d", 0, true},
+		{"-1h", 0, true},
+		{"xd", 0, true},
+		{"soon", 0, true},
+	}
+
+	for _, tt := range tests {
+		got, err := ParseDuration(tt.input)
+		if (err != nil) != tt.wantErr {
+			t.Fatalf("ParseDuration(%q) error = %v, wantErr %v", tt.input, err, tt.wantErr)
+		}
+		if got != tt.want {
+			t.Errorf("ParseDuration(%q) = %v, want %v", tt.input, got, tt.want)
+		}
+	}
+}
+
+func TestHashAppKey(t *testing.T) {
+	key := base64.StdEncoding.EncodeToString([]byte("test-key"))
+	hash, err := HashAppKey(key)
+	if err != nil {
+		t.Fatalf("HashAppKey failed: %v", err)
+	}
+	if len(hash) != 64 {
+		t.Errorf("expected 64 hex chars, got %d", len(hash))
+	}
+
+	if _, err := HashAppKey("not base64!"); err == nil {
+		t.Error("expected error for invalid key")
+	}
+}
+
+func TestStore(t *testing.T) {
+	store := NewStore(filepath.Join(t.TempDir(), "apikeys.json"))
+
+	policies, err := store.Load()
+	if err != nil {
+		t.Fatalf("Load on missing file failed: %v", err)
+	}
+	if len(policies) != 0 {
+		t.Fatalf("expected no policies, got %d", len(policies))
+	}
+
+	policy := Policy{
+		Rate:  Rate{Count: 10, Per: time.Second},
+		Quota: Rate{Count: 10000, Per: 24 * time.Hour},
+	}
+	if err := store.Set("hash1", policy); err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+	if err := store.Set("hash2", Policy{Rate: Rate{Count: 1, Per: time.Minute}}); err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+
+	policies, err = store.Load()
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if !reflect.DeepEqual(policies["hash1"], policy) {
+		t.Errorf("hash1 = %+v, want %+v", policies["hash1"], policy)
+	}
+	if !policies["hash2"].Quota.IsZero() {
+		t.Errorf("hash2 quota should be empty, got %v", policies["hash2"].Quota)
+	}
+
+	now := time.Now()
+	if err := store.Set("expired", Policy{ExpiresAt: now.Add(-time.Minute)}); err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+	expired, err := store.Expired(now)
+	if err != nil {
+		t.Fatalf("Expired failed: %v", err)
+	}
+	if !reflect.DeepEqual(expired, []string{"expired"}) {
+		t.Errorf("Expired() = %v, want [expired]", expired)
+	}
+	if err := store.Delete("expired"); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+
+	if err := store.Delete("hash1"); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+	policies, _ = store.Load()
+	if _, ok := policies["hash1"]; ok {
+		t.Error("hash1 should have been deleted")
+	}
+	if len(policies) != 1 {
+		t.Errorf("expected 1 policy left, got %d", len(policies))
+	}
+}
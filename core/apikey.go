@@ -3,14 +3,33 @@ package core
 import (
 	"context"
 	"fmt"
+	"time"
+
 	"github.com/anyproto/anytype-heart/pkg/lib/pb/model"
 
 	"github.com/anyproto/anytype-heart/pb"
 	"github.com/anyproto/anytype-heart/pb/service"
+
+	"github.com/anyproto/anytype-cli/core/apigateway"
 )
 
-// CreateAPIKey creates a new API key for local app access
+// CreateAPIKey creates a new API key for local app access with the middleware's default scope.
+// Read-only scopes, space restrictions and expiry are enforced by the API gateway, see apigateway.Policy.
 func CreateAPIKey(name string) (*pb.RpcAccountLocalLinkCreateAppResponse, error) {
+	return createAPIKey(name, model.AccountAuth_Limited)
+}
+
+// nativeScope returns the middleware scope of a gateway scope. Read-only keys get the JSON API
+// scope, for which the middleware rejects every gRPC method, so that they cannot write even
+// when used against the middleware directly.
+func nativeScope(scope string) model.AccountAuthLocalApiScope {
+	if scope == apigateway.ScopeReadOnly {
+		return model.AccountAuth_JsonAPI
+	}
+	return model.AccountAuth_Limited
+}
+
+func createAPIKey(name string, scope model.AccountAuthLocalApiScope) (*pb.RpcAccountLocalLinkCreateAppResponse, error) {
 	var resp *pb.RpcAccountLocalLinkCreateAppResponse
 
 	err := GRPCCall(func(ctx context.Context, client service.ClientCommandsClient) error {
@@ -18,6 +37,7 @@ func CreateAPIKey(name string) (*pb.RpcAccountLocalLinkCreateAppResponse, error)
 		resp, err = client.AccountLocalLinkCreateApp(ctx, &pb.RpcAccountLocalLinkCreateAppRequest{
 			App: &model.AccountAuthAppInfo{
 				AppName: name,
+				Scope:   scope,
 			},
 		})
 		if err != nil {
@@ -72,3 +92,106 @@ func RevokeAPIKey(appId string) error {
 		return nil
 	})
 }
+
+// DeliverAPIKey hands a newly created key to its recipient. If it fails, the key is revoked
+// again, since nobody will ever see it.
+type DeliverAPIKey func(appKey, appHash string) error
+
+// CreateAPIKeyWithPolicy creates an API key, stores the policy the gateway enforces for it and
+// passes it to deliver, if set. If any step fails the key is revoked again, so it never exists
+// without its restrictions or without anyone holding it.
+func CreateAPIKeyWithPolicy(name string, policy apigateway.Policy, deliver DeliverAPIKey) (*pb.RpcAccountLocalLinkCreateAppResponse, string, error) {
+	resp, err := createAPIKey(name, nativeScope(policy.Scope))
+	if err != nil {
+		return nil, "", err
+	}
+
+	appHash, err := apigateway.HashAppKey(resp.AppKey)
+	if err != nil {
+		return nil, "", err
+	}
+
+	if !policy.IsZero() {
+		if err := apigateway.GetStore().Set(appHash, policy); err != nil {
+			return nil, "", revokeAfterFailure(appHash, err)
+		}
+	}
+
+	if deliver != nil {
+		if err := deliver(resp.AppKey, appHash); err != nil {
+			_ = apigateway.GetStore().Delete(appHash)
+			return nil, "", revokeAfterFailure(appHash, err)
+		}
+	}
+
+	return resp, appHash, nil
+}
+
+func revokeAfterFailure(appHash string, err error) error {
+	if revokeErr := RevokeAPIKey(appHash); revokeErr != nil {
+		return fmt.Errorf("%w; additionally failed to revoke the new key %s: %v", err, appHash, revokeErr)
+	}
+	return fmt.Errorf("%w; the new key has been revoked", err)
+}
+
+// RotateAPIKey issues a replacement for the key with the given id, carrying over its name and
+// policy, and schedules the old key for revocation after grace. A zero grace revokes it immediately.
+// If expiresIn is zero, a key that had an expiry gets the same lifetime again. The old key is
+// only retired once the replacement has been delivered. It returns the time the old key will be
+// revoked at, which is zero if it was revoked immediately.
+func RotateAPIKey(appHash string, grace, expiresIn time.Duration, deliver DeliverAPIKey) (*pb.RpcAccountLocalLinkCreateAppResponse, string, time.Time, error) {
+	apps, err := ListAPIKeys()
+	if err != nil {
+		return nil, "", time.Time{}, err
+	}
+
+	var old *model.AccountAuthAppInfo
+	for _, app := range apps.App {
+		if app.AppHash == appHash {
+			old = app
+			break
+		}
+	}
+	if old == nil {
+		return nil, "", time.Time{}, fmt.Errorf("API key with Id '%s' not found", appHash)
+	}
+
+	store := apigateway.GetStore()
+	policy, _, err := store.Get(appHash)
+	if err != nil {
+		return nil, "", time.Time{}, err
+	}
+
+	now := time.Now()
+	newPolicy := policy
+	switch {
+	case expiresIn > 0:
+		newPolicy.ExpiresAt = now.Add(expiresIn)
+	case !policy.ExpiresAt.IsZero() && old.CreatedAt > 0:
+		newPolicy.ExpiresAt = now.Add(policy.ExpiresAt.Sub(time.Unix(old.CreatedAt, 0)))
+	}
+
+	resp, newHash, err := CreateAPIKeyWithPolicy(old.AppName, newPolicy, deliver)
+	if err != nil {
+		return nil, "", time.Time{}, fmt.Errorf("failed to create replacement key: %w", err)
+	}
+
+	if grace <= 0 {
+		if err := RevokeAPIKey(appHash); err != nil {
+			return resp, newHash, time.Time{}, fmt.Errorf("replacement key created but failed to revoke the old key: %w", err)
+		}
+		if err := store.Delete(appHash); err != nil {
+			return resp, newHash, time.Time{}, fmt.Errorf("failed to remove policy of the old key: %w", err)
+		}
+		return resp, newHash, time.Time{}, nil
+	}
+
+	// The service revokes the old key once it expires
+	if revokeAt := now.Add(grace); policy.ExpiresAt.IsZero() || revokeAt.Before(policy.ExpiresAt) {
+		policy.ExpiresAt = revokeAt
+	}
+	if err := store.Set(appHash, policy); err != nil {
+		return resp, newHash, time.Time{}, fmt.Errorf("replacement key created but failed to schedule revocation of the old key: %w", err)
+	}
+	return resp, newHash, policy.ExpiresAt, nil
+}
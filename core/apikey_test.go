@@ -0,0 +1,25 @@
+package core
+
+import (
+	"testing"
+
+	"github.com/anyproto/anytype-heart/pkg/lib/pb/model"
+
+	"github.com/anyproto/anytype-cli/core/apigateway"
+)
+
+func TestNativeScope(t *testing.T) {
+	tests := []struct {
+		scope string
+		want  model.AccountAuthLocalApiScope
+	}{
+		{"", model.AccountAuth_Limited},
+		{apigateway.ScopeFull, model.AccountAuth_Limited},
+		{apigateway.ScopeReadOnly, model.AccountAuth_JsonAPI},
+	}
+	for _, tt := range tests {
+		if got := nativeScope(tt.scope); got != tt.want {
+			t.Errorf("nativeScope(%q) = %v, want %v", tt.scope, got, tt.want)
+		}
+	}
+}
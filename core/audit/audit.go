@@ -0,0 +1,369 @@
+package audit
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/anyproto/anytype-cli/core/config"
+)
+
+const (
+	// FileName is the name of the active audit log inside the logs directory
+	FileName = "audit.jsonl"
+
+	DefaultMaxSize    = 10 * 1024 * 1024
+	DefaultMaxBackups = 5
+)
+
+// Process describes the local client process that issued an RPC, if it could be resolved.
+type Process struct {
+	PID  int32  `json:"pid"`
+	Name string `json:"name,omitempty"`
+	Path string `json:"path,omitempty"`
+}
+
+// Record is a single audit log entry. Request and response payloads are never recorded;
+// only identifiers needed to attribute the call are kept.
+type Record struct {
+	Time       time.Time `json:"time"`
+	Method     string    `json:"method"`
+	Peer       string    `json:"peer,omitempty"`
+	Process    *Process  `json:"process,omitempty"`
+	DurationMs float64   `json:"durationMs"`
+	Code       string    `json:"code"`
+	SpaceId    string    `json:"spaceId,omitempty"`
+	ObjectIds  []string  `json:"objectIds,omitempty"`
+}
+
+// GetFilePath returns the path of the active audit log.
+func GetFilePath() string {
+	return filepath.Join(config.GetLogsDir(), FileName)
+}
+
+// Logger appends records to a JSONL file and rotates it once it grows past maxSize.
+// Rotated files are kept as <name>.1 (newest) through <name>.<maxBackups> (oldest).
+type Logger struct {
+	mu         sync.Mutex
+	path       string
+	maxSize    int64
+	maxBackups int
+	file       *os.File
+	size       int64
+}
+
+// NewLogger opens (or creates) the audit log at path.
+func NewLogger(path string, maxSize int64, maxBackups int) (*Logger, error) {
+	if maxSize <= 0 {
+		maxSize = DefaultMaxSize
+	}
+	if maxBackups < 0 {
+		maxBackups = 0
+	}
+
+	l := &Logger{
+		path:       path,
+		maxSize:    maxSize,
+		maxBackups: maxBackups,
+	}
+	if err := l.open(); err != nil {
+		return nil, err
+	}
+	return l, nil
+}
+
+func (l *Logger) open() error {
+	if err := os.MkdirAll(filepath.Dir(l.path), 0755); err != nil {
+		return fmt.Errorf("failed to create audit log directory: %w", err)
+	}
+
+	f, err := os.OpenFile(l.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		return fmt.Errorf("failed to open audit log: %w", err)
+	}
+
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return fmt.Errorf("failed to stat audit log: %w", err)
+	}
+
+	l.file = f
+	l.size = info.Size()
+	return nil
+}
+
+// Write appends a record to the log, rotating first if the record would exceed the size limit.
+func (l *Logger) Write(rec Record) error {
+	data, err := json.Marshal(rec)
+	if err != nil {
+		return fmt.Errorf("failed to marshal audit record: %w", err)
+	}
+	data = append(data, '\n')
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.file == nil {
+		return fmt.Errorf("audit log is closed")
+	}
+
+	if l.size > 0 && l.size+int64(len(data)) > l.maxSize {
+		if err := l.rotate(); err != nil {
+			return err
+		}
+	}
+
+	n, err := l.file.Write(data)
+	l.size += int64(n)
+	if err != nil {
+		return fmt.Errorf("failed to write audit record: %w", err)
+	}
+	return nil
+}
+
+func (l *Logger) rotate() error {
+	if err := l.file.Close(); err != nil {
+		return fmt.Errorf("failed to close audit log: %w", err)
+	}
+	l.file = nil
+
+	if l.maxBackups == 0 {
+		if err := os.Remove(l.path); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to remove audit log: %w", err)
+		}
+		return l.open()
+	}
+
+	_ = os.Remove(backupPath(l.path, l.maxBackups))
+	for i := l.maxBackups - 1; i >= 1; i-- {
+		if err := os.Rename(backupPath(l.path, i), backupPath(l.path, i+1)); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to rotate audit log: %w", err)
+		}
+	}
+	if err := os.Rename(l.path, backupPath(l.path, 1)); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to rotate audit log: %w", err)
+	}
+
+	return l.open()
+}
+
+// Close closes the underlying file.
+func (l *Logger) Close() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.file == nil {
+		return nil
+	}
+	err := l.file.Close()
+	l.file = nil
+	return err
+}
+
+func backupPath(path string, n int) string {
+	return fmt.Sprintf("%s.%d", path, n)
+}
+
+// Filter selects records by their attributes. Zero-valued fields match everything.
+type Filter struct {
+	Method  string
+	Peer    string
+	Process string
+	SpaceId string
+	Object  string
+	Code    string
+	Since   time.Time
+}
+
+// Match reports whether the record satisfies every set criterion.
+// Method and Process match by case-insensitive substring, the rest by exact value.
+func (f Filter) Match(rec Record) bool {
+	if f.Method != "" && !strings.Contains(strings.ToLower(rec.Method), strings.ToLower(f.Method)) {
+		return false
+	}
+	if f.Peer != "" && rec.Peer != f.Peer && !strings.HasPrefix(rec.Peer, f.Peer+":") {
+		return false
+	}
+	if f.Process != "" {
+		if rec.Process == nil || !strings.Contains(strings.ToLower(rec.Process.Name), strings.ToLower(f.Process)) {
+			return false
+		}
+	}
+	if f.SpaceId != "" && rec.SpaceId != f.SpaceId {
+		return false
+	}
+	if f.Object != "" && !containsString(rec.ObjectIds, f.Object) {
+		return false
+	}
+	if f.Code != "" && !strings.EqualFold(rec.Code, f.Code) {
+		return false
+	}
+	if !f.Since.IsZero() && rec.Time.Before(f.Since) {
+		return false
+	}
+	return true
+}
+
+func containsString(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+// ReadRecords decodes all records from r that match the filter. Malformed lines are skipped.
+func ReadRecords(r io.Reader, filter Filter) ([]Record, error) {
+	var records []Record
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		rec, ok := parseLine(scanner.Bytes())
+		if ok && filter.Match(rec) {
+			records = append(records, rec)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return records, fmt.Errorf("failed to read audit log: %w", err)
+	}
+	return records, nil
+}
+
+// Tail returns the last n matching records across the active log and its backups,
+// oldest first. A non-positive n returns every matching record.
+func Tail(path string, n int, filter Filter) ([]Record, error) {
+	paths := []string{path}
+	for i := 1; ; i++ {
+		p := backupPath(path, i)
+		if _, err := os.Stat(p); err != nil {
+			break
+		}
+		paths = append(paths, p)
+	}
+
+	var records []Record
+	for i := len(paths) - 1; i >= 0; i-- {
+		f, err := os.Open(paths[i])
+		if err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+			return nil, fmt.Errorf("failed to open audit log: %w", err)
+		}
+		recs, err := ReadRecords(f, filter)
+		f.Close()
+		if err != nil {
+			return nil, err
+		}
+		records = append(records, recs...)
+	}
+
+	if n > 0 && len(records) > n {
+		records = records[len(records)-n:]
+	}
+	return records, nil
+}
+
+func parseLine(line []byte) (Record, bool) {
+	var rec Record
+	if len(line) == 0 {
+		return rec, false
+	}
+	if err := json.Unmarshal(line, &rec); err != nil {
+		return rec, false
+	}
+	return rec, true
+}
+
+// Follow polls the active log for new records and passes matching ones to fn until stop is closed.
+// It starts at the current end of the file and reopens it when rotation is detected.
+func Follow(path string, filter Filter, interval time.Duration, stop <-chan struct{}, fn func(Record)) error {
+	f, err := os.Open(path)
+	if err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to open audit log: %w", err)
+	}
+
+	var offset int64
+	if f != nil {
+		offset, _ = f.Seek(0, io.SeekEnd)
+	}
+	defer func() {
+		if f != nil {
+			f.Close()
+		}
+	}()
+
+	var pending []byte
+	buf := make([]byte, 32*1024)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-stop:
+			return nil
+		case <-ticker.C:
+		}
+
+		info, statErr := os.Stat(path)
+		if statErr != nil {
+			continue
+		}
+
+		rotated := f == nil
+		if f != nil {
+			if cur, err := f.Stat(); err != nil || !os.SameFile(cur, info) || info.Size() < offset {
+				rotated = true
+			}
+		}
+		if rotated {
+			if f != nil {
+				// Drain whatever was appended to the old file before it was rotated away
+				pending = readAvailable(f, buf, pending, filter, fn)
+				f.Close()
+			}
+			f, err = os.Open(path)
+			if err != nil {
+				f = nil
+				continue
+			}
+			offset = 0
+			pending = nil
+		}
+
+		pending = readAvailable(f, buf, pending, filter, fn)
+		offset, _ = f.Seek(0, io.SeekCurrent)
+	}
+}
+
+func readAvailable(f *os.File, buf, pending []byte, filter Filter, fn func(Record)) []byte {
+	for {
+		n, err := f.Read(buf)
+		if n > 0 {
+			pending = append(pending, buf[:n]...)
+			for {
+				idx := bytes.IndexByte(pending, '\n')
+				if idx < 0 {
+					break
+				}
+				if rec, ok := parseLine(pending[:idx]); ok && filter.Match(rec) {
+					fn(rec)
+				}
+				pending = pending[idx+1:]
+			}
+		}
+		if err != nil || n == 0 {
+			return pending
+		}
+	}
+}
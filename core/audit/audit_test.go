@@ -0,0 +1,105 @@
+package audit
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLoggerRotation(t *testing.T) {
+	tempDir := t.TempDir()
+	path := filepath.Join(tempDir, FileName)
+
+	logger, err := NewLogger(path, 200, 2)
+	if err != nil {
+		t.Fatalf("NewLogger failed: %v", err)
+	}
+	defer logger.Close()
+
+	for i := 0; i < 10; i++ {
+		if err := logger.Write(Record{Time: time.Now(), Method: "/anytype.ClientCommands/ObjectShow", Code: "OK"}); err != nil {
+			t.Fatalf("Write failed: %v", err)
+		}
+	}
+
+	for _, p := range []string{path, path + ".1", path + ".2"} {
+		info, err := os.Stat(p)
+		if err != nil {
+			t.Fatalf("expected %s to exist: %v", p, err)
+		}
+		if info.Size() > 200 {
+			t.Errorf("%s size = %d, want <= 200", p, info.Size())
+		}
+	}
+	if _, err := os.Stat(path + ".3"); !os.IsNotExist(err) {
+		t.Errorf("expected no more than 2 backups, found %s.3", path)
+	}
+}
+
+func TestTailFilter(t *testing.T) {
+	tempDir := t.TempDir()
+	path := filepath.Join(tempDir, FileName)
+
+	logger, err := NewLogger(path, 0, 1)
+	if err != nil {
+		t.Fatalf("NewLogger failed: %v", err)
+	}
+
+	now := time.Now()
+	records := []Record{
+		{Time: now.Add(-2 * time.Hour), Method: "/anytype.ClientCommands/ObjectShow", Code: "OK", SpaceId: "space-1", ObjectIds: []string{"obj-1"}},
+		{Time: now.Add(-time.Minute), Method: "/anytype.ClientCommands/ObjectSearch", Code: "OK", SpaceId: "space-2"},
+		{Time: now, Method: "/anytype.ClientCommands/ObjectShow", Code: "Unauthenticated", Peer: "127.0.0.1:50000", Process: &Process{PID: 42, Name: "python3"}},
+	}
+	for _, rec := range records {
+		if err := logger.Write(rec); err != nil {
+			t.Fatalf("Write failed: %v", err)
+		}
+	}
+	logger.Close()
+
+	tests := []struct {
+		name   string
+		n      int
+		filter Filter
+		want   int
+	}{
+		{"all", 0, Filter{}, 3},
+		{"last n", 2, Filter{}, 2},
+		{"method substring", 0, Filter{Method: "objectshow"}, 2},
+		{"space", 0, Filter{SpaceId: "space-2"}, 1},
+		{"object", 0, Filter{Object: "obj-1"}, 1},
+		{"code", 0, Filter{Code: "unauthenticated"}, 1},
+		{"peer host", 0, Filter{Peer: "127.0.0.1"}, 1},
+		{"process", 0, Filter{Process: "python"}, 1},
+		{"since", 0, Filter{Since: now.Add(-time.Hour)}, 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Tail(path, tt.n, tt.filter)
+			if err != nil {
+				t.Fatalf("Tail failed: %v", err)
+			}
+			if len(got) != tt.want {
+				t.Errorf("Tail() returned %d records, want %d", len(got), tt.want)
+			}
+		})
+	}
+}
+
+func TestReadRecordsSkipsMalformedLines(t *testing.T) {
+	input := `{"method":"/a","code":"OK"}
+not json
+{"method":"/b","code":"OK"}
+`
+	got, err := ReadRecords(strings.NewReader(input), Filter{})
+	if err != nil {
+		t.Fatalf("ReadRecords failed: %v", err)
+	}
+	if len(got) != 2 {
+		t.Errorf("ReadRecords() returned %d records, want 2", len(got))
+	}
+}
@@ -1,12 +1,10 @@
 package core
 
 import (
-	"bufio"
 	"context"
 	"encoding/base64"
 	"errors"
 	"fmt"
-	"os"
 	"runtime"
 	"strings"
 
@@ -14,7 +12,9 @@ import (
 	"github.com/anyproto/anytype-heart/pb/service"
 
 	"github.com/anyproto/anytype-cli/core/config"
+	"github.com/anyproto/anytype-cli/core/credentials"
 	"github.com/anyproto/anytype-cli/core/output"
+	"github.com/anyproto/anytype-cli/core/serverstatus"
 )
 
 // Authenticate performs the full authentication flow for a bot account using an account key.
@@ -24,7 +24,7 @@ func Authenticate(accountKey, rootPath, apiAddr string) error {
 		rootPath = config.GetDataDir()
 	}
 	if apiAddr == "" {
-		apiAddr = config.DefaultAPIAddress
+		apiAddr = config.GetAPIAddress()
 	}
 
 	var sessionToken string
@@ -70,14 +70,9 @@ func Authenticate(accountKey, rootPath, apiAddr string) error {
 		return err
 	}
 
-	savedToKeyring, err := SaveSessionToken(sessionToken)
-	if err != nil {
+	if _, err := SaveSessionToken(sessionToken); err != nil {
 		return fmt.Errorf("failed to save session token: %w", err)
 	}
-	if !savedToKeyring {
-		output.Warning("System keyring unavailable (requires D-Bus on Linux, Keychain on macOS, Credential Manager on Windows)")
-		output.Warning("Storing credentials in config file: %s (insecure)", config.GetConfigManager().GetFilePath())
-	}
 
 	er, err := ListenForEvents(sessionToken)
 	if err != nil {
@@ -155,16 +150,9 @@ func ValidateAccountKey(accountKey string) error {
 	return nil
 }
 
-// Login handles user interaction for login by prompting for account key if not provided,
-// validating it, performing authentication, and saving the key to keychain.
+// Login validates the account key, performs authentication, and saves the key to the
+// configured credentials backend.
 func Login(accountKey, rootPath, apiAddr string) error {
-	if accountKey == "" {
-		output.Print("Enter account key: ")
-		reader := bufio.NewReader(os.Stdin)
-		accountKey, _ = reader.ReadString('\n')
-		accountKey = strings.TrimSpace(accountKey)
-	}
-
 	if err := ValidateAccountKey(accountKey); err != nil {
 		return err
 	}
@@ -173,13 +161,16 @@ func Login(accountKey, rootPath, apiAddr string) error {
 		return err
 	}
 
-	savedToKeyring, err := SaveAccountKey(accountKey)
+	accountId, _ := config.GetAccountIdFromConfig()
+	if err := serverstatus.SetAuth(serverstatus.Auth{State: serverstatus.AuthLoggedIn, AccountId: accountId}); err != nil {
+		output.Warning("Failed to update server status: %v", err)
+	}
+
+	backend, err := SaveAccountKey(accountKey)
 	if err != nil {
 		output.Warning("Failed to save account key: %v", err)
-	} else if savedToKeyring {
-		output.Success("Account key saved to keychain.")
 	} else {
-		output.Success("Account key saved to config file.")
+		output.Success("Account key saved to %s.", credentials.Describe(backend))
 	}
 
 	return nil
@@ -204,10 +195,18 @@ func Logout() error {
 		return fmt.Errorf("failed to delete stored token: %w", err)
 	}
 
+	// The credentials backend is a setting rather than account state, so it survives logout
+	credentialsCfg, _ := config.GetCredentialsConfig()
+
 	configMgr := config.GetConfigManager()
 	if err := configMgr.Delete(); err != nil {
 		output.Warning("Failed to clear config: %v", err)
 	}
+	if credentialsCfg != (config.CredentialsConfig{}) {
+		if err := configMgr.SetCredentials(credentialsCfg); err != nil {
+			output.Warning("Failed to keep credentials backend setting: %v", err)
+		}
+	}
 
 	CloseEventReceiver()
 
@@ -237,17 +236,22 @@ func Logout() error {
 		output.Warning("Could not notify server: %v", err)
 	}
 
+	if err := serverstatus.SetAuth(serverstatus.Auth{State: serverstatus.AuthIdle, Reason: "logged out"}); err != nil {
+		output.Warning("Failed to update server status: %v", err)
+	}
+
 	return nil
 }
 
 // CreateWallet creates a new wallet and account, establishes a session,
-// saves credentials, and returns the account key, account ID, and whether credentials were saved to keyring.
-func CreateWallet(name, rootPath, apiAddr string) (string, string, bool, error) {
+// saves credentials, and returns the account key, account ID, and the credentials backend the key was saved to
+// (empty if saving failed).
+func CreateWallet(name, rootPath, apiAddr string) (string, string, string, error) {
 	if rootPath == "" {
 		rootPath = config.GetDataDir()
 	}
 	if apiAddr == "" {
-		apiAddr = config.DefaultAPIAddress
+		apiAddr = config.GetAPIAddress()
 	}
 
 	var sessionToken string
@@ -284,21 +288,16 @@ func CreateWallet(name, rootPath, apiAddr string) (string, string, bool, error)
 	})
 
 	if err != nil {
-		return "", "", false, err
+		return "", "", "", err
 	}
 
-	savedToKeyring, err := SaveSessionToken(sessionToken)
-	if err != nil {
-		return "", "", false, fmt.Errorf("failed to save session token: %w", err)
-	}
-	if !savedToKeyring {
-		output.Warning("System keyring unavailable (requires D-Bus on Linux, Keychain on macOS, Credential Manager on Windows)")
-		output.Warning("Storing credentials in config file: %s (insecure)", config.GetConfigManager().GetFilePath())
+	if _, err := SaveSessionToken(sessionToken); err != nil {
+		return "", "", "", fmt.Errorf("failed to save session token: %w", err)
 	}
 
 	_, err = ListenForEvents(sessionToken)
 	if err != nil {
-		return "", "", false, fmt.Errorf("failed to start event listener: %w", err)
+		return "", "", "", fmt.Errorf("failed to start event listener: %w", err)
 	}
 
 	var accountId string
@@ -315,7 +314,7 @@ func CreateWallet(name, rootPath, apiAddr string) (string, string, bool, error)
 		return nil
 	})
 	if err != nil {
-		return "", "", false, err
+		return "", "", "", err
 	}
 
 	var techSpaceId string
@@ -334,10 +333,10 @@ func CreateWallet(name, rootPath, apiAddr string) (string, string, bool, error)
 		return nil
 	})
 	if err != nil {
-		return "", "", false, err
+		return "", "", "", err
 	}
 
-	accountKeySavedToKeyring, err := SaveAccountKey(accountKey)
+	accountKeyBackend, err := SaveAccountKey(accountKey)
 	if err != nil {
 		output.Warning("Failed to save account key: %v", err)
 	}
@@ -351,5 +350,9 @@ func CreateWallet(name, rootPath, apiAddr string) (string, string, bool, error)
 		}
 	}
 
-	return accountKey, accountId, accountKeySavedToKeyring, nil
+	if err := serverstatus.SetAuth(serverstatus.Auth{State: serverstatus.AuthLoggedIn, AccountId: accountId}); err != nil {
+		output.Warning("Failed to update server status: %v", err)
+	}
+
+	return accountKey, accountId, accountKeyBackend, nil
 }
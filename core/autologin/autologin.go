@@ -0,0 +1,87 @@
+package autologin
+
+import (
+	"context"
+	"time"
+
+	"github.com/anyproto/anytype-cli/core/serverconfig"
+	"github.com/anyproto/anytype-cli/core/serverstatus"
+)
+
+// Runner logs the server in with the stored account key and keeps retrying with exponential
+// backoff until it succeeds, the attempt limit is reached or the context is cancelled.
+// Every state change is passed to OnChange.
+type Runner struct {
+	// Login performs one login attempt and returns the account id
+	Login func(ctx context.Context) (string, error)
+	// Settings returns the current auto-login settings, which may change between attempts
+	Settings func() serverconfig.AutoLogin
+	// LoggedIn reports whether the account was logged in by another client meanwhile
+	LoggedIn func() bool
+	// OnChange receives every new state
+	OnChange func(serverstatus.Auth)
+
+	now   func() time.Time
+	after func(time.Duration) <-chan time.Time
+}
+
+// Delay returns the wait before attempt n+1 after n failed attempts: the retry delay
+// doubled for every further failure, capped at the maximum retry delay.
+func Delay(settings serverconfig.AutoLogin, failed int) time.Duration {
+	d := settings.Delay()
+	limit := settings.MaxDelay()
+	for i := 1; i < failed && d < limit; i++ {
+		d *= 2
+	}
+	return min(d, limit)
+}
+
+// Run blocks until the account is logged in, auto-login is disabled, the attempt limit is
+// reached or ctx is done.
+func (r *Runner) Run(ctx context.Context) {
+	now := r.now
+	if now == nil {
+		now = func() time.Time { return time.Now().UTC().Truncate(time.Second) }
+	}
+	after := r.after
+	if after == nil {
+		after = time.After
+	}
+
+	for attempt := 1; ; attempt++ {
+		if r.LoggedIn != nil && r.LoggedIn() {
+			return
+		}
+		settings := r.Settings()
+		if !settings.IsEnabled() {
+			r.OnChange(serverstatus.Auth{State: serverstatus.AuthIdle, Reason: "auto-login is disabled", Since: now()})
+			return
+		}
+
+		r.OnChange(serverstatus.Auth{State: serverstatus.AuthLoggingIn, Attempts: attempt, Since: now()})
+		accountId, err := r.Login(ctx)
+		if err == nil {
+			r.OnChange(serverstatus.Auth{State: serverstatus.AuthLoggedIn, AccountId: accountId, Attempts: attempt, Since: now()})
+			return
+		}
+		if ctx.Err() != nil {
+			return
+		}
+
+		failed := serverstatus.Auth{State: serverstatus.AuthFailed, Reason: err.Error(), Attempts: attempt, Since: now()}
+		if limit := settings.MaxAttempts(); limit > 0 && attempt >= limit {
+			r.OnChange(failed)
+			return
+		}
+
+		delay := Delay(settings, attempt)
+		failed.RetryAt = now().Add(delay)
+		r.OnChange(failed)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-after(delay):
+		}
+	}
+}
@@ -0,0 +1,119 @@
+package autologin
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/anyproto/anytype-cli/core/serverconfig"
+	"github.com/anyproto/anytype-cli/core/serverstatus"
+)
+
+func TestDelay(t *testing.T) {
+	settings := serverconfig.AutoLogin{RetryDelay: time.Second, MaxRetryDelay: 10 * time.Second}
+	want := []time.Duration{time.Second, 2 * time.Second, 4 * time.Second, 8 * time.Second, 10 * time.Second, 10 * time.Second}
+	for i, w := range want {
+		if got := Delay(settings, i+1); got != w {
+			t.Errorf("Delay(%d) = %s, want %s", i+1, got, w)
+		}
+	}
+}
+
+// testRunner returns a runner whose login fails failures times before succeeding,
+// recording every state and every delay waited for.
+func testRunner(settings serverconfig.AutoLogin, failures int) (*Runner, *[]serverstatus.Auth, *[]time.Duration) {
+	var states []serverstatus.Auth
+	var delays []time.Duration
+	calls := 0
+	r := &Runner{
+		Login: func(ctx context.Context) (string, error) {
+			calls++
+			if calls <= failures {
+				return "", errors.New("network unreachable")
+			}
+			return "account", nil
+		},
+		Settings: func() serverconfig.AutoLogin { return settings },
+		OnChange: func(a serverstatus.Auth) { states = append(states, a) },
+		now:      func() time.Time { return time.Unix(0, 0) },
+		after: func(d time.Duration) <-chan time.Time {
+			delays = append(delays, d)
+			ch := make(chan time.Time, 1)
+			ch <- time.Time{}
+			return ch
+		},
+	}
+	return r, &states, &delays
+}
+
+func stateNames(states []serverstatus.Auth) []serverstatus.AuthState {
+	var names []serverstatus.AuthState
+	for _, s := range states {
+		names = append(names, s.State)
+	}
+	return names
+}
+
+func TestRunRetriesUntilLoggedIn(t *testing.T) {
+	r, states, delays := testRunner(serverconfig.AutoLogin{RetryDelay: time.Second, MaxRetryDelay: 3 * time.Second}, 4)
+	r.Run(context.Background())
+
+	wantStates := []serverstatus.AuthState{
+		serverstatus.AuthLoggingIn, serverstatus.AuthFailed,
+		serverstatus.AuthLoggingIn, serverstatus.AuthFailed,
+		serverstatus.AuthLoggingIn, serverstatus.AuthFailed,
+		serverstatus.AuthLoggingIn, serverstatus.AuthFailed,
+		serverstatus.AuthLoggingIn, serverstatus.AuthLoggedIn,
+	}
+	if got := stateNames(*states); !slices.Equal(got, wantStates) {
+		t.Fatalf("states = %v, want %v", got, wantStates)
+	}
+	if want := []time.Duration{time.Second, 2 * time.Second, 3 * time.Second, 3 * time.Second}; !slices.Equal(*delays, want) {
+		t.Errorf("delays = %v, want %v", *delays, want)
+	}
+
+	failed := (*states)[1]
+	if failed.Reason != "network unreachable" || !failed.RetryAt.Equal(time.Unix(1, 0)) {
+		t.Errorf("failed state = %+v, want reason and retry time", failed)
+	}
+	last := (*states)[len(*states)-1]
+	if last.AccountId != "account" || last.Attempts != 5 {
+		t.Errorf("logged-in state = %+v", last)
+	}
+}
+
+func TestRunStopsAtAttemptLimit(t *testing.T) {
+	r, states, _ := testRunner(serverconfig.AutoLogin{Attempts: 2}, 10)
+	r.Run(context.Background())
+
+	last := (*states)[len(*states)-1]
+	if last.State != serverstatus.AuthFailed || last.Attempts != 2 || !last.RetryAt.IsZero() {
+		t.Errorf("final state = %+v, want failed after 2 attempts without retry", last)
+	}
+}
+
+func TestRunDisabled(t *testing.T) {
+	disabled := false
+	r, states, _ := testRunner(serverconfig.AutoLogin{Enabled: &disabled}, 0)
+	r.Run(context.Background())
+
+	if got := stateNames(*states); !slices.Equal(got, []serverstatus.AuthState{serverstatus.AuthIdle}) {
+		t.Errorf("states = %v, want only idle", got)
+	}
+}
+
+func TestRunStopsWhenLoggedInElsewhere(t *testing.T) {
+	r, states, _ := testRunner(serverconfig.AutoLogin{}, 10)
+	attempts := 0
+	r.LoggedIn = func() bool {
+		attempts++
+		return attempts > 2
+	}
+	r.Run(context.Background())
+
+	if got := len(*states); got != 4 {
+		t.Errorf("recorded %d states, want 4 (two failed attempts)", got)
+	}
+}
@@ -18,8 +18,6 @@ import (
 	"github.com/anyproto/anytype-cli/core/config"
 )
 
-const defaultTimeout = 5 * time.Second
-
 var (
 	clientInstance service.ClientCommandsClient
 	grpcConn       *grpc.ClientConn
@@ -31,7 +29,7 @@ var (
 func GetGRPCClient() (service.ClientCommandsClient, error) {
 	once.Do(func() {
 		var err error
-		grpcConn, err = grpc.NewClient(config.GRPCDNSAddress, grpc.WithTransportCredentials(insecure.NewCredentials()))
+		grpcConn, err = grpc.NewClient(config.GetGRPCDialAddress(), grpc.WithTransportCredentials(insecure.NewCredentials()))
 		if err != nil {
 			initErr = fmt.Errorf("failed to connect to gRPC server: %w", err)
 			return
@@ -75,7 +73,7 @@ func GRPCCall(fn func(ctx context.Context, client service.ClientCommandsClient)
 		return fmt.Errorf("failed to get stored token: %w", err)
 	}
 
-	ctx, cancel := ClientContextWithAuthTimeout(token, defaultTimeout)
+	ctx, cancel := ClientContextWithAuthTimeout(token, config.GetRPCTimeout())
 	defer cancel()
 
 	err = fn(ctx, client)
@@ -95,7 +93,7 @@ func GRPCCallNoAuth(fn func(ctx context.Context, client service.ClientCommandsCl
 		return fmt.Errorf("error connecting to gRPC server: %w", err)
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), defaultTimeout)
+	ctx, cancel := context.WithTimeout(context.Background(), config.GetRPCTimeout())
 	defer cancel()
 
 	err = fn(ctx, client)
@@ -0,0 +1,117 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestWriteFileAtomic(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config.json")
+
+	for _, data := range []string{`{"accountId":"first"}`, `{"accountId":"second"}`} {
+		if err := WriteFileAtomic(path, []byte(data), 0600); err != nil {
+			t.Fatalf("WriteFileAtomic failed: %v", err)
+		}
+		got, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatalf("ReadFile failed: %v", err)
+		}
+		if string(got) != data {
+			t.Errorf("file content = %s, want %s", got, data)
+		}
+	}
+
+	if runtime.GOOS != "windows" {
+		info, err := os.Stat(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if perm := info.Mode().Perm(); perm != 0600 {
+			t.Errorf("file mode = %o, want 600", perm)
+		}
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 {
+		t.Errorf("directory has %d entries, want only the config file", len(entries))
+	}
+}
+
+func TestConfigManagerConcurrentUpdates(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.json")
+
+	// Separate managers stand in for separate processes sharing the file
+	const writers = 20
+	var wg sync.WaitGroup
+	for i := 0; i < writers; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			cm := &ConfigManager{config: &Config{}, filePath: path}
+			if err := cm.Load(); err != nil {
+				t.Errorf("Load failed: %v", err)
+				return
+			}
+			err := cm.Update(func(cfg *Config) {
+				if cfg.AccountId != "" {
+					cfg.AccountId += ","
+				}
+				cfg.AccountId += fmt.Sprint(i)
+			})
+			if err != nil {
+				t.Errorf("Update failed: %v", err)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	cfg, err := readConfig(path)
+	if err != nil {
+		t.Fatalf("readConfig failed: %v", err)
+	}
+	seen := make(map[string]bool)
+	for _, id := range strings.Split(cfg.AccountId, ",") {
+		seen[id] = true
+	}
+	for i := 0; i < writers; i++ {
+		if !seen[fmt.Sprint(i)] {
+			t.Errorf("update %d was lost: %q", i, cfg.AccountId)
+		}
+	}
+}
+
+func TestConfigManagerUpdateKeepsOtherFields(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.json")
+
+	stale := &ConfigManager{config: &Config{}, filePath: path}
+	if err := stale.Load(); err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+
+	other := &ConfigManager{config: &Config{}, filePath: path}
+	if err := other.SetAccountId("account"); err != nil {
+		t.Fatalf("SetAccountId failed: %v", err)
+	}
+
+	// stale has not seen the account id, which must survive its update
+	if err := stale.SetTechSpaceId("tech-space"); err != nil {
+		t.Fatalf("SetTechSpaceId failed: %v", err)
+	}
+
+	cfg, err := readConfig(path)
+	if err != nil {
+		t.Fatalf("readConfig failed: %v", err)
+	}
+	if cfg.AccountId != "account" || cfg.TechSpaceId != "tech-space" {
+		t.Errorf("config = %+v, want both fields set", cfg)
+	}
+}
@@ -4,22 +4,41 @@ import (
 	"encoding/json"
 	"fmt"
 	"os"
-	"path/filepath"
 	"sync"
 )
 
 type Config struct {
+	// Version is the schema version of the file; see CurrentConfigVersion
+	Version     int    `json:"version,omitempty"`
 	AccountId   string `json:"accountId,omitempty"`
 	TechSpaceId string `json:"techSpaceId,omitempty"`
 	// Credentials stored in plain text - only used when keyring is unavailable
 	// WARNING: This is insecure and should only be used on headless servers
 	AccountKey   string `json:"accountKey,omitempty"`
 	SessionToken string `json:"sessionToken,omitempty"`
+	// Credentials selects where the account key and session token are stored
+	Credentials CredentialsConfig `json:"credentials,omitzero"`
+	// LogLevel is the server log level; see the logLevel config key
+	LogLevel string `json:"logLevel,omitempty"`
+	// RPCTimeout is the timeout for client calls, as a Go duration
+	RPCTimeout string `json:"rpcTimeout,omitempty"`
+	// OutputFormat is the default of --output; see the outputFormat config key
+	OutputFormat string `json:"outputFormat,omitempty"`
+}
+
+// CredentialsConfig configures the credential storage backend.
+type CredentialsConfig struct {
+	// Backend is one of auto, keyring, file, encrypted, env or helper. Empty means auto.
+	Backend string `json:"backend,omitempty"`
+	// KeyFile holds the passphrase for the encrypted backend
+	KeyFile string `json:"keyFile,omitempty"`
+	// Helper is the command implementing the credential helper protocol
+	Helper string `json:"helper,omitempty"`
 }
 
 var (
-	instance *ConfigManager
-	once     sync.Once
+	instance   *ConfigManager
+	instanceMu sync.Mutex
 )
 
 type ConfigManager struct {
@@ -28,16 +47,24 @@ type ConfigManager struct {
 	filePath string
 }
 
+// GetConfigManager returns the config manager of the active profile. A new manager
+// is created when the active profile, and with it the config file, changes.
 func GetConfigManager() *ConfigManager {
-	once.Do(func() {
+	instanceMu.Lock()
+	defer instanceMu.Unlock()
+
+	filePath := GetConfigFilePath()
+	if instance == nil || instance.filePath != filePath {
 		instance = &ConfigManager{
 			config:   &Config{},
-			filePath: GetConfigFilePath(),
+			filePath: filePath,
 		}
-	})
+	}
 	return instance
 }
 
+// Load reads the config file, upgrading it first if it was written by an older version.
+// Writes replace the file atomically, so no lock is needed just to read it.
 func (cm *ConfigManager) Load() error {
 	cm.mu.Lock()
 	defer cm.mu.Unlock()
@@ -46,44 +73,55 @@ func (cm *ConfigManager) Load() error {
 		return fmt.Errorf("could not determine config file path")
 	}
 
-	data, err := os.ReadFile(cm.filePath)
+	cfg, err := readConfig(cm.filePath)
 	if err != nil {
-		if os.IsNotExist(err) {
-			// Config doesn't exist yet, that's okay
-			return nil
-		}
-		return fmt.Errorf("failed to read config file: %w", err)
+		return err
 	}
-
-	if err := json.Unmarshal(data, cm.config); err != nil {
-		return fmt.Errorf("failed to parse config file: %w", err)
+	if cfg.Version < CurrentConfigVersion {
+		if _, err := MigrateConfigFile(cm.filePath, false); err != nil {
+			return fmt.Errorf("failed to upgrade config file: %w", err)
+		}
+		if cfg, err = readConfig(cm.filePath); err != nil {
+			return err
+		}
 	}
-
+	cm.config = cfg
 	return nil
 }
 
-func (cm *ConfigManager) Save() error {
-	cm.mu.RLock()
-	defer cm.mu.RUnlock()
-
-	if cm.filePath == "" {
-		return fmt.Errorf("could not determine config file path")
+// readConfig reads the config file as is. A missing file yields an empty config of the current version.
+func readConfig(path string) (*Config, error) {
+	cfg := &Config{}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			// Config doesn't exist yet, that's okay
+			cfg.Version = CurrentConfigVersion
+			return cfg, nil
+		}
+		return nil, fmt.Errorf("failed to read config file: %w", err)
 	}
 
-	configDir := filepath.Dir(cm.filePath)
-	if err := os.MkdirAll(configDir, 0755); err != nil {
-		return fmt.Errorf("failed to create config directory: %w", err)
+	if err := json.Unmarshal(data, cfg); err != nil {
+		return nil, fmt.Errorf("failed to parse config file: %w", err)
 	}
+	return cfg, nil
+}
 
-	data, err := json.MarshalIndent(cm.config, "", "  ")
+// writeConfig stamps cfg with the current version and replaces the file. It refuses to
+// overwrite a file written by a newer version, whose fields this build would drop.
+func writeConfig(path string, cfg *Config) error {
+	if cfg.Version > CurrentConfigVersion {
+		return newerConfigError(cfg.Version)
+	}
+	cfg.Version = CurrentConfigVersion
+	data, err := json.MarshalIndent(cfg, "", "  ")
 	if err != nil {
 		return fmt.Errorf("failed to marshal config: %w", err)
 	}
-
-	if err := os.WriteFile(cm.filePath, data, 0600); err != nil {
+	if err := WriteFileAtomic(path, data, 0600); err != nil {
 		return fmt.Errorf("failed to write config file: %w", err)
 	}
-
 	return nil
 }
 
@@ -103,43 +141,68 @@ func (cm *ConfigManager) GetFilePath() string {
 }
 
 func (cm *ConfigManager) SetAccountId(accountId string) error {
-	cm.mu.Lock()
-	cm.config.AccountId = accountId
-	cm.mu.Unlock()
-
-	return cm.Save()
+	return cm.Update(func(cfg *Config) { cfg.AccountId = accountId })
 }
 
 func (cm *ConfigManager) SetTechSpaceId(techSpaceId string) error {
-	cm.mu.Lock()
-	cm.config.TechSpaceId = techSpaceId
-	cm.mu.Unlock()
-
-	return cm.Save()
+	return cm.Update(func(cfg *Config) { cfg.TechSpaceId = techSpaceId })
 }
 
 func (cm *ConfigManager) SetSessionToken(token string) error {
-	cm.mu.Lock()
-	cm.config.SessionToken = token
-	cm.mu.Unlock()
-
-	return cm.Save()
+	return cm.Update(func(cfg *Config) { cfg.SessionToken = token })
 }
 
 func (cm *ConfigManager) SetAccountKey(accountKey string) error {
-	cm.mu.Lock()
-	cm.config.AccountKey = accountKey
-	cm.mu.Unlock()
+	return cm.Update(func(cfg *Config) { cfg.AccountKey = accountKey })
+}
 
-	return cm.Save()
+func (cm *ConfigManager) SetCredentials(credentials CredentialsConfig) error {
+	return cm.Update(func(cfg *Config) { cfg.Credentials = credentials })
 }
 
-func (cm *ConfigManager) Reset() error {
+// Update is a locked read-modify-write of the config file: it takes the file lock, re-reads
+// the file so fields changed by other processes are kept, applies fn and replaces the file atomically.
+func (cm *ConfigManager) Update(fn func(*Config)) error {
 	cm.mu.Lock()
-	cm.config = &Config{}
-	cm.mu.Unlock()
+	defer cm.mu.Unlock()
+
+	if cm.filePath == "" {
+		return fmt.Errorf("could not determine config file path")
+	}
+
+	unlock, err := LockFile(cm.filePath)
+	if err != nil {
+		return fmt.Errorf("failed to lock config file: %w", err)
+	}
+	defer unlock()
 
-	return cm.Save()
+	cfg, err := readConfig(cm.filePath)
+	if err != nil {
+		return err
+	}
+	if cfg.Version < CurrentConfigVersion {
+		if _, err := migrateConfigFile(cm.filePath, false); err != nil {
+			return fmt.Errorf("failed to upgrade config file: %w", err)
+		}
+		if cfg, err = readConfig(cm.filePath); err != nil {
+			return err
+		}
+	}
+	if cfg.Version > CurrentConfigVersion {
+		return newerConfigError(cfg.Version)
+	}
+	version := cfg.Version
+	fn(cfg)
+	cfg.Version = version
+	if err := writeConfig(cm.filePath, cfg); err != nil {
+		return err
+	}
+	cm.config = cfg
+	return nil
+}
+
+func (cm *ConfigManager) Reset() error {
+	return cm.Update(func(cfg *Config) { *cfg = Config{} })
 }
 
 func (cm *ConfigManager) Delete() error {
@@ -150,6 +213,12 @@ func (cm *ConfigManager) Delete() error {
 		return fmt.Errorf("could not determine config file path")
 	}
 
+	unlock, err := LockFile(cm.filePath)
+	if err != nil {
+		return fmt.Errorf("failed to lock config file: %w", err)
+	}
+	defer unlock()
+
 	cm.config = &Config{}
 
 	if err := os.Remove(cm.filePath); err != nil && !os.IsNotExist(err) {
@@ -5,17 +5,15 @@ import (
 )
 
 func GetAccountIdFromConfig() (string, error) {
-	configMgr := GetConfigManager()
-	if err := configMgr.Load(); err != nil {
+	accountId, _, err := Resolve("accountId")
+	if err != nil {
 		return "", fmt.Errorf("failed to load config: %w", err)
 	}
-
-	cfg := configMgr.Get()
-	if cfg.AccountId == "" {
+	if accountId == "" {
 		return "", fmt.Errorf("no account Id found in config")
 	}
 
-	return cfg.AccountId, nil
+	return accountId, nil
 }
 
 func SetAccountIdToConfig(accountId string) error {
@@ -27,17 +25,15 @@ func SetAccountIdToConfig(accountId string) error {
 }
 
 func GetTechSpaceIdFromConfig() (string, error) {
-	configMgr := GetConfigManager()
-	if err := configMgr.Load(); err != nil {
+	techSpaceId, _, err := Resolve("techSpaceId")
+	if err != nil {
 		return "", fmt.Errorf("failed to load config: %w", err)
 	}
-
-	cfg := configMgr.Get()
-	if cfg.TechSpaceId == "" {
+	if techSpaceId == "" {
 		return "", fmt.Errorf("no tech space Id found in config")
 	}
 
-	return cfg.TechSpaceId, nil
+	return techSpaceId, nil
 }
 
 func SetTechSpaceIdToConfig(techSpaceId string) error {
@@ -101,3 +97,34 @@ func SetAccountKeyToConfig(accountKey string) error {
 
 	return configMgr.SetAccountKey(accountKey)
 }
+
+func GetCredentialsConfig() (CredentialsConfig, error) {
+	configMgr := GetConfigManager()
+	if err := configMgr.Load(); err != nil {
+		return CredentialsConfig{}, fmt.Errorf("failed to load config: %w", err)
+	}
+
+	return configMgr.Get().Credentials, nil
+}
+
+// GetEffectiveCredentialsConfig returns the credentials settings with ANYTYPE_CREDENTIALS_*
+// environment overrides applied. Use GetCredentialsConfig for the values stored in the file.
+func GetEffectiveCredentialsConfig() (CredentialsConfig, error) {
+	if _, err := GetCredentialsConfig(); err != nil {
+		return CredentialsConfig{}, err
+	}
+	return CredentialsConfig{
+		Backend: Value("credentials.backend"),
+		KeyFile: Value("credentials.keyFile"),
+		Helper:  Value("credentials.helper"),
+	}, nil
+}
+
+func SetCredentialsConfig(credentials CredentialsConfig) error {
+	configMgr := GetConfigManager()
+	if err := configMgr.Load(); err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	return configMgr.SetCredentials(credentials)
+}
@@ -7,7 +7,7 @@ import (
 )
 
 func TestConfigManager(t *testing.T) {
-	t.Run("SaveAndLoad", func(t *testing.T) {
+	t.Run("UpdateAndLoad", func(t *testing.T) {
 		tempDir, err := os.MkdirTemp("", "anytype-config-test")
 		if err != nil {
 			t.Fatalf("Failed to create temp dir: %v", err)
@@ -21,13 +21,16 @@ func TestConfigManager(t *testing.T) {
 		}
 
 		cm := &ConfigManager{
-			config:   testConfig,
+			config:   &Config{},
 			filePath: configPath,
 		}
 
-		err = cm.Save()
+		err = cm.Update(func(cfg *Config) {
+			cfg.AccountId = testConfig.AccountId
+			cfg.TechSpaceId = testConfig.TechSpaceId
+		})
 		if err != nil {
-			t.Errorf("Save failed: %v", err)
+			t.Errorf("Update failed: %v", err)
 		}
 
 		if _, err := os.Stat(configPath); os.IsNotExist(err) {
@@ -62,11 +65,11 @@ func TestConfigManager(t *testing.T) {
 
 		configPath := filepath.Join(tempDir, "config.json")
 		cm := &ConfigManager{
-			config:   &Config{AccountId: "test"},
+			config:   &Config{},
 			filePath: configPath,
 		}
 
-		_ = cm.Save()
+		_ = cm.SetAccountId("test")
 
 		err = cm.Delete()
 		if err != nil {
@@ -15,6 +15,11 @@ const (
 	GRPCWebPort = "31011"
 	APIPort     = "31012"
 
+	// Ports of the Anytype desktop app's middleware, which may run on the same machine
+	DesktopGRPCPort    = "31007"
+	DesktopGRPCWebPort = "31008"
+	DesktopAPIPort     = "31009"
+
 	// Full addresses
 	DefaultGRPCAddress    = LocalhostIP + ":" + GRPCPort
 	DefaultGRPCWebAddress = LocalhostIP + ":" + GRPCWebPort
@@ -32,11 +37,13 @@ const (
 	AnytypeNetworkAddress = "N83gJpVd9MuNRZAuJLZ7LiMntTThhPc6DtzWWVjb1M3PouVU"
 
 	// Directory and file names
-	AnytypeDirName = ".anytype"
-	ConfigFileName = "config.json"
-	DataDirName    = "data"
-	LogsDirName    = "logs"
-	AnytypeName    = "anytype"
+	AnytypeDirName      = ".anytype"
+	ConfigFileName      = "config.json"
+	APIKeysFileName     = "apikeys.json"
+	CredentialsFileName = "credentials.enc"
+	DataDirName         = "data"
+	LogsDirName         = "logs"
+	AnytypeName         = "anytype"
 )
 
 func GetWorkDir() string {
@@ -55,23 +62,29 @@ func GetWorkDir() string {
 	}
 }
 
+// GetConfigDir returns the config directory of the active profile: ~/.anytype for the
+// default profile and ~/.anytype/profiles/<name> for named profiles.
 func GetConfigDir() string {
-	homeDir, err := os.UserHomeDir()
-	if err != nil {
-		return ""
-	}
-	return filepath.Join(homeDir, AnytypeDirName)
+	return getProfileDir(ActiveProfile())
 }
 
 func GetConfigFilePath() string {
 	return filepath.Join(GetConfigDir(), ConfigFileName)
 }
 
+func GetAPIKeysFilePath() string {
+	return filepath.Join(GetConfigDir(), APIKeysFileName)
+}
+
+func GetCredentialsFilePath() string {
+	return filepath.Join(GetConfigDir(), CredentialsFileName)
+}
+
 func GetDataDir() string {
 	if dataPath := os.Getenv("DATA_PATH"); dataPath != "" {
 		return dataPath
 	}
-	return filepath.Join(GetWorkDir(), DataDirName)
+	return Value("dataDir")
 }
 
 func GetLogsDir() string {
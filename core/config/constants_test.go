@@ -125,6 +125,34 @@ func TestGetConfigFilePath(t *testing.T) {
 	}
 }
 
+func TestGetAPIKeysFilePath(t *testing.T) {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		t.Fatalf("Failed to get home dir: %v", err)
+	}
+
+	expected := filepath.Join(homeDir, AnytypeDirName, APIKeysFileName)
+	got := GetAPIKeysFilePath()
+
+	if got != expected {
+		t.Errorf("GetAPIKeysFilePath() = %v, want %v", got, expected)
+	}
+}
+
+func TestGetCredentialsFilePath(t *testing.T) {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		t.Fatalf("Failed to get home dir: %v", err)
+	}
+
+	expected := filepath.Join(homeDir, AnytypeDirName, CredentialsFileName)
+	got := GetCredentialsFilePath()
+
+	if got != expected {
+		t.Errorf("GetCredentialsFilePath() = %v, want %v", got, expected)
+	}
+}
+
 func TestGetLogsDir(t *testing.T) {
 	homeDir, err := os.UserHomeDir()
 	if err != nil {
@@ -155,6 +183,8 @@ func TestConstants(t *testing.T) {
 		{"GRPCDNSAddress", GRPCDNSAddress, "dns:///127.0.0.1:31010"},
 		{"AnytypeDirName", AnytypeDirName, ".anytype"},
 		{"ConfigFileName", ConfigFileName, "config.json"},
+		{"APIKeysFileName", APIKeysFileName, "apikeys.json"},
+		{"CredentialsFileName", CredentialsFileName, "credentials.enc"},
 		{"DataDirName", DataDirName, "data"},
 		{"LogsDirName", LogsDirName, "logs"},
 		{"AnytypeName", AnytypeName, "anytype"},
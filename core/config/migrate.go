@@ -0,0 +1,177 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// Migration upgrades config.json from the previous version to Version. Migrations work on
+// the decoded JSON document so they can rename or drop fields Config no longer has.
+type Migration struct {
+	Version int
+	// Migrate changes doc in place and describes each change it made
+	Migrate func(doc map[string]any) []string
+}
+
+// migrations must be kept in order; the last one defines CurrentConfigVersion.
+var migrations = []Migration{
+	{Version: 1, Migrate: migrateToV1},
+}
+
+// CurrentConfigVersion is the config.json version written by this build.
+var CurrentConfigVersion = migrations[len(migrations)-1].Version
+
+// MigrationResult describes the upgrade of a config file.
+type MigrationResult struct {
+	Path        string
+	FromVersion int
+	ToVersion   int
+	// Changes lists what each applied migration changed, prefixed with its version
+	Changes []string
+	// BackupPath is where the original file was kept; empty for a dry run
+	BackupPath string
+}
+
+// Needed reports whether the file was, or would be, upgraded.
+func (r *MigrationResult) Needed() bool {
+	return r.FromVersion < r.ToVersion
+}
+
+// configVersion returns the version of a config document; files from before versioning are version 0.
+func configVersion(doc map[string]any) (int, error) {
+	raw, ok := doc["version"]
+	if !ok {
+		return 0, nil
+	}
+	v, ok := raw.(float64)
+	if !ok || v < 0 || v != float64(int(v)) {
+		return 0, fmt.Errorf("invalid config version %v", raw)
+	}
+	return int(v), nil
+}
+
+// MigrateConfigData upgrades a config.json document to CurrentConfigVersion.
+// It returns the upgraded document and the changes made; data is returned unchanged if it is current.
+func MigrateConfigData(data []byte) ([]byte, *MigrationResult, error) {
+	doc := make(map[string]any)
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, nil, fmt.Errorf("failed to parse config file: %w", err)
+	}
+	from, err := configVersion(doc)
+	if err != nil {
+		return nil, nil, err
+	}
+	result := &MigrationResult{FromVersion: from, ToVersion: from}
+	if from > CurrentConfigVersion {
+		return nil, nil, newerConfigError(from)
+	}
+	if from == CurrentConfigVersion {
+		return data, result, nil
+	}
+
+	for _, m := range migrations {
+		if m.Version <= from {
+			continue
+		}
+		for _, change := range m.Migrate(doc) {
+			result.Changes = append(result.Changes, fmt.Sprintf("v%d: %s", m.Version, change))
+		}
+		doc["version"] = m.Version
+		result.ToVersion = m.Version
+	}
+
+	// Re-encode through Config for a stable field order
+	var cfg Config
+	migrated, err := json.Marshal(doc)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to encode migrated config: %w", err)
+	}
+	if err := json.Unmarshal(migrated, &cfg); err != nil {
+		return nil, nil, fmt.Errorf("failed to decode migrated config: %w", err)
+	}
+	out, err := json.MarshalIndent(&cfg, "", "  ")
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to marshal config: %w", err)
+	}
+	return out, result, nil
+}
+
+func newerConfigError(version int) error {
+	return fmt.Errorf("config file version %d is newer than supported version %d; upgrade anytype", version, CurrentConfigVersion)
+}
+
+// MigrateConfigFile upgrades the config file at path to CurrentConfigVersion under the file lock.
+// The original is kept as <path>.v<old version>.bak. With dryRun, the file is left untouched.
+// A missing file needs no migration.
+func MigrateConfigFile(path string, dryRun bool) (*MigrationResult, error) {
+	if !dryRun {
+		unlock, err := LockFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to lock config file: %w", err)
+		}
+		defer unlock()
+	}
+	return migrateConfigFile(path, dryRun)
+}
+
+// migrateConfigFile is MigrateConfigFile for callers already holding the file lock.
+func migrateConfigFile(path string, dryRun bool) (*MigrationResult, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return &MigrationResult{Path: path, FromVersion: CurrentConfigVersion, ToVersion: CurrentConfigVersion}, nil
+		}
+		return nil, fmt.Errorf("failed to read config file: %w", err)
+	}
+
+	migrated, result, err := MigrateConfigData(data)
+	if err != nil {
+		return nil, err
+	}
+	result.Path = path
+	if !result.Needed() || dryRun {
+		return result, nil
+	}
+
+	backup := fmt.Sprintf("%s.v%d.bak", path, result.FromVersion)
+	if err := WriteFileAtomic(backup, data, 0600); err != nil {
+		return nil, fmt.Errorf("failed to back up config file: %w", err)
+	}
+	if err := WriteFileAtomic(path, migrated, 0600); err != nil {
+		return nil, fmt.Errorf("failed to write config file: %w", err)
+	}
+	result.BackupPath = backup
+	return result, nil
+}
+
+// v1Keys are the top-level fields config.json had when versioning was introduced.
+var v1Keys = []string{"accountId", "techSpaceId", "accountKey", "sessionToken", "credentials", "logLevel", "rpcTimeout", "outputFormat", "version"}
+
+// migrateToV1 normalizes the log level and drops fields no build ever read, which
+// would otherwise be lost silently on the next write.
+func migrateToV1(doc map[string]any) []string {
+	var changes []string
+
+	if level, ok := doc["logLevel"].(string); ok && level != strings.ToUpper(level) {
+		doc["logLevel"] = strings.ToUpper(level)
+		changes = append(changes, fmt.Sprintf("normalize logLevel %q to %q", level, strings.ToUpper(level)))
+	}
+
+	var unknown []string
+	for key := range doc {
+		if !slices.Contains(v1Keys, key) {
+			unknown = append(unknown, key)
+		}
+	}
+	sort.Strings(unknown)
+	for _, key := range unknown {
+		delete(doc, key)
+		changes = append(changes, fmt.Sprintf("remove unknown key %q", key))
+	}
+
+	return append(changes, "set version to 1")
+}
@@ -0,0 +1,139 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestMigrateConfigData(t *testing.T) {
+	tests := []struct {
+		name        string
+		data        string
+		wantFrom    int
+		wantChanges []string
+		wantErr     string
+	}{
+		{
+			name:     "unversioned",
+			data:     `{"accountId":"abc","logLevel":"debug","legacy":true}`,
+			wantFrom: 0,
+			wantChanges: []string{
+				`v1: normalize logLevel "debug" to "DEBUG"`,
+				`v1: remove unknown key "legacy"`,
+				"v1: set version to 1",
+			},
+		},
+		{
+			name:        "unversioned with output format",
+			data:        `{"accountId":"abc","outputFormat":"json"}`,
+			wantFrom:    0,
+			wantChanges: []string{"v1: set version to 1"},
+		},
+		{name: "current", data: `{"version":1,"accountId":"abc"}`, wantFrom: 1},
+		{name: "newer", data: `{"version":99}`, wantErr: "newer than supported"},
+		{name: "invalid version", data: `{"version":"one"}`, wantErr: "invalid config version"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, result, err := MigrateConfigData([]byte(tt.data))
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("MigrateConfigData failed: %v", err)
+			}
+			if result.FromVersion != tt.wantFrom || result.ToVersion != CurrentConfigVersion {
+				t.Errorf("versions = %d -> %d, want %d -> %d", result.FromVersion, result.ToVersion, tt.wantFrom, CurrentConfigVersion)
+			}
+			if !slices.Equal(result.Changes, tt.wantChanges) {
+				t.Errorf("changes = %q, want %q", result.Changes, tt.wantChanges)
+			}
+		})
+	}
+}
+
+func TestMigrateConfigFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.json")
+	original := `{"accountId":"abc","logLevel":"info"}`
+	if err := os.WriteFile(path, []byte(original), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := MigrateConfigFile(path, true)
+	if err != nil {
+		t.Fatalf("dry run failed: %v", err)
+	}
+	if !result.Needed() || result.BackupPath != "" {
+		t.Errorf("dry run result = %+v, want a pending upgrade without backup", result)
+	}
+	if data, _ := os.ReadFile(path); string(data) != original {
+		t.Errorf("dry run changed the file: %s", data)
+	}
+
+	result, err = MigrateConfigFile(path, false)
+	if err != nil {
+		t.Fatalf("migration failed: %v", err)
+	}
+	if backup, _ := os.ReadFile(result.BackupPath); string(backup) != original {
+		t.Errorf("backup = %s, want the original file", backup)
+	}
+
+	cfg, err := readConfig(path)
+	if err != nil {
+		t.Fatalf("readConfig failed: %v", err)
+	}
+	if cfg.Version != CurrentConfigVersion || cfg.AccountId != "abc" || cfg.LogLevel != "INFO" {
+		t.Errorf("migrated config = %+v", cfg)
+	}
+
+	if result, err = MigrateConfigFile(path, false); err != nil || result.Needed() {
+		t.Errorf("second migration = %+v, %v; want no change", result, err)
+	}
+}
+
+func TestConfigManagerUpgradesOnLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.json")
+	if err := os.WriteFile(path, []byte(`{"accountId":"abc"}`), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	cm := &ConfigManager{config: &Config{}, filePath: path}
+	if err := cm.Load(); err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if got := cm.Get(); got.Version != CurrentConfigVersion || got.AccountId != "abc" {
+		t.Errorf("loaded config = %+v", got)
+	}
+	if _, err := os.Stat(path + ".v0.bak"); err != nil {
+		t.Errorf("backup missing: %v", err)
+	}
+}
+
+func TestConfigManagerRefusesNewerVersion(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.json")
+	newer := `{"version":99,"accountId":"abc","futureField":"x"}`
+	if err := os.WriteFile(path, []byte(newer), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	cm := &ConfigManager{config: &Config{}, filePath: path}
+	if err := cm.Load(); err != nil {
+		t.Fatalf("Load should read a newer file: %v", err)
+	}
+	if got := cm.Get().AccountId; got != "abc" {
+		t.Errorf("AccountId = %q, want abc", got)
+	}
+	if err := cm.SetTechSpaceId("tech"); err == nil {
+		t.Error("updating a newer config file should fail")
+	}
+	if data, _ := os.ReadFile(path); string(data) != newer {
+		t.Errorf("newer file was changed: %s", data)
+	}
+}
@@ -0,0 +1,351 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"sync"
+)
+
+const (
+	// DefaultProfile uses the files directly in ~/.anytype, as before profiles existed.
+	DefaultProfile = "default"
+	// ProfileEnvVar selects the profile when --profile is not given.
+	ProfileEnvVar = "ANYTYPE_PROFILE"
+
+	ProfilesFileName = "profiles.json"
+	ProfilesDirName  = "profiles"
+)
+
+// Profile holds the settings of one named headless instance. Empty fields fall back to the defaults.
+type Profile struct {
+	APIAddress     string `json:"apiAddress,omitempty"`
+	GRPCAddress    string `json:"grpcAddress,omitempty"`
+	GRPCWebAddress string `json:"grpcWebAddress,omitempty"`
+	DataDir        string `json:"dataDir,omitempty"`
+	DefaultSpace   string `json:"defaultSpace,omitempty"`
+}
+
+// Profiles is the content of profiles.json.
+type Profiles struct {
+	Current  string             `json:"current,omitempty"`
+	Profiles map[string]Profile `json:"profiles,omitempty"`
+}
+
+var (
+	profileMu     sync.RWMutex
+	activeProfile string
+
+	profileNameRe = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]{0,62}$`)
+)
+
+// ValidateProfileName checks that name can be used as a profile and directory name.
+func ValidateProfileName(name string) error {
+	if !profileNameRe.MatchString(name) {
+		return fmt.Errorf("invalid profile name %q: use lowercase letters, digits, '-' and '_'", name)
+	}
+	return nil
+}
+
+// SetActiveProfile selects the profile for the rest of the process. An empty name
+// resolves the profile from ANYTYPE_PROFILE or the current profile in profiles.json.
+func SetActiveProfile(name string) error {
+	if name == "" {
+		name = os.Getenv(ProfileEnvVar)
+	}
+	if name == "" {
+		profiles, err := LoadProfiles()
+		if err != nil {
+			return err
+		}
+		name = profiles.Current
+	}
+	if name == "" {
+		name = DefaultProfile
+	}
+
+	if name != DefaultProfile {
+		if _, err := GetProfile(name); err != nil {
+			return err
+		}
+	}
+
+	profileMu.Lock()
+	activeProfile = name
+	profileMu.Unlock()
+	return nil
+}
+
+// ActiveProfile returns the profile selected with SetActiveProfile, or the default profile.
+func ActiveProfile() string {
+	profileMu.RLock()
+	defer profileMu.RUnlock()
+	if activeProfile == "" {
+		return DefaultProfile
+	}
+	return activeProfile
+}
+
+// GetActiveProfile returns the settings of the active profile.
+func GetActiveProfile() Profile {
+	p, _ := GetProfile(ActiveProfile())
+	return p
+}
+
+func getBaseConfigDir() string {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(homeDir, AnytypeDirName)
+}
+
+func GetProfilesFilePath() string {
+	return filepath.Join(getBaseConfigDir(), ProfilesFileName)
+}
+
+// getProfileDir returns the directory holding a profile's config, API key policies, encrypted credentials and logs.
+func getProfileDir(name string) string {
+	base := getBaseConfigDir()
+	if base == "" || name == DefaultProfile {
+		return base
+	}
+	return filepath.Join(base, ProfilesDirName, name)
+}
+
+func getProfileDataDir(name string) string {
+	if name == DefaultProfile {
+		return filepath.Join(GetWorkDir(), DataDirName)
+	}
+	return filepath.Join(GetWorkDir(), ProfilesDirName, name, DataDirName)
+}
+
+// LoadProfiles reads profiles.json. A missing file yields no profiles.
+func LoadProfiles() (*Profiles, error) {
+	profiles := &Profiles{Profiles: make(map[string]Profile)}
+
+	data, err := os.ReadFile(GetProfilesFilePath())
+	if err != nil {
+		if os.IsNotExist(err) {
+			return profiles, nil
+		}
+		return nil, fmt.Errorf("failed to read profiles file: %w", err)
+	}
+	if err := json.Unmarshal(data, profiles); err != nil {
+		return nil, fmt.Errorf("failed to parse profiles file: %w", err)
+	}
+	if profiles.Profiles == nil {
+		profiles.Profiles = make(map[string]Profile)
+	}
+	return profiles, nil
+}
+
+// updateProfiles is a locked read-modify-write of profiles.json. The file is only written if fn succeeds.
+func updateProfiles(fn func(*Profiles) error) error {
+	path := GetProfilesFilePath()
+	unlock, err := LockFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to lock profiles file: %w", err)
+	}
+	defer unlock()
+
+	profiles, err := LoadProfiles()
+	if err != nil {
+		return err
+	}
+	if err := fn(profiles); err != nil {
+		return err
+	}
+
+	data, err := json.MarshalIndent(profiles, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal profiles: %w", err)
+	}
+	if err := WriteFileAtomic(path, data, 0600); err != nil {
+		return fmt.Errorf("failed to write profiles file: %w", err)
+	}
+	return nil
+}
+
+// GetProfile returns a profile's settings. The default profile always exists and may have settings of its own.
+func GetProfile(name string) (Profile, error) {
+	profiles, err := LoadProfiles()
+	if err != nil {
+		return Profile{}, err
+	}
+	p, ok := profiles.Profiles[name]
+	if !ok && name != DefaultProfile {
+		return Profile{}, fmt.Errorf("profile %q does not exist", name)
+	}
+	return p, nil
+}
+
+// ListProfiles returns all profile names, including the default profile, sorted.
+func ListProfiles() ([]string, error) {
+	profiles, err := LoadProfiles()
+	if err != nil {
+		return nil, err
+	}
+	names := []string{DefaultProfile}
+	for name := range profiles.Profiles {
+		if name != DefaultProfile {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names[1:])
+	return names, nil
+}
+
+// CurrentProfile returns the profile selected with UseProfile.
+func CurrentProfile() (string, error) {
+	profiles, err := LoadProfiles()
+	if err != nil {
+		return "", err
+	}
+	if profiles.Current == "" {
+		return DefaultProfile, nil
+	}
+	return profiles.Current, nil
+}
+
+// CreateProfile adds a new profile. Missing listen addresses are filled with the next free
+// port triple after the ones used by existing profiles, the desktop app and the avoid addresses.
+func CreateProfile(name string, p Profile, avoid ...string) (Profile, error) {
+	if err := ValidateProfileName(name); err != nil {
+		return Profile{}, err
+	}
+	if name == DefaultProfile {
+		return Profile{}, fmt.Errorf("profile %q already exists", name)
+	}
+
+	err := updateProfiles(func(profiles *Profiles) error {
+		if _, ok := profiles.Profiles[name]; ok {
+			return fmt.Errorf("profile %q already exists", name)
+		}
+
+		if p.APIAddress == "" || p.GRPCAddress == "" || p.GRPCWebAddress == "" {
+			grpcPort, grpcWebPort, apiPort := nextFreePorts(profiles, avoid)
+			if p.GRPCAddress == "" {
+				p.GRPCAddress = net.JoinHostPort(LocalhostIP, strconv.Itoa(grpcPort))
+			}
+			if p.GRPCWebAddress == "" {
+				p.GRPCWebAddress = net.JoinHostPort(LocalhostIP, strconv.Itoa(grpcWebPort))
+			}
+			if p.APIAddress == "" {
+				p.APIAddress = net.JoinHostPort(LocalhostIP, strconv.Itoa(apiPort))
+			}
+		}
+
+		profiles.Profiles[name] = p
+		return nil
+	})
+	if err != nil {
+		return Profile{}, err
+	}
+	return p, nil
+}
+
+// nextFreePorts returns the first gRPC, gRPC-Web and API port triple, in steps of three
+// from the default ports, that neither a profile, the desktop app nor an avoid address uses.
+func nextFreePorts(profiles *Profiles, avoid []string) (int, int, int) {
+	used := map[int]bool{}
+	for _, port := range []string{DesktopGRPCPort, DesktopGRPCWebPort, DesktopAPIPort} {
+		n, _ := strconv.Atoi(port)
+		used[n] = true
+	}
+	addrs := append([]string{DefaultGRPCAddress, DefaultGRPCWebAddress, DefaultAPIAddress}, avoid...)
+	for _, p := range profiles.Profiles {
+		addrs = append(addrs, p.GRPCAddress, p.GRPCWebAddress, p.APIAddress)
+	}
+	for _, addr := range addrs {
+		if _, port, err := net.SplitHostPort(addr); err == nil {
+			if n, err := strconv.Atoi(port); err == nil {
+				used[n] = true
+			}
+		}
+	}
+
+	base, _ := strconv.Atoi(GRPCPort)
+	for {
+		if !used[base] && !used[base+1] && !used[base+2] {
+			return base, base + 1, base + 2
+		}
+		base += 3
+	}
+}
+
+// UpdateProfile replaces the settings of an existing profile, including the default profile.
+func UpdateProfile(name string, p Profile) error {
+	return updateProfiles(func(profiles *Profiles) error {
+		if _, ok := profiles.Profiles[name]; !ok && name != DefaultProfile {
+			return fmt.Errorf("profile %q does not exist", name)
+		}
+		profiles.Profiles[name] = p
+		return nil
+	})
+}
+
+// UseProfile makes name the profile used when neither --profile nor ANYTYPE_PROFILE is set.
+func UseProfile(name string) error {
+	return updateProfiles(func(profiles *Profiles) error {
+		if _, ok := profiles.Profiles[name]; !ok && name != DefaultProfile {
+			return fmt.Errorf("profile %q does not exist", name)
+		}
+		if name == DefaultProfile {
+			profiles.Current = ""
+		} else {
+			profiles.Current = name
+		}
+		return nil
+	})
+}
+
+// DeleteProfile removes a profile and its config directory. Its data directory is kept.
+func DeleteProfile(name string) error {
+	if name == DefaultProfile {
+		return fmt.Errorf("the default profile cannot be deleted")
+	}
+	err := updateProfiles(func(profiles *Profiles) error {
+		if _, ok := profiles.Profiles[name]; !ok {
+			return fmt.Errorf("profile %q does not exist", name)
+		}
+		delete(profiles.Profiles, name)
+		if profiles.Current == name {
+			profiles.Current = ""
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	if err := os.RemoveAll(getProfileDir(name)); err != nil {
+		return fmt.Errorf("failed to remove profile directory: %w", err)
+	}
+	return nil
+}
+
+// GetAPIAddress returns the effective API listen address of the active profile.
+func GetAPIAddress() string {
+	return Value("listenAddress")
+}
+
+// GetGRPCAddress returns the effective gRPC listen address of the active profile.
+func GetGRPCAddress() string {
+	return Value("grpcListenAddress")
+}
+
+// GetGRPCWebAddress returns the effective gRPC-Web listen address of the active profile.
+func GetGRPCWebAddress() string {
+	return Value("grpcWebListenAddress")
+}
+
+// GetGRPCDialAddress returns the address clients use to reach the active profile's gRPC server.
+func GetGRPCDialAddress() string {
+	return "dns:///" + GetGRPCAddress()
+}
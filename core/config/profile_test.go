@@ -0,0 +1,143 @@
+package config
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func setupProfileTest(t *testing.T) string {
+	t.Helper()
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv(ProfileEnvVar, "")
+	t.Cleanup(func() {
+		profileMu.Lock()
+		activeProfile = ""
+		profileMu.Unlock()
+	})
+	return home
+}
+
+func TestCreateProfileAllocatesPorts(t *testing.T) {
+	setupProfileTest(t)
+
+	staging, err := CreateProfile("staging", Profile{})
+	if err != nil {
+		t.Fatalf("CreateProfile failed: %v", err)
+	}
+	want := Profile{GRPCAddress: "127.0.0.1:31013", GRPCWebAddress: "127.0.0.1:31014", APIAddress: "127.0.0.1:31015"}
+	if staging != want {
+		t.Errorf("CreateProfile() = %+v, want %+v", staging, want)
+	}
+
+	prod, err := CreateProfile("prod", Profile{APIAddress: "0.0.0.0:8080"})
+	if err != nil {
+		t.Fatalf("CreateProfile failed: %v", err)
+	}
+	if prod.GRPCAddress != "127.0.0.1:31016" || prod.APIAddress != "0.0.0.0:8080" {
+		t.Errorf("CreateProfile() = %+v, expected next free gRPC port and the given API address", prod)
+	}
+
+	// Ports used outside profiles, e.g. by an installed service's flags, are skipped
+	qa, err := CreateProfile("qa", Profile{}, "127.0.0.1:31019", "0.0.0.0:31020")
+	if err != nil {
+		t.Fatalf("CreateProfile failed: %v", err)
+	}
+	if qa.GRPCAddress != "127.0.0.1:31022" {
+		t.Errorf("CreateProfile() = %+v, expected the ports after the avoided ones", qa)
+	}
+
+	if _, err := CreateProfile("prod", Profile{}); err == nil {
+		t.Error("Expected error for duplicate profile")
+	}
+	if _, err := CreateProfile(DefaultProfile, Profile{}); err == nil {
+		t.Error("Expected error for creating the default profile")
+	}
+	if _, err := CreateProfile("Bad Name", Profile{}); err == nil {
+		t.Error("Expected error for invalid profile name")
+	}
+
+	names, err := ListProfiles()
+	if err != nil {
+		t.Fatalf("ListProfiles failed: %v", err)
+	}
+	if !reflect.DeepEqual(names, []string{DefaultProfile, "prod", "qa", "staging"}) {
+		t.Errorf("ListProfiles() = %v", names)
+	}
+}
+
+func TestActiveProfilePaths(t *testing.T) {
+	home := setupProfileTest(t)
+
+	if got := GetConfigDir(); got != filepath.Join(home, AnytypeDirName) {
+		t.Errorf("GetConfigDir() for default profile = %s", got)
+	}
+
+	if _, err := CreateProfile("staging", Profile{DefaultSpace: "space1"}); err != nil {
+		t.Fatalf("CreateProfile failed: %v", err)
+	}
+	if err := UseProfile("staging"); err != nil {
+		t.Fatalf("UseProfile failed: %v", err)
+	}
+	if err := SetActiveProfile(""); err != nil {
+		t.Fatalf("SetActiveProfile failed: %v", err)
+	}
+
+	if got := ActiveProfile(); got != "staging" {
+		t.Errorf("ActiveProfile() = %s, want staging", got)
+	}
+	if got, want := GetConfigFilePath(), filepath.Join(home, AnytypeDirName, ProfilesDirName, "staging", ConfigFileName); got != want {
+		t.Errorf("GetConfigFilePath() = %s, want %s", got, want)
+	}
+	if got := GetAPIAddress(); got != "127.0.0.1:31015" {
+		t.Errorf("GetAPIAddress() = %s", got)
+	}
+	if got := GetGRPCDialAddress(); got != "dns:///127.0.0.1:31013" {
+		t.Errorf("GetGRPCDialAddress() = %s", got)
+	}
+	if got := GetConfigManager().GetFilePath(); got != GetConfigFilePath() {
+		t.Errorf("config manager uses %s, want %s", got, GetConfigFilePath())
+	}
+
+	t.Setenv(ProfileEnvVar, DefaultProfile)
+	if err := SetActiveProfile(""); err != nil {
+		t.Fatalf("SetActiveProfile failed: %v", err)
+	}
+	if got := ActiveProfile(); got != DefaultProfile {
+		t.Errorf("ANYTYPE_PROFILE should take precedence, got %s", got)
+	}
+
+	if err := SetActiveProfile("missing"); err == nil {
+		t.Error("Expected error for missing profile")
+	}
+}
+
+func TestDeleteProfile(t *testing.T) {
+	setupProfileTest(t)
+
+	if err := DeleteProfile(DefaultProfile); err == nil {
+		t.Error("Expected error deleting the default profile")
+	}
+
+	if _, err := CreateProfile("staging", Profile{}); err != nil {
+		t.Fatalf("CreateProfile failed: %v", err)
+	}
+	if err := UseProfile("staging"); err != nil {
+		t.Fatalf("UseProfile failed: %v", err)
+	}
+	if err := DeleteProfile("staging"); err != nil {
+		t.Fatalf("DeleteProfile failed: %v", err)
+	}
+
+	current, err := CurrentProfile()
+	if err != nil {
+		t.Fatalf("CurrentProfile failed: %v", err)
+	}
+	if current != DefaultProfile {
+		t.Errorf("CurrentProfile() after delete = %s, want default", current)
+	}
+	if _, err := GetProfile("staging"); err == nil {
+		t.Error("Expected deleted profile to be gone")
+	}
+}
@@ -0,0 +1,370 @@
+package config
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+)
+
+// Source tells where the effective value of a config key comes from.
+type Source string
+
+const (
+	SourceFlag    Source = "flag"
+	SourceEnv     Source = "env"
+	SourceFile    Source = "file"
+	SourceDefault Source = "default"
+)
+
+// Key describes one config key: its documentation, default, validation and where it is stored.
+// Keys stored in the profile live in profiles.json, all others in the profile's config.json.
+type Key struct {
+	Name        string
+	Description string
+	// Default returns the value used when the key is not set anywhere
+	Default func() string
+	// Validate checks a value before it is stored; nil accepts anything
+	Validate func(string) error
+
+	get func(*Config, *Profile) string
+	set func(*Config, *Profile, string)
+	// profile is set for keys stored in profiles.json rather than config.json
+	profile bool
+}
+
+// EnvVar returns the environment variable overriding the key, e.g. ANYTYPE_LISTEN_ADDRESS for listenAddress.
+func (k *Key) EnvVar() string {
+	var b strings.Builder
+	b.WriteString("ANYTYPE_")
+	prevLower := false
+	for _, r := range k.Name {
+		switch {
+		case r == '.':
+			b.WriteByte('_')
+			prevLower = false
+			continue
+		case unicode.IsUpper(r) && prevLower:
+			b.WriteByte('_')
+		}
+		b.WriteRune(unicode.ToUpper(r))
+		prevLower = unicode.IsLower(r) || unicode.IsDigit(r)
+	}
+	return b.String()
+}
+
+const (
+	LogLevelDebug = "DEBUG"
+	LogLevelInfo  = "INFO"
+	LogLevelWarn  = "WARN"
+	LogLevelError = "ERROR"
+	LogLevelFatal = "FATAL"
+
+	OutputText = "text"
+	OutputJSON = "json"
+
+	DefaultRPCTimeout = 5 * time.Second
+)
+
+func constant(value string) func() string {
+	return func() string { return value }
+}
+
+func validateAddress(value string) error {
+	if _, _, err := net.SplitHostPort(value); err != nil {
+		return fmt.Errorf("invalid address %q: expected host:port", value)
+	}
+	return nil
+}
+
+func validateLogLevel(value string) error {
+	switch strings.ToUpper(value) {
+	case LogLevelDebug, LogLevelInfo, LogLevelWarn, LogLevelError, LogLevelFatal:
+		return nil
+	}
+	return fmt.Errorf("invalid log level %q: expected DEBUG, INFO, WARN, ERROR or FATAL", value)
+}
+
+func validatePositiveDuration(value string) error {
+	d, err := time.ParseDuration(value)
+	if err != nil || d <= 0 {
+		return fmt.Errorf("invalid duration %q: expected a positive duration such as 5s or 1m", value)
+	}
+	return nil
+}
+
+// CredentialsBackends lists the values of the credentials.backend key. Package credentials
+// implements them.
+var CredentialsBackends = []string{"auto", "keyring", "file", "encrypted", "env", "helper"}
+
+func validateCredentialsBackend(value string) error {
+	if !slices.Contains(CredentialsBackends, strings.ToLower(strings.TrimSpace(value))) {
+		return fmt.Errorf("unknown credentials backend %q (available: %s)", value, strings.Join(CredentialsBackends, ", "))
+	}
+	return nil
+}
+
+// ValidateOutputFormat checks an output format given with --output or the outputFormat key.
+func ValidateOutputFormat(value string) error {
+	if value != OutputText && value != OutputJSON {
+		return fmt.Errorf("invalid output format %q: expected text or json", value)
+	}
+	return nil
+}
+
+// Keys lists all config keys in display order.
+var Keys = []*Key{
+	{
+		Name:        "listenAddress",
+		Description: "API listen address of the server",
+		Default:     constant(DefaultAPIAddress),
+		Validate:    validateAddress,
+		get:         func(_ *Config, p *Profile) string { return p.APIAddress },
+		set:         func(_ *Config, p *Profile, v string) { p.APIAddress = v },
+		profile:     true,
+	},
+	{
+		Name:        "grpcListenAddress",
+		Description: "gRPC listen address of the server, also used by client commands",
+		Default:     constant(DefaultGRPCAddress),
+		Validate:    validateAddress,
+		get:         func(_ *Config, p *Profile) string { return p.GRPCAddress },
+		set:         func(_ *Config, p *Profile, v string) { p.GRPCAddress = v },
+		profile:     true,
+	},
+	{
+		Name:        "grpcWebListenAddress",
+		Description: "gRPC-Web listen address of the server",
+		Default:     constant(DefaultGRPCWebAddress),
+		Validate:    validateAddress,
+		get:         func(_ *Config, p *Profile) string { return p.GRPCWebAddress },
+		set:         func(_ *Config, p *Profile, v string) { p.GRPCWebAddress = v },
+		profile:     true,
+	},
+	{
+		Name:        "dataDir",
+		Description: "Directory holding the account data (DATA_PATH is also honoured)",
+		Default:     func() string { return getProfileDataDir(ActiveProfile()) },
+		get:         func(_ *Config, p *Profile) string { return p.DataDir },
+		set:         func(_ *Config, p *Profile, v string) { p.DataDir = v },
+		profile:     true,
+	},
+	{
+		Name:        "defaultSpace",
+		Description: "Space id used by space commands when none is given",
+		Default:     constant(""),
+		get:         func(_ *Config, p *Profile) string { return p.DefaultSpace },
+		set:         func(_ *Config, p *Profile, v string) { p.DefaultSpace = v },
+		profile:     true,
+	},
+	{
+		Name:        "logLevel",
+		Description: "Log level of the server: DEBUG, INFO, WARN, ERROR or FATAL",
+		Default:     constant(LogLevelError),
+		Validate:    validateLogLevel,
+		get:         func(c *Config, _ *Profile) string { return c.LogLevel },
+		set:         func(c *Config, _ *Profile, v string) { c.LogLevel = strings.ToUpper(v) },
+	},
+	{
+		Name:        "outputFormat",
+		Description: "Default output format of commands with --output: text or json",
+		Default:     constant(OutputText),
+		Validate:    ValidateOutputFormat,
+		get:         func(c *Config, _ *Profile) string { return c.OutputFormat },
+		set:         func(c *Config, _ *Profile, v string) { c.OutputFormat = v },
+	},
+	{
+		Name:        "rpcTimeout",
+		Description: "Timeout for client calls to the server",
+		Default:     constant(DefaultRPCTimeout.String()),
+		Validate:    validatePositiveDuration,
+		get:         func(c *Config, _ *Profile) string { return c.RPCTimeout },
+		set:         func(c *Config, _ *Profile, v string) { c.RPCTimeout = v },
+	},
+	{
+		Name:        "credentials.backend",
+		Description: "Where the account key and session token are stored",
+		Default:     constant("auto"),
+		Validate:    validateCredentialsBackend,
+		get:         func(c *Config, _ *Profile) string { return c.Credentials.Backend },
+		set:         func(c *Config, _ *Profile, v string) { c.Credentials.Backend = v },
+	},
+	{
+		Name:        "credentials.keyFile",
+		Description: "Passphrase file for the encrypted credentials backend",
+		Default:     constant(""),
+		get:         func(c *Config, _ *Profile) string { return c.Credentials.KeyFile },
+		set:         func(c *Config, _ *Profile, v string) { c.Credentials.KeyFile = v },
+	},
+	{
+		Name:        "credentials.helper",
+		Description: "Command implementing the credential helper protocol",
+		Default:     constant(""),
+		get:         func(c *Config, _ *Profile) string { return c.Credentials.Helper },
+		set:         func(c *Config, _ *Profile, v string) { c.Credentials.Helper = v },
+	},
+	{
+		Name:        "accountId",
+		Description: "Id of the logged-in account (set by login)",
+		Default:     constant(""),
+		get:         func(c *Config, _ *Profile) string { return c.AccountId },
+		set:         func(c *Config, _ *Profile, v string) { c.AccountId = v },
+	},
+	{
+		Name:        "techSpaceId",
+		Description: "Id of the account's tech space (set by login)",
+		Default:     constant(""),
+		get:         func(c *Config, _ *Profile) string { return c.TechSpaceId },
+		set:         func(c *Config, _ *Profile, v string) { c.TechSpaceId = v },
+	},
+}
+
+// LookupKey returns the key with the given name.
+func LookupKey(name string) (*Key, error) {
+	i := slices.IndexFunc(Keys, func(k *Key) bool { return k.Name == name })
+	if i < 0 {
+		return nil, fmt.Errorf("unknown config key: %s", name)
+	}
+	return Keys[i], nil
+}
+
+var (
+	flagMu        sync.RWMutex
+	flagOverrides = make(map[string]string)
+)
+
+// SetFlagValue records a value given on the command line. It takes precedence over
+// the environment and the config files for the rest of the process.
+func SetFlagValue(name, value string) error {
+	key, err := LookupKey(name)
+	if err != nil {
+		return err
+	}
+	if key.Validate != nil {
+		if err := key.Validate(value); err != nil {
+			return err
+		}
+	}
+	flagMu.Lock()
+	flagOverrides[name] = value
+	flagMu.Unlock()
+	return nil
+}
+
+// Resolve returns the effective value of a key and its source. Flags take precedence
+// over ANYTYPE_<KEY> environment variables, which take precedence over the config files.
+func Resolve(name string) (string, Source, error) {
+	key, err := LookupKey(name)
+	if err != nil {
+		return "", "", err
+	}
+
+	flagMu.RLock()
+	value, ok := flagOverrides[name]
+	flagMu.RUnlock()
+	if ok {
+		return value, SourceFlag, nil
+	}
+
+	if value := os.Getenv(key.EnvVar()); value != "" {
+		return value, SourceEnv, nil
+	}
+
+	value, err = storedValue(key)
+	if err != nil {
+		return "", "", err
+	}
+	if value != "" {
+		return value, SourceFile, nil
+	}
+
+	return key.Default(), SourceDefault, nil
+}
+
+// Value returns the effective value of a key, falling back to its default if the config cannot be read.
+func Value(name string) string {
+	value, _, err := Resolve(name)
+	if err != nil {
+		if key, lookupErr := LookupKey(name); lookupErr == nil {
+			return key.Default()
+		}
+	}
+	return value
+}
+
+// StoredValue returns a key's value from the config files or its default, ignoring flags and
+// the environment. This is the value a separately started process, such as the service, sees.
+func StoredValue(name string) string {
+	key, err := LookupKey(name)
+	if err != nil {
+		return ""
+	}
+	if value, err := storedValue(key); err == nil && value != "" {
+		return value
+	}
+	return key.Default()
+}
+
+func storedValue(key *Key) (string, error) {
+	if key.profile {
+		p, err := GetProfile(ActiveProfile())
+		if err != nil {
+			return "", err
+		}
+		return key.get(nil, &p), nil
+	}
+	cfg, err := LoadStoredConfig()
+	if err != nil {
+		return "", err
+	}
+	return key.get(cfg, nil), nil
+}
+
+// SetValue validates a value and stores it in the config file of the active profile.
+// An empty value removes the key so its default applies again.
+func SetValue(name, value string) error {
+	key, err := LookupKey(name)
+	if err != nil {
+		return err
+	}
+	if value != "" && key.Validate != nil {
+		if err := key.Validate(value); err != nil {
+			return err
+		}
+	}
+
+	if key.profile {
+		profile := ActiveProfile()
+		return updateProfiles(func(profiles *Profiles) error {
+			p, ok := profiles.Profiles[profile]
+			if !ok && profile != DefaultProfile {
+				return fmt.Errorf("profile %q does not exist", profile)
+			}
+			key.set(nil, &p, value)
+			profiles.Profiles[profile] = p
+			return nil
+		})
+	}
+
+	return GetConfigManager().Update(func(cfg *Config) {
+		key.set(cfg, nil, value)
+	})
+}
+
+// GetLogLevel returns the effective server log level.
+func GetLogLevel() string {
+	return strings.ToUpper(Value("logLevel"))
+}
+
+// GetRPCTimeout returns the effective timeout for client calls.
+func GetRPCTimeout() time.Duration {
+	d, err := time.ParseDuration(Value("rpcTimeout"))
+	if err != nil || d <= 0 {
+		return DefaultRPCTimeout
+	}
+	return d
+}
@@ -0,0 +1,110 @@
+package config
+
+import (
+	"testing"
+	"time"
+)
+
+func resetFlagValues(t *testing.T) {
+	t.Helper()
+	t.Cleanup(func() {
+		flagMu.Lock()
+		flagOverrides = make(map[string]string)
+		flagMu.Unlock()
+	})
+}
+
+func TestKeyEnvVar(t *testing.T) {
+	tests := map[string]string{
+		"listenAddress":        "ANYTYPE_LISTEN_ADDRESS",
+		"grpcWebListenAddress": "ANYTYPE_GRPC_WEB_LISTEN_ADDRESS",
+		"logLevel":             "ANYTYPE_LOG_LEVEL",
+		"credentials.backend":  "ANYTYPE_CREDENTIALS_BACKEND",
+		"credentials.keyFile":  "ANYTYPE_CREDENTIALS_KEY_FILE",
+		"techSpaceId":          "ANYTYPE_TECH_SPACE_ID",
+	}
+	for name, want := range tests {
+		key, err := LookupKey(name)
+		if err != nil {
+			t.Fatalf("LookupKey(%s) failed: %v", name, err)
+		}
+		if got := key.EnvVar(); got != want {
+			t.Errorf("EnvVar(%s) = %s, want %s", name, got, want)
+		}
+	}
+}
+
+func TestResolvePrecedence(t *testing.T) {
+	setupProfileTest(t)
+	resetFlagValues(t)
+	t.Setenv("ANYTYPE_LOG_LEVEL", "")
+
+	assertValue := func(want string, wantSource Source) {
+		t.Helper()
+		value, source, err := Resolve("logLevel")
+		if err != nil {
+			t.Fatalf("Resolve failed: %v", err)
+		}
+		if value != want || source != wantSource {
+			t.Errorf("Resolve(logLevel) = %s from %s, want %s from %s", value, source, want, wantSource)
+		}
+	}
+
+	assertValue(LogLevelError, SourceDefault)
+
+	if err := SetValue("logLevel", "warn"); err != nil {
+		t.Fatalf("SetValue failed: %v", err)
+	}
+	assertValue(LogLevelWarn, SourceFile)
+
+	t.Setenv("ANYTYPE_LOG_LEVEL", "INFO")
+	assertValue(LogLevelInfo, SourceEnv)
+
+	if err := SetFlagValue("logLevel", LogLevelDebug); err != nil {
+		t.Fatalf("SetFlagValue failed: %v", err)
+	}
+	assertValue(LogLevelDebug, SourceFlag)
+
+	if got := StoredValue("logLevel"); got != LogLevelWarn {
+		t.Errorf("StoredValue(logLevel) = %s, want %s", got, LogLevelWarn)
+	}
+}
+
+func TestSetValueValidation(t *testing.T) {
+	setupProfileTest(t)
+
+	tests := []struct {
+		key     string
+		value   string
+		wantErr bool
+	}{
+		{"listenAddress", "0.0.0.0:8080", false},
+		{"listenAddress", "8080", true},
+		{"logLevel", "verbose", true},
+		{"rpcTimeout", "10s", false},
+		{"rpcTimeout", "-1s", true},
+		{"outputFormat", "json", false},
+		{"outputFormat", "yaml", true},
+		{"unknownKey", "value", true},
+	}
+	for _, tt := range tests {
+		err := SetValue(tt.key, tt.value)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("SetValue(%s, %s) error = %v, wantErr %v", tt.key, tt.value, err, tt.wantErr)
+		}
+	}
+
+	if got := GetAPIAddress(); got != "0.0.0.0:8080" {
+		t.Errorf("GetAPIAddress() = %s, want value stored in the default profile", got)
+	}
+	if got := GetRPCTimeout(); got != 10*time.Second {
+		t.Errorf("GetRPCTimeout() = %s, want 10s", got)
+	}
+
+	if err := SetValue("listenAddress", ""); err != nil {
+		t.Fatalf("SetValue failed: %v", err)
+	}
+	if got := GetAPIAddress(); got != DefaultAPIAddress {
+		t.Errorf("GetAPIAddress() after unset = %s, want default", got)
+	}
+}
@@ -0,0 +1,116 @@
+// Package credentials stores the CLI's secrets (account key and session token) in one of
+// several backends: the OS keyring, the config file, an encrypted file, environment variables
+// or an external credential helper.
+package credentials
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/anyproto/anytype-cli/core/config"
+)
+
+// Well-known credential names.
+const (
+	AccountKey   = "account-key"
+	SessionToken = "session-token"
+)
+
+// Backend names, as used in the credentials.backend config setting.
+const (
+	BackendAuto      = "auto"
+	BackendKeyring   = "keyring"
+	BackendFile      = "file"
+	BackendEncrypted = "encrypted"
+	BackendEnv       = "env"
+	BackendHelper    = "helper"
+)
+
+// BackendEnvVar overrides the credentials.backend config setting.
+const BackendEnvVar = "ANYTYPE_CREDENTIALS_BACKEND"
+
+var ErrNotFound = errors.New("credentials not found")
+
+// Backend stores named secrets.
+type Backend interface {
+	Name() string
+	// Get returns ErrNotFound if the secret is not stored
+	Get(name string) (string, error)
+	Set(name, value string) error
+	// Delete succeeds if the secret is not stored
+	Delete(name string) error
+}
+
+// Backends lists the selectable backend names, as declared by the credentials.backend config key.
+func Backends() []string {
+	return slices.Clone(config.CredentialsBackends)
+}
+
+// ParseBackend validates a backend name. Empty means auto.
+func ParseBackend(name string) (string, error) {
+	name = strings.ToLower(strings.TrimSpace(name))
+	if name == "" {
+		return BackendAuto, nil
+	}
+	for _, b := range Backends() {
+		if name == b {
+			return name, nil
+		}
+	}
+	return "", fmt.Errorf("unknown credentials backend %q (available: %s)", name, strings.Join(Backends(), ", "))
+}
+
+// ConfiguredBackend returns the backend name selected by the environment or config file.
+func ConfiguredBackend(cfg config.CredentialsConfig) (string, error) {
+	if env := os.Getenv(BackendEnvVar); env != "" {
+		return ParseBackend(env)
+	}
+	return ParseBackend(cfg.Backend)
+}
+
+// New creates the backend with the given name. Auto is resolved by the caller, since it
+// depends on whether the keyring is usable.
+func New(name string, cfg config.CredentialsConfig) (Backend, error) {
+	switch name {
+	case BackendKeyring:
+		return NewKeyring(), nil
+	case BackendFile:
+		return NewConfigFile(), nil
+	case BackendEncrypted:
+		passphrase, err := encryptionPassphrase(cfg)
+		if err != nil {
+			return nil, err
+		}
+		return NewEncryptedFile(config.GetCredentialsFilePath(), passphrase), nil
+	case BackendEnv:
+		return processEnv, nil
+	case BackendHelper:
+		if cfg.Helper == "" {
+			return nil, fmt.Errorf("credentials backend %q requires credentials.helper to be set", BackendHelper)
+		}
+		return NewHelper(cfg.Helper), nil
+	default:
+		return nil, fmt.Errorf("unknown credentials backend %q", name)
+	}
+}
+
+// Describe returns a human-readable location for a backend, for status messages.
+func Describe(name string) string {
+	switch name {
+	case BackendKeyring:
+		return "keychain"
+	case BackendFile:
+		return "config file"
+	case BackendEncrypted:
+		return "encrypted file"
+	case BackendEnv:
+		return "environment"
+	case BackendHelper:
+		return "credential helper"
+	default:
+		return name
+	}
+}
@@ -0,0 +1,237 @@
+package credentials
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/anyproto/anytype-cli/core/config"
+)
+
+func testBackend(t *testing.T, b Backend) {
+	t.Helper()
+
+	if _, err := b.Get(AccountKey); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("Get on empty backend: got %v, want ErrNotFound", err)
+	}
+
+	if err := b.Set(AccountKey, "account-secret"); err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+	if err := b.Set(SessionToken, "token-secret"); err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+
+	if got, err := b.Get(AccountKey); err != nil || got != "account-secret" {
+		t.Errorf("Get(AccountKey) = %q, %v", got, err)
+	}
+	if got, err := b.Get(SessionToken); err != nil || got != "token-secret" {
+		t.Errorf("Get(SessionToken) = %q, %v", got, err)
+	}
+
+	if err := b.Delete(AccountKey); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+	if _, err := b.Get(AccountKey); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Get after Delete: got %v, want ErrNotFound", err)
+	}
+	if err := b.Delete(AccountKey); err != nil {
+		t.Errorf("Delete of missing secret should succeed, got %v", err)
+	}
+	if got, err := b.Get(SessionToken); err != nil || got != "token-secret" {
+		t.Errorf("other secret should survive Delete, got %q, %v", got, err)
+	}
+}
+
+func TestEncryptedFileBackend(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "credentials.enc")
+	testBackend(t, NewEncryptedFile(path, "correct horse"))
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("credentials file missing: %v", err)
+	}
+	if strings.Contains(string(data), "token-secret") {
+		t.Error("credentials file contains the secret in plain text")
+	}
+	if runtime.GOOS != "windows" {
+		info, _ := os.Stat(path)
+		if info.Mode().Perm() != 0600 {
+			t.Errorf("file mode = %v, want 0600", info.Mode().Perm())
+		}
+	}
+
+	if _, err := NewEncryptedFile(path, "wrong").Get(SessionToken); !errors.Is(err, ErrWrongPassphrase) {
+		t.Errorf("Get with wrong passphrase: got %v, want ErrWrongPassphrase", err)
+	}
+}
+
+func TestEncryptedFileBackendConcurrentWriters(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "credentials.enc")
+
+	// Separate backends stand in for separate processes: only the file lock orders their updates
+	var wg sync.WaitGroup
+	errs := make(chan error, 4)
+	for _, name := range []string{AccountKey, SessionToken} {
+		wg.Add(1)
+		go func(name string) {
+			defer wg.Done()
+			b := NewEncryptedFile(path, "correct horse")
+			for i := 0; i < 2; i++ {
+				if err := b.Set(name, name+"-value"); err != nil {
+					errs <- err
+				}
+			}
+		}(name)
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Fatalf("Set failed: %v", err)
+	}
+
+	b := NewEncryptedFile(path, "correct horse")
+	for _, name := range []string{AccountKey, SessionToken} {
+		if got, err := b.Get(name); err != nil || got != name+"-value" {
+			t.Errorf("Get(%s) = %q, %v; an update was lost", name, got, err)
+		}
+	}
+}
+
+func TestEnvBackend(t *testing.T) {
+	t.Setenv("ANYTYPE_ACCOUNT_KEY", "")
+	t.Setenv("ANYTYPE_SESSION_TOKEN", "")
+	testBackend(t, NewEnv())
+
+	t.Setenv("ANYTYPE_ACCOUNT_KEY", "from-env")
+	b := NewEnv()
+	if got, err := b.Get(AccountKey); err != nil || got != "from-env" {
+		t.Errorf("Get(AccountKey) = %q, %v, want from-env", got, err)
+	}
+	if err := b.Delete(AccountKey); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := b.Get(AccountKey); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Delete should mask the environment variable, got %v", err)
+	}
+}
+
+func TestHelperBackend(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("helper test uses a shell script")
+	}
+
+	dir := t.TempDir()
+	script := filepath.Join(dir, "helper.sh")
+	// Stores each secret in a file named after it
+	err := os.WriteFile(script, []byte(`#!/bin/sh
+dir="$(dirname "$0")/store"
+mkdir -p "$dir"
+while IFS= read -r line && [ -n "$line" ]; do
+  case "$line" in
+    name=*) name="${line#name=}" ;;
+    value=*) value="${line#value=}" ;;
+  esac
+done
+case "$1" in
+  get) [ -f "$dir/$name" ] && printf 'value=%s\n' "$(cat "$dir/$name")" ;;
+  store) printf '%s' "$value" > "$dir/$name" ;;
+  erase) rm -f "$dir/$name" ;;
+esac
+exit 0
+`), 0700)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	testBackend(t, NewHelper(script))
+
+	if err := NewHelper("exit 1;").Set(AccountKey, "x"); err == nil {
+		t.Error("expected error from failing helper")
+	}
+}
+
+func TestParseBackend(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    string
+		wantErr bool
+	}{
+		{"", BackendAuto, false},
+		{"Keyring", BackendKeyring, false},
+		{"encrypted", BackendEncrypted, false},
+		{"vault", "", true},
+	}
+	for _, tt := range tests {
+		got, err := ParseBackend(tt.input)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("ParseBackend(%q) = %q, %v", tt.input, got, err)
+		}
+	}
+}
+
+func TestBackendKeyValidation(t *testing.T) {
+	key, err := config.LookupKey("credentials.backend")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The config schema declares the names this package implements
+	for _, b := range []string{BackendAuto, BackendKeyring, BackendFile, BackendEncrypted, BackendEnv, BackendHelper} {
+		if err := key.Validate(b); err != nil {
+			t.Errorf("Validate(%q) = %v", b, err)
+		}
+	}
+	if err := key.Validate("vault"); err == nil {
+		t.Error("Validate(vault) should fail")
+	}
+}
+
+func TestConfiguredBackendEnvOverride(t *testing.T) {
+	t.Setenv(BackendEnvVar, "env")
+	got, err := ConfiguredBackend(config.CredentialsConfig{Backend: BackendKeyring})
+	if err != nil || got != BackendEnv {
+		t.Errorf("ConfiguredBackend = %q, %v, want env", got, err)
+	}
+}
+
+func TestNewEncryptedRequiresPassphrase(t *testing.T) {
+	t.Setenv(PassphraseEnvVar, "")
+	if _, err := New(BackendEncrypted, config.CredentialsConfig{}); err == nil {
+		t.Error("expected error without passphrase")
+	}
+
+	keyFile := filepath.Join(t.TempDir(), "key")
+	if err := os.WriteFile(keyFile, []byte("secret\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := New(BackendEncrypted, config.CredentialsConfig{KeyFile: keyFile}); err != nil {
+		t.Errorf("unexpected error with key file: %v", err)
+	}
+
+	if _, err := New(BackendHelper, config.CredentialsConfig{}); err == nil {
+		t.Error("expected error without helper command")
+	}
+}
+
+func TestSealOpen(t *testing.T) {
+	sealed, err := Seal([]byte("secret"), "passphrase")
+	if err != nil {
+		t.Fatalf("Seal failed: %v", err)
+	}
+
+	plain, err := Open(sealed, "passphrase")
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	if string(plain) != "secret" {
+		t.Errorf("Expected %q, got %q", "secret", plain)
+	}
+
+	if _, err := Open(sealed, "other"); !errors.Is(err, ErrWrongPassphrase) {
+		t.Errorf("Expected ErrWrongPassphrase, got %v", err)
+	}
+}
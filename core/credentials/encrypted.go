@@ -0,0 +1,261 @@
+package credentials
+
+import (
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"golang.org/x/crypto/nacl/secretbox"
+	"golang.org/x/crypto/scrypt"
+
+	"github.com/anyproto/anytype-cli/core/config"
+)
+
+// PassphraseEnvVar holds the passphrase for the encrypted backend. It takes precedence over credentials.keyFile.
+const PassphraseEnvVar = "ANYTYPE_CREDENTIALS_PASSPHRASE"
+
+const (
+	encryptedFileVersion = 1
+	saltSize             = 16
+	nonceSize            = 24
+	keySize              = 32
+
+	// scrypt parameters recommended for interactive logins
+	scryptN = 1 << 15
+	scryptR = 8
+	scryptP = 1
+)
+
+var ErrWrongPassphrase = errors.New("cannot decrypt credentials: wrong passphrase or corrupted file")
+
+// encryptedFile is the on-disk format: all secrets as one JSON object sealed with NaCl secretbox
+// under a key derived from the passphrase with scrypt.
+type encryptedFile struct {
+	Version int    `json:"version"`
+	KDF     string `json:"kdf"`
+	Salt    []byte `json:"salt"`
+	Nonce   []byte `json:"nonce"`
+	Box     []byte `json:"box"`
+}
+
+// The in-process mutex serializes goroutines; the file lock taken by update also keeps other
+// anytype processes from interleaving their read-modify-write cycles with ours.
+type encryptedFileBackend struct {
+	mu         sync.Mutex
+	path       string
+	passphrase string
+}
+
+// NewEncryptedFile returns a backend storing secrets in path, encrypted with passphrase.
+func NewEncryptedFile(path, passphrase string) Backend {
+	return &encryptedFileBackend{path: path, passphrase: passphrase}
+}
+
+func encryptionPassphrase(cfg config.CredentialsConfig) (string, error) {
+	if p := os.Getenv(PassphraseEnvVar); p != "" {
+		return p, nil
+	}
+	if cfg.KeyFile == "" {
+		return "", fmt.Errorf("credentials backend %q requires %s or credentials.keyFile to be set", BackendEncrypted, PassphraseEnvVar)
+	}
+	data, err := os.ReadFile(cfg.KeyFile)
+	if err != nil {
+		return "", fmt.Errorf("failed to read credentials key file: %w", err)
+	}
+	p := strings.TrimSpace(string(data))
+	if p == "" {
+		return "", fmt.Errorf("credentials key file %s is empty", cfg.KeyFile)
+	}
+	return p, nil
+}
+
+func (b *encryptedFileBackend) Name() string {
+	return BackendEncrypted
+}
+
+func (b *encryptedFileBackend) Get(name string) (string, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	secrets, _, err := b.load()
+	if err != nil {
+		return "", err
+	}
+	value, ok := secrets[name]
+	if !ok {
+		return "", ErrNotFound
+	}
+	return value, nil
+}
+
+func (b *encryptedFileBackend) Set(name, value string) error {
+	return b.update(func(secrets map[string]string) bool {
+		secrets[name] = value
+		return true
+	})
+}
+
+func (b *encryptedFileBackend) Delete(name string) error {
+	return b.update(func(secrets map[string]string) bool {
+		if _, ok := secrets[name]; !ok {
+			return false
+		}
+		delete(secrets, name)
+		return true
+	})
+}
+
+// update is a locked read-modify-write of the file: fn changes the secrets and reports whether
+// anything changed. The file is removed once the last secret is gone.
+func (b *encryptedFileBackend) update(fn func(map[string]string) bool) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if err := os.MkdirAll(filepath.Dir(b.path), 0700); err != nil {
+		return fmt.Errorf("failed to create config directory: %w", err)
+	}
+	unlock, err := config.LockFile(b.path)
+	if err != nil {
+		return fmt.Errorf("failed to lock credentials file: %w", err)
+	}
+	defer unlock()
+
+	secrets, salt, err := b.load()
+	if err != nil {
+		return err
+	}
+	if !fn(secrets) {
+		return nil
+	}
+	if len(secrets) == 0 {
+		if err := os.Remove(b.path); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to remove credentials file: %w", err)
+		}
+		return nil
+	}
+	return b.save(secrets, salt)
+}
+
+// load decrypts the file. A missing file yields no secrets and no salt.
+func (b *encryptedFileBackend) load() (map[string]string, []byte, error) {
+	secrets := make(map[string]string)
+
+	data, err := os.ReadFile(b.path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return secrets, nil, nil
+		}
+		return nil, nil, fmt.Errorf("failed to read credentials file: %w", err)
+	}
+
+	plain, salt, err := open(data, b.passphrase)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := json.Unmarshal(plain, &secrets); err != nil {
+		return nil, nil, fmt.Errorf("failed to parse decrypted credentials: %w", err)
+	}
+	return secrets, salt, nil
+}
+
+func (b *encryptedFileBackend) save(secrets map[string]string, salt []byte) error {
+	plain, err := json.Marshal(secrets)
+	if err != nil {
+		return fmt.Errorf("failed to marshal credentials: %w", err)
+	}
+
+	data, err := seal(plain, b.passphrase, salt)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(b.path), 0700); err != nil {
+		return fmt.Errorf("failed to create config directory: %w", err)
+	}
+	if err := config.WriteFileAtomic(b.path, data, 0600); err != nil {
+		return fmt.Errorf("failed to write credentials file: %w", err)
+	}
+	return nil
+}
+
+// Seal encrypts data with a key derived from passphrase and returns it in the same format as
+// the encrypted credentials file.
+func Seal(data []byte, passphrase string) ([]byte, error) {
+	return seal(data, passphrase, nil)
+}
+
+// Open decrypts data produced by Seal. It returns ErrWrongPassphrase if the passphrase does not match.
+func Open(data []byte, passphrase string) ([]byte, error) {
+	plain, _, err := open(data, passphrase)
+	return plain, err
+}
+
+// seal encrypts plain; a nil salt generates a new one.
+func seal(plain []byte, passphrase string, salt []byte) ([]byte, error) {
+	if salt == nil {
+		salt = make([]byte, saltSize)
+		if _, err := rand.Read(salt); err != nil {
+			return nil, fmt.Errorf("failed to generate salt: %w", err)
+		}
+	}
+	key, err := deriveKey(passphrase, salt)
+	if err != nil {
+		return nil, err
+	}
+
+	var nonce [nonceSize]byte
+	if _, err := rand.Read(nonce[:]); err != nil {
+		return nil, fmt.Errorf("failed to generate nonce: %w", err)
+	}
+
+	data, err := json.Marshal(encryptedFile{
+		Version: encryptedFileVersion,
+		KDF:     "scrypt",
+		Salt:    salt,
+		Nonce:   nonce[:],
+		Box:     secretbox.Seal(nil, plain, &nonce, key),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal encrypted data: %w", err)
+	}
+	return data, nil
+}
+
+// open decrypts data and also returns its salt so it can be reused when resealing.
+func open(data []byte, passphrase string) ([]byte, []byte, error) {
+	var f encryptedFile
+	if err := json.Unmarshal(data, &f); err != nil {
+		return nil, nil, fmt.Errorf("failed to parse encrypted data: %w", err)
+	}
+	if f.Version != encryptedFileVersion || f.KDF != "scrypt" || len(f.Nonce) != nonceSize {
+		return nil, nil, fmt.Errorf("unsupported encrypted data format")
+	}
+
+	key, err := deriveKey(passphrase, f.Salt)
+	if err != nil {
+		return nil, nil, err
+	}
+	var nonce [nonceSize]byte
+	copy(nonce[:], f.Nonce)
+
+	plain, ok := secretbox.Open(nil, f.Box, &nonce, key)
+	if !ok {
+		return nil, nil, ErrWrongPassphrase
+	}
+	return plain, f.Salt, nil
+}
+
+func deriveKey(passphrase string, salt []byte) (*[keySize]byte, error) {
+	derived, err := scrypt.Key([]byte(passphrase), salt, scryptN, scryptR, scryptP, keySize)
+	if err != nil {
+		return nil, fmt.Errorf("failed to derive encryption key: %w", err)
+	}
+	var key [keySize]byte
+	copy(key[:], derived)
+	return &key, nil
+}
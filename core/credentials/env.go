@@ -0,0 +1,62 @@
+package credentials
+
+import (
+	"os"
+	"strings"
+	"sync"
+)
+
+// EnvVar returns the environment variable holding a secret, e.g. ANYTYPE_ACCOUNT_KEY.
+func EnvVar(name string) string {
+	return "ANYTYPE_" + strings.ToUpper(strings.ReplaceAll(name, "-", "_"))
+}
+
+// envBackend reads secrets from ANYTYPE_ACCOUNT_KEY and ANYTYPE_SESSION_TOKEN. Environment
+// variables cannot be written, so values stored at runtime (e.g. a fresh session token)
+// only live in memory for the current process.
+type envBackend struct {
+	mu     sync.Mutex
+	memory map[string]string
+}
+
+// processEnv is shared so values stored at runtime survive between lookups.
+var processEnv = NewEnv()
+
+func NewEnv() Backend {
+	return &envBackend{memory: make(map[string]string)}
+}
+
+func (b *envBackend) Name() string {
+	return BackendEnv
+}
+
+func (b *envBackend) Get(name string) (string, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if value, ok := b.memory[name]; ok {
+		if value == "" {
+			return "", ErrNotFound
+		}
+		return value, nil
+	}
+	if value := os.Getenv(EnvVar(name)); value != "" {
+		return value, nil
+	}
+	return "", ErrNotFound
+}
+
+func (b *envBackend) Set(name, value string) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.memory[name] = value
+	return nil
+}
+
+func (b *envBackend) Delete(name string) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	// Mask the environment variable for the rest of the process
+	b.memory[name] = ""
+	return nil
+}
@@ -0,0 +1,55 @@
+package credentials
+
+import (
+	"fmt"
+
+	"github.com/anyproto/anytype-cli/core/config"
+)
+
+type configFileBackend struct{}
+
+// NewConfigFile returns the backend storing secrets in plain text in config.json.
+// It is the fallback when no keyring is available and should only be used on headless servers.
+func NewConfigFile() Backend {
+	return configFileBackend{}
+}
+
+func (configFileBackend) Name() string {
+	return BackendFile
+}
+
+func (configFileBackend) Get(name string) (string, error) {
+	cfg, err := config.LoadStoredConfig()
+	if err != nil {
+		return "", err
+	}
+
+	var value string
+	switch name {
+	case AccountKey:
+		value = cfg.AccountKey
+	case SessionToken:
+		value = cfg.SessionToken
+	default:
+		return "", fmt.Errorf("config file cannot store %q", name)
+	}
+	if value == "" {
+		return "", ErrNotFound
+	}
+	return value, nil
+}
+
+func (configFileBackend) Set(name, value string) error {
+	switch name {
+	case AccountKey:
+		return config.SetAccountKeyToConfig(value)
+	case SessionToken:
+		return config.SetSessionTokenToConfig(value)
+	default:
+		return fmt.Errorf("config file cannot store %q", name)
+	}
+}
+
+func (b configFileBackend) Delete(name string) error {
+	return b.Set(name, "")
+}
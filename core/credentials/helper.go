@@ -0,0 +1,93 @@
+package credentials
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+
+	"github.com/anyproto/anytype-cli/core/config"
+)
+
+// helperBackend talks to an external program using a protocol modelled on git credential
+// helpers. The helper is run through the shell with the action (get, store or erase) appended
+// as the last argument and receives key=value lines on stdin, terminated by an empty line:
+//
+//	service=anytype-cli
+//	profile=<name>      (named profiles only)
+//	name=account-key
+//	value=<secret>      (store only)
+//
+// For get, the helper prints value=<secret>. Printing nothing means the secret is not stored.
+type helperBackend struct {
+	command string
+}
+
+func NewHelper(command string) Backend {
+	return &helperBackend{command: command}
+}
+
+func (b *helperBackend) Name() string {
+	return BackendHelper
+}
+
+func (b *helperBackend) Get(name string) (string, error) {
+	out, err := b.run("get", name, "")
+	if err != nil {
+		return "", err
+	}
+
+	scanner := bufio.NewScanner(bytes.NewReader(out))
+	for scanner.Scan() {
+		if value, ok := strings.CutPrefix(scanner.Text(), "value="); ok && value != "" {
+			return value, nil
+		}
+	}
+	return "", ErrNotFound
+}
+
+func (b *helperBackend) Set(name, value string) error {
+	_, err := b.run("store", name, value)
+	return err
+}
+
+func (b *helperBackend) Delete(name string) error {
+	_, err := b.run("erase", name, "")
+	return err
+}
+
+func (b *helperBackend) run(action, name, value string) ([]byte, error) {
+	if strings.ContainsAny(name, "\n=") || strings.Contains(value, "\n") {
+		return nil, fmt.Errorf("credential contains characters not supported by the helper protocol")
+	}
+
+	var input strings.Builder
+	fmt.Fprintf(&input, "service=%s\n", keyringService)
+	if profile := config.ActiveProfile(); profile != config.DefaultProfile {
+		fmt.Fprintf(&input, "profile=%s\n", profile)
+	}
+	fmt.Fprintf(&input, "name=%s\n", name)
+	if value != "" {
+		fmt.Fprintf(&input, "value=%s\n", value)
+	}
+	input.WriteString("\n")
+
+	script := b.command + " " + action
+	var cmd *exec.Cmd
+	if runtime.GOOS == "windows" {
+		cmd = exec.Command("cmd", "/C", script)
+	} else {
+		cmd = exec.Command("sh", "-c", script)
+	}
+	cmd.Stdin = strings.NewReader(input.String())
+	cmd.Stderr = os.Stderr
+
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("credential helper %q failed on %s: %w", b.command, action, err)
+	}
+	return out, nil
+}
@@ -0,0 +1,59 @@
+package credentials
+
+import (
+	"errors"
+
+	"github.com/zalando/go-keyring"
+
+	"github.com/anyproto/anytype-cli/core/config"
+)
+
+const keyringService = "anytype-cli"
+
+// keyringUser namespaces keyring entries by profile. The default profile keeps the plain
+// names so credentials stored before profiles existed are still found.
+func keyringUser(name string) string {
+	if profile := config.ActiveProfile(); profile != config.DefaultProfile {
+		return profile + "/" + name
+	}
+	return name
+}
+
+type keyringBackend struct{}
+
+// NewKeyring returns the OS keyring backend (Keychain, Secret Service, Credential Manager).
+func NewKeyring() Backend {
+	return keyringBackend{}
+}
+
+// KeyringAvailable checks whether the OS keyring accepts writes.
+func KeyringAvailable() bool {
+	if err := keyring.Set(keyringService, "test", "test"); err != nil {
+		return false
+	}
+	_ = keyring.Delete(keyringService, "test")
+	return true
+}
+
+func (keyringBackend) Name() string {
+	return BackendKeyring
+}
+
+func (keyringBackend) Get(name string) (string, error) {
+	value, err := keyring.Get(keyringService, keyringUser(name))
+	if errors.Is(err, keyring.ErrNotFound) {
+		return "", ErrNotFound
+	}
+	return value, err
+}
+
+func (keyringBackend) Set(name, value string) error {
+	return keyring.Set(keyringService, keyringUser(name), value)
+}
+
+func (keyringBackend) Delete(name string) error {
+	if err := keyring.Delete(keyringService, keyringUser(name)); err != nil && !errors.Is(err, keyring.ErrNotFound) {
+		return err
+	}
+	return nil
+}
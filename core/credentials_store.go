@@ -0,0 +1,228 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/anyproto/anytype-cli/core/config"
+	"github.com/anyproto/anytype-cli/core/credentials"
+)
+
+// credentialNames lists the secrets the CLI stores.
+var credentialNames = []string{credentials.AccountKey, credentials.SessionToken}
+
+// CredentialLocation describes one credential found in one backend.
+type CredentialLocation struct {
+	Name    string
+	Backend string
+	Value   string
+	// Err is set if the backend could not be read
+	Err error
+}
+
+// inspectableBackends returns every backend that can currently be checked for credentials,
+// regardless of which one is configured. Backends that cannot be opened are reported with an error.
+func inspectableBackends(cfg config.CredentialsConfig) ([]credentials.Backend, map[string]error) {
+	var backends []credentials.Backend
+	unavailable := make(map[string]error)
+
+	if isKeyringAvailable() {
+		backends = append(backends, credentials.NewKeyring())
+	} else {
+		unavailable[credentials.BackendKeyring] = fmt.Errorf("keyring unavailable")
+	}
+
+	backends = append(backends, credentials.NewConfigFile())
+
+	if _, err := os.Stat(config.GetCredentialsFilePath()); err == nil {
+		if b, err := credentials.New(credentials.BackendEncrypted, cfg); err == nil {
+			backends = append(backends, b)
+		} else {
+			unavailable[credentials.BackendEncrypted] = err
+		}
+	}
+
+	if b, err := credentials.New(credentials.BackendEnv, cfg); err == nil {
+		backends = append(backends, b)
+	}
+
+	if cfg.Helper != "" {
+		if b, err := credentials.New(credentials.BackendHelper, cfg); err == nil {
+			backends = append(backends, b)
+		}
+	}
+
+	return backends, unavailable
+}
+
+// InspectCredentials returns the configured backend and every stored credential in any backend.
+func InspectCredentials() (string, []CredentialLocation, error) {
+	cfg, err := config.GetEffectiveCredentialsConfig()
+	if err != nil {
+		return "", nil, err
+	}
+	configured, err := credentials.ConfiguredBackend(cfg)
+	if err != nil {
+		return "", nil, err
+	}
+
+	backends, unavailable := inspectableBackends(cfg)
+
+	var locations []CredentialLocation
+	for _, b := range backends {
+		for _, name := range credentialNames {
+			value, err := b.Get(name)
+			if errors.Is(err, credentials.ErrNotFound) {
+				continue
+			}
+			locations = append(locations, CredentialLocation{Name: name, Backend: b.Name(), Value: value, Err: err})
+		}
+	}
+	for backend, err := range unavailable {
+		if backend == credentials.BackendKeyring {
+			continue
+		}
+		locations = append(locations, CredentialLocation{Backend: backend, Err: err})
+	}
+
+	return configured, locations, nil
+}
+
+// MigrateCredentials copies the stored credentials to the target backend, verifies them,
+// makes the target the configured backend and removes the credentials from the previous location.
+// cfg supplies settings the target needs, such as the key file or helper command.
+// Returns the names of the migrated credentials.
+func MigrateCredentials(target string, cfg config.CredentialsConfig) ([]string, error) {
+	switch target {
+	case credentials.BackendKeyring, credentials.BackendFile, credentials.BackendEncrypted, credentials.BackendHelper:
+	case credentials.BackendEnv:
+		return nil, fmt.Errorf("cannot migrate to the env backend; set %s instead", credentials.EnvVar(credentials.AccountKey))
+	default:
+		return nil, fmt.Errorf("cannot migrate to backend %q", target)
+	}
+	if target == credentials.BackendKeyring && !isKeyringAvailable() {
+		return nil, fmt.Errorf("system keyring is not available")
+	}
+
+	_, _, sources, err := credentialBackends()
+	if err != nil {
+		return nil, err
+	}
+
+	values := make(map[string]string)
+	for _, name := range credentialNames {
+		value, _, err := getCredential(name)
+		if errors.Is(err, ErrNotFound) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", name, err)
+		}
+		values[name] = value
+	}
+	if len(values) == 0 {
+		return nil, fmt.Errorf("no stored credentials to migrate")
+	}
+
+	dest, err := credentials.New(target, cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	// previous holds what the target stored before, so that a failed migration can restore it
+	previous := make(map[string]string)
+	var migrated []string
+	for _, name := range credentialNames {
+		value, ok := values[name]
+		if !ok {
+			continue
+		}
+		if old, err := dest.Get(name); err == nil {
+			previous[name] = old
+		}
+		// Set may have written part of the value before failing, so name is rolled back too
+		migrated = append(migrated, name)
+		if err := dest.Set(name, value); err != nil {
+			return nil, rollbackMigration(dest, migrated, previous, fmt.Errorf("failed to write %s to %s: %w", name, target, err))
+		}
+		got, err := dest.Get(name)
+		if err != nil {
+			return nil, rollbackMigration(dest, migrated, previous, fmt.Errorf("failed to verify %s in %s: %w", name, target, err))
+		}
+		if got != value {
+			return nil, rollbackMigration(dest, migrated, previous, fmt.Errorf("failed to verify %s in %s: stored value does not match", name, target))
+		}
+	}
+
+	cfg.Backend = target
+	if err := config.SetCredentialsConfig(cfg); err != nil {
+		return nil, rollbackMigration(dest, migrated, previous, fmt.Errorf("failed to save credentials backend: %w", err))
+	}
+
+	for _, b := range sources {
+		if b.Name() == target {
+			continue
+		}
+		for _, name := range migrated {
+			if err := b.Delete(name); err != nil {
+				return migrated, fmt.Errorf("credentials migrated, but failed to remove %s from %s: %w", name, b.Name(), err)
+			}
+		}
+	}
+
+	return migrated, nil
+}
+
+// rollbackMigration undoes the writes of a failed migration to dest: each of names gets back its
+// previous value, or is deleted if it had none. Errors from doing so are added to err.
+func rollbackMigration(dest credentials.Backend, names []string, previous map[string]string, err error) error {
+	errs := []error{err}
+	for _, name := range names {
+		var rollbackErr error
+		if old, ok := previous[name]; ok {
+			rollbackErr = dest.Set(name, old)
+		} else {
+			rollbackErr = dest.Delete(name)
+		}
+		if rollbackErr != nil {
+			errs = append(errs, fmt.Errorf("failed to remove the copy of %s from %s: %w", name, dest.Name(), rollbackErr))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// PurgeCredentials removes the account key and session token from every backend that can be
+// written to. Environment variables are left alone. Returns the backends credentials were removed from.
+func PurgeCredentials() ([]string, error) {
+	cfg, err := config.GetEffectiveCredentialsConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	backends, _ := inspectableBackends(cfg)
+
+	var purged []string
+	var errs []error
+	for _, b := range backends {
+		if b.Name() == credentials.BackendEnv {
+			// Environment variables belong to the caller and cannot be removed from here
+			continue
+		}
+		found := false
+		for _, name := range credentialNames {
+			if _, err := b.Get(name); err != nil {
+				continue
+			}
+			found = true
+			if err := b.Delete(name); err != nil {
+				errs = append(errs, fmt.Errorf("%s: %w", b.Name(), err))
+			}
+		}
+		if found {
+			purged = append(purged, b.Name())
+		}
+	}
+
+	return purged, errors.Join(errs...)
+}
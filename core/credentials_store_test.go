@@ -0,0 +1,172 @@
+package core
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/anyproto/anytype-cli/core/config"
+	"github.com/anyproto/anytype-cli/core/credentials"
+)
+
+func TestMigrateCredentials(t *testing.T) {
+	setupTestHome(t)
+	t.Setenv(credentials.PassphraseEnvVar, "correct horse battery staple")
+	t.Setenv(credentials.BackendEnvVar, "")
+
+	keyringUnavailable = true
+	defer func() { keyringUnavailable = false }()
+
+	if _, err := SaveAccountKey("test-account-key-12345"); err != nil {
+		t.Fatalf("SaveAccountKey failed: %v", err)
+	}
+	if _, err := SaveSessionToken("test-session-token-12345"); err != nil {
+		t.Fatalf("SaveSessionToken failed: %v", err)
+	}
+
+	migrated, err := MigrateCredentials(credentials.BackendEncrypted, config.CredentialsConfig{})
+	if err != nil {
+		t.Fatalf("MigrateCredentials failed: %v", err)
+	}
+	if len(migrated) != 2 {
+		t.Errorf("Expected 2 migrated credentials, got %v", migrated)
+	}
+
+	cfg, err := config.LoadStoredConfig()
+	if err != nil {
+		t.Fatalf("Failed to load config: %v", err)
+	}
+	if cfg.Credentials.Backend != credentials.BackendEncrypted {
+		t.Errorf("Expected configured backend %q, got %q", credentials.BackendEncrypted, cfg.Credentials.Backend)
+	}
+	if cfg.AccountKey != "" || cfg.SessionToken != "" {
+		t.Error("Expected plaintext credentials to be removed from config file")
+	}
+
+	key, backend, err := GetStoredAccountKey()
+	if err != nil {
+		t.Fatalf("GetStoredAccountKey failed: %v", err)
+	}
+	if key != "test-account-key-12345" || backend != credentials.BackendEncrypted {
+		t.Errorf("Expected key from encrypted backend, got %q from %q", key, backend)
+	}
+
+	_, locations, err := InspectCredentials()
+	if err != nil {
+		t.Fatalf("InspectCredentials failed: %v", err)
+	}
+	for _, loc := range locations {
+		if loc.Backend != credentials.BackendEncrypted {
+			t.Errorf("Unexpected credential %q in backend %q", loc.Name, loc.Backend)
+		}
+	}
+}
+
+func TestMigrateCredentialsRejectsEnv(t *testing.T) {
+	setupTestHome(t)
+
+	if _, err := MigrateCredentials(credentials.BackendEnv, config.CredentialsConfig{}); err == nil {
+		t.Error("Expected error when migrating to env backend")
+	}
+}
+
+func TestMigrateCredentialsRollsBack(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("helper test uses a shell script")
+	}
+
+	// The helper stores secrets in files named after them; it refuses to store the session
+	// token, or with "mismatch" returns a different value for it
+	script := `#!/bin/sh
+dir="$(dirname "$0")/store"
+mkdir -p "$dir"
+while IFS= read -r line && [ -n "$line" ]; do
+  case "$line" in
+    name=*) name="${line#name=}" ;;
+    value=*) value="${line#value=}" ;;
+  esac
+done
+case "$1" in
+  get)
+    [ "$name" = session-token ] && [ "$MODE" = mismatch ] && { echo value=other; exit 0; }
+    [ -f "$dir/$name" ] && printf 'value=%s\n' "$(cat "$dir/$name")" ;;
+  store)
+    [ "$name" = session-token ] && [ "$MODE" = fail ] && exit 1
+    printf '%s' "$value" > "$dir/$name" ;;
+  erase) rm -f "$dir/$name" ;;
+esac
+exit 0
+`
+
+	tests := []struct {
+		mode    string
+		wantErr string
+	}{
+		{mode: "fail", wantErr: "failed to write session-token"},
+		{mode: "mismatch", wantErr: "stored value does not match"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.mode, func(t *testing.T) {
+			setupTestHome(t)
+			t.Setenv(credentials.BackendEnvVar, "")
+			t.Setenv("MODE", tt.mode)
+
+			keyringUnavailable = true
+			defer func() { keyringUnavailable = false }()
+
+			dir := t.TempDir()
+			helper := filepath.Join(dir, "helper.sh")
+			if err := os.WriteFile(helper, []byte(script), 0700); err != nil {
+				t.Fatal(err)
+			}
+
+			if _, err := SaveAccountKey("test-account-key-12345"); err != nil {
+				t.Fatalf("SaveAccountKey failed: %v", err)
+			}
+			if _, err := SaveSessionToken("test-session-token-12345"); err != nil {
+				t.Fatalf("SaveSessionToken failed: %v", err)
+			}
+
+			_, err := MigrateCredentials(credentials.BackendHelper, config.CredentialsConfig{Helper: helper})
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("MigrateCredentials error = %v, want %q", err, tt.wantErr)
+			}
+
+			if _, err := credentials.NewHelper(helper).Get(credentials.AccountKey); !errors.Is(err, credentials.ErrNotFound) {
+				t.Errorf("Expected the copied account key to be removed from the helper, got %v", err)
+			}
+			key, backend, err := GetStoredAccountKey()
+			if err != nil || key != "test-account-key-12345" || backend != credentials.BackendFile {
+				t.Errorf("Expected the account key to stay in the file backend, got %q from %q: %v", key, backend, err)
+			}
+		})
+	}
+}
+
+func TestPurgeCredentials(t *testing.T) {
+	setupTestHome(t)
+	t.Setenv(credentials.BackendEnvVar, "")
+
+	keyringUnavailable = true
+	defer func() { keyringUnavailable = false }()
+
+	if _, err := SaveAccountKey("test-account-key-12345"); err != nil {
+		t.Fatalf("SaveAccountKey failed: %v", err)
+	}
+
+	purged, err := PurgeCredentials()
+	if err != nil {
+		t.Fatalf("PurgeCredentials failed: %v", err)
+	}
+	if len(purged) != 1 || purged[0] != credentials.BackendFile {
+		t.Errorf("Expected credentials purged from file backend, got %v", purged)
+	}
+
+	if _, _, err := GetStoredAccountKey(); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected ErrNotFound after purge, got %v", err)
+	}
+}
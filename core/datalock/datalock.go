@@ -0,0 +1,190 @@
+// Package datalock makes sure only one server opens a data directory at a time. The server
+// holds an OS file lock on a file in the directory for as long as it runs and records itself
+// in the file, so that a second server can tell who is using the directory.
+package datalock
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// FileName is the lock file inside the data directory
+const FileName = "anytype-server.lock"
+
+// errHeld is returned by tryLock when another process holds the lock.
+var errHeld = errors.New("lock is held by another process")
+
+// Owner describes the server holding the lock.
+type Owner struct {
+	PID            int       `json:"pid"`
+	Host           string    `json:"host"`
+	Profile        string    `json:"profile,omitempty"`
+	GRPCAddress    string    `json:"grpcAddress,omitempty"`
+	GRPCWebAddress string    `json:"grpcWebAddress,omitempty"`
+	APIAddress     string    `json:"apiAddress,omitempty"`
+	StartedAt      time.Time `json:"startedAt"`
+}
+
+func (o *Owner) describe() string {
+	parts := []string{fmt.Sprintf("PID %d on %s", o.PID, o.Host)}
+	if o.Profile != "" {
+		parts = append(parts, "profile "+o.Profile)
+	}
+	if o.GRPCAddress != "" {
+		parts = append(parts, "gRPC "+o.GRPCAddress)
+	}
+	if o.APIAddress != "" {
+		parts = append(parts, "API "+o.APIAddress)
+	}
+	desc := strings.Join(parts, ", ")
+	if !o.StartedAt.IsZero() {
+		desc += ", started " + o.StartedAt.Local().Format(time.DateTime)
+	}
+	return desc
+}
+
+// LockedError is returned by Acquire when another server uses the directory.
+type LockedError struct {
+	Dir string
+	// Owner is nil if the lock file could not be read
+	Owner *Owner
+}
+
+func (e *LockedError) Error() string {
+	if e.Owner == nil {
+		return fmt.Sprintf("data directory %s is in use by another anytype server", e.Dir)
+	}
+	return fmt.Sprintf("data directory %s is in use by another anytype server (%s)", e.Dir, e.Owner.describe())
+}
+
+// Lock is a held data directory lock.
+type Lock struct {
+	f      *os.File
+	locked bool
+}
+
+// Acquire locks dir for owner without waiting. If the lock file still names a server that
+// exited without releasing it, the stale record is replaced and returned as stale.
+func Acquire(dir string, owner Owner) (lock *Lock, stale *Owner, err error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, nil, fmt.Errorf("failed to create data directory: %w", err)
+	}
+	path := filepath.Join(dir, FileName)
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0600)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to open data directory lock: %w", err)
+	}
+
+	prev, _ := readOwner(f)
+	locked := true
+	switch err := tryLock(f); {
+	case errors.Is(err, errHeld):
+		f.Close()
+		return nil, nil, &LockedError{Dir: dir, Owner: prev}
+	case err != nil:
+		// Some network filesystems do not support locks; fall back to checking the recorded process
+		locked = false
+		if prev != nil && prev.PID != os.Getpid() && !prev.gone() {
+			f.Close()
+			return nil, nil, &LockedError{Dir: dir, Owner: prev}
+		}
+	}
+
+	// A record in an unlocked file was left by a server that did not release the lock
+	if prev != nil && prev.PID != os.Getpid() {
+		stale = prev
+	}
+
+	l := &Lock{f: f, locked: locked}
+	if err := l.write(owner); err != nil {
+		l.Release()
+		return nil, nil, err
+	}
+	return l, stale, nil
+}
+
+// Check returns a LockedError if another server holds the lock on dir, without taking it.
+func Check(dir string) error {
+	f, err := os.OpenFile(filepath.Join(dir, FileName), os.O_RDWR, 0)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil
+		}
+		return fmt.Errorf("failed to open data directory lock: %w", err)
+	}
+	defer f.Close()
+
+	prev, _ := readOwner(f)
+	switch err := tryLock(f); {
+	case errors.Is(err, errHeld):
+		return &LockedError{Dir: dir, Owner: prev}
+	case err != nil:
+		if prev != nil && prev.PID != os.Getpid() && !prev.gone() {
+			return &LockedError{Dir: dir, Owner: prev}
+		}
+		return nil
+	}
+	return unlock(f)
+}
+
+// gone reports whether the owner is known to have exited: it ran on this host and its
+// process no longer exists. Owners on other hosts cannot be checked.
+func (o *Owner) gone() bool {
+	host, err := os.Hostname()
+	if err != nil || o.Host != host {
+		return false
+	}
+	return !processExists(o.PID)
+}
+
+func readOwner(f *os.File) (*Owner, error) {
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		return nil, err
+	}
+	data, err := io.ReadAll(f)
+	if err != nil || len(data) == 0 {
+		return nil, err
+	}
+	var o Owner
+	if err := json.Unmarshal(data, &o); err != nil {
+		return nil, err
+	}
+	return &o, nil
+}
+
+func (l *Lock) write(owner Owner) error {
+	data, err := json.MarshalIndent(owner, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal lock owner: %w", err)
+	}
+	if err := l.f.Truncate(0); err != nil {
+		return fmt.Errorf("failed to write data directory lock: %w", err)
+	}
+	if _, err := l.f.WriteAt(data, 0); err != nil {
+		return fmt.Errorf("failed to write data directory lock: %w", err)
+	}
+	return l.f.Sync()
+}
+
+// Release clears the owner record and releases the lock. The file itself is kept: removing
+// it could let a server that opened it just before lock a file nobody else sees.
+func (l *Lock) Release() error {
+	if l == nil || l.f == nil {
+		return nil
+	}
+	err := l.f.Truncate(0)
+	if l.locked {
+		unlock(l.f)
+	}
+	if closeErr := l.f.Close(); err == nil {
+		err = closeErr
+	}
+	l.f = nil
+	return err
+}
@@ -0,0 +1,106 @@
+package datalock
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestAcquire(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "data")
+	owner := Owner{PID: os.Getpid(), Host: "host", GRPCAddress: "127.0.0.1:31010", APIAddress: "127.0.0.1:31012"}
+
+	lock, stale, err := Acquire(dir, owner)
+	if err != nil {
+		t.Fatalf("Acquire failed: %v", err)
+	}
+	if stale != nil {
+		t.Errorf("stale = %+v, want nil", stale)
+	}
+
+	// Locks belong to open files, so a second acquire in the same process conflicts as well
+	_, _, err = Acquire(dir, Owner{PID: os.Getpid() + 1})
+	var locked *LockedError
+	if !errors.As(err, &locked) {
+		t.Fatalf("second Acquire error = %v, want LockedError", err)
+	}
+	if locked.Owner == nil || locked.Owner.GRPCAddress != owner.GRPCAddress {
+		t.Errorf("locked owner = %+v, want %+v", locked.Owner, owner)
+	}
+	if msg := err.Error(); !strings.Contains(msg, "127.0.0.1:31010") || !strings.Contains(msg, "PID "+strconv.Itoa(owner.PID)) {
+		t.Errorf("error %q does not name the owner", msg)
+	}
+
+	if err := Check(dir); !errors.As(err, &locked) {
+		t.Errorf("Check error = %v, want LockedError", err)
+	}
+
+	if err := lock.Release(); err != nil {
+		t.Fatalf("Release failed: %v", err)
+	}
+	if err := Check(dir); err != nil {
+		t.Errorf("Check after Release = %v", err)
+	}
+	lock, stale, err = Acquire(dir, owner)
+	if err != nil {
+		t.Fatalf("Acquire after Release failed: %v", err)
+	}
+	if stale != nil {
+		t.Errorf("stale after Release = %+v, want nil", stale)
+	}
+	lock.Release()
+}
+
+func TestAcquireStale(t *testing.T) {
+	dir := t.TempDir()
+	// A server that was killed leaves its record in a file nobody locks
+	data, _ := json.Marshal(Owner{PID: os.Getpid() + 1, Host: "host"})
+	if err := os.WriteFile(filepath.Join(dir, FileName), data, 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	lock, stale, err := Acquire(dir, Owner{PID: os.Getpid(), Host: "host"})
+	if err != nil {
+		t.Fatalf("Acquire failed: %v", err)
+	}
+	defer lock.Release()
+	if stale == nil || stale.PID != os.Getpid()+1 {
+		t.Errorf("stale = %+v, want the killed server", stale)
+	}
+
+	data, err = os.ReadFile(filepath.Join(dir, FileName))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var o Owner
+	if err := json.Unmarshal(data, &o); err != nil || o.PID != os.Getpid() {
+		t.Errorf("lock file = %s, want the new owner", data)
+	}
+}
+
+func TestOwnerGone(t *testing.T) {
+	host, err := os.Hostname()
+	if err != nil {
+		t.Skip(err)
+	}
+	tests := []struct {
+		name  string
+		owner Owner
+		want  bool
+	}{
+		{"running", Owner{PID: os.Getpid(), Host: host}, false},
+		{"exited", Owner{PID: 1 << 30, Host: host}, true},
+		{"other host", Owner{PID: 1 << 30, Host: host + "-other"}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.owner.gone(); got != tt.want {
+				t.Errorf("gone() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
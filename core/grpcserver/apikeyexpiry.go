@@ -0,0 +1,62 @@
+//go:build !nogrpcserver
+// +build !nogrpcserver
+
+package grpcserver
+
+import (
+	"context"
+	"time"
+
+	"github.com/anyproto/anytype-heart/pb"
+
+	"github.com/anyproto/anytype-cli/core/apigateway"
+)
+
+const apiKeyExpiryInterval = time.Minute
+
+// runAPIKeyExpiry periodically revokes API keys whose expiry time has passed, including
+// keys replaced by rotation once their grace period is over. The gateway rejects expired
+// keys immediately; this removes them from the account as well.
+func (s *Server) runAPIKeyExpiry(store *apigateway.Store, stop <-chan struct{}) {
+	ticker := time.NewTicker(apiKeyExpiryInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			s.revokeExpiredAPIKeys(store)
+		}
+	}
+}
+
+func (s *Server) revokeExpiredAPIKeys(store *apigateway.Store) {
+	hashes, err := store.Expired(time.Now())
+	if err != nil {
+		log.Errorf("failed to check API key expiry: %v", err)
+		return
+	}
+
+	for _, hash := range hashes {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		resp := s.mw.AccountLocalLinkRevokeApp(ctx, &pb.RpcAccountLocalLinkRevokeAppRequest{AppHash: hash})
+		cancel()
+
+		switch resp.Error.GetCode() {
+		case pb.RpcAccountLocalLinkRevokeAppResponseError_NULL, pb.RpcAccountLocalLinkRevokeAppResponseError_NOT_FOUND:
+		case pb.RpcAccountLocalLinkRevokeAppResponseError_ACCOUNT_IS_NOT_RUNNING:
+			// Not logged in yet; try again on the next tick
+			return
+		default:
+			log.Errorf("failed to revoke expired API key %s: %s", hash, resp.Error.GetDescription())
+			continue
+		}
+
+		if err := store.Delete(hash); err != nil {
+			log.Errorf("failed to remove policy for revoked API key %s: %v", hash, err)
+			continue
+		}
+		log.Infof("Revoked expired API key %s", hash)
+	}
+}
@@ -0,0 +1,57 @@
+//go:build !nogrpcserver
+// +build !nogrpcserver
+
+package grpcserver
+
+import (
+	"net"
+	"net/http"
+	"sync/atomic"
+
+	"github.com/anyproto/anytype-heart/core"
+
+	"github.com/anyproto/anytype-cli/core/apigateway"
+)
+
+// apiUpstream forwards the gateway's requests to the middleware's own JSON API server, which
+// the middleware starts with each account on an internal loopback address instead of the
+// public one. That address is reachable by local processes, which still need an API key there
+// but bypass the gateway's limits.
+type apiUpstream struct {
+	mw *core.Middleware
+	// enabled is set while clients asked for the API on the gateway's address
+	enabled atomic.Bool
+	// addr is the internal address the middleware's API server listens on
+	addr  string
+	proxy http.Handler
+}
+
+func newAPIUpstream(mw *core.Middleware) (*apiUpstream, error) {
+	addr, err := freeLoopbackAddr()
+	if err != nil {
+		return nil, err
+	}
+	return &apiUpstream{mw: mw, addr: addr, proxy: apigateway.NewProxy(addr)}, nil
+}
+
+// Handler returns the proxy to the API of the running account, or nil if no account runs or
+// the API is disabled.
+func (u *apiUpstream) Handler() http.Handler {
+	if !u.enabled.Load() || u.mw.GetApp() == nil {
+		return nil
+	}
+	return u.proxy
+}
+
+// freeLoopbackAddr picks a loopback port that is free at the moment.
+func freeLoopbackAddr() (string, error) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return "", err
+	}
+	addr := ln.Addr().String()
+	if err := ln.Close(); err != nil {
+		return "", err
+	}
+	return addr, nil
+}
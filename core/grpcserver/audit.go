@@ -0,0 +1,168 @@
+//go:build !nogrpcserver
+// +build !nogrpcserver
+
+package grpcserver
+
+import (
+	"context"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/anyproto/anytype-heart/util/grpcprocess"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/stats"
+	"google.golang.org/grpc/status"
+
+	"github.com/anyproto/anytype-cli/core/audit"
+)
+
+type spaceIdGetter interface{ GetSpaceId() string }
+type contextIdGetter interface{ GetContextId() string }
+type objectIdGetter interface{ GetObjectId() string }
+type objectIdsGetter interface{ GetObjectIds() []string }
+
+// auditor writes one audit record per RPC. Client processes are resolved once per
+// connection, since resolving walks the system connection table; a peer address alone
+// is not enough to cache on, as the port is reused by other processes once closed.
+type auditor struct {
+	logger *audit.Logger
+}
+
+func newAuditor(logger *audit.Logger) *auditor {
+	return &auditor{logger: logger}
+}
+
+type connProcessKey struct{}
+
+// connProcess is the client process of one connection, resolved on its first audited call.
+type connProcess struct {
+	once sync.Once
+	proc *audit.Process
+}
+
+// connContext tags a connection of an HTTP server, e.g. the gRPC-Web one, whose requests
+// grpc tags one by one.
+func (a *auditor) connContext(ctx context.Context, _ net.Conn) context.Context {
+	return context.WithValue(ctx, connProcessKey{}, &connProcess{})
+}
+
+// TagConn implements stats.Handler to attach a connProcess to every connection.
+func (a *auditor) TagConn(ctx context.Context, _ *stats.ConnTagInfo) context.Context {
+	if _, ok := ctx.Value(connProcessKey{}).(*connProcess); ok {
+		return ctx
+	}
+	return context.WithValue(ctx, connProcessKey{}, &connProcess{})
+}
+
+func (a *auditor) HandleConn(context.Context, stats.ConnStats) {}
+
+func (a *auditor) TagRPC(ctx context.Context, _ *stats.RPCTagInfo) context.Context { return ctx }
+
+func (a *auditor) HandleRPC(context.Context, stats.RPCStats) {}
+
+func (a *auditor) unaryInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	if isLivenessProbe(ctx) {
+		return handler(ctx, req)
+	}
+	start := time.Now()
+	resp, err := handler(ctx, req)
+	a.record(ctx, info.FullMethod, req, start, err)
+	return resp, err
+}
+
+func (a *auditor) streamInterceptor(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	start := time.Now()
+	err := handler(srv, ss)
+	a.record(ss.Context(), info.FullMethod, nil, start, err)
+	return err
+}
+
+func (a *auditor) record(ctx context.Context, method string, req interface{}, start time.Time, err error) {
+	rec := audit.Record{
+		Time:       start.UTC(),
+		Method:     method,
+		DurationMs: float64(time.Since(start).Microseconds()) / 1000,
+		Code:       status.Code(err).String(),
+	}
+
+	if p, ok := peer.FromContext(ctx); ok && p.Addr != nil {
+		rec.Peer = p.Addr.String()
+	}
+	rec.Process = a.processFor(ctx, rec.Peer)
+	rec.SpaceId, rec.ObjectIds = requestIds(req)
+
+	if werr := a.logger.Write(rec); werr != nil {
+		log.Errorf("audit: %v", werr)
+	}
+}
+
+func (a *auditor) processFor(ctx context.Context, peerAddr string) *audit.Process {
+	if pi, ok := grpcprocess.FromContext(ctx); ok && pi != nil {
+		return &audit.Process{PID: pi.PID, Name: pi.Name, Path: pi.Path}
+	}
+	if peerAddr == "" {
+		return nil
+	}
+	conn, ok := ctx.Value(connProcessKey{}).(*connProcess)
+	if !ok {
+		return resolveProcess(peerAddr)
+	}
+	// Misses are kept too, so unresolvable peers are not looked up on every call
+	conn.once.Do(func() { conn.proc = resolveProcess(peerAddr) })
+	return conn.proc
+}
+
+func resolveProcess(peerAddr string) *audit.Process {
+	host, port, err := net.SplitHostPort(peerAddr)
+	if err != nil {
+		return nil
+	}
+	if ip := net.ParseIP(host); ip == nil || !ip.IsLoopback() {
+		return nil
+	}
+	pi, err := grpcprocess.ResolveProcess(host, port)
+	if err != nil {
+		return nil
+	}
+	return &audit.Process{PID: pi.PID, Name: pi.Name, Path: pi.Path}
+}
+
+// requestIds extracts the space and object identifiers from a request without touching its payload.
+func requestIds(req interface{}) (string, []string) {
+	if req == nil {
+		return "", nil
+	}
+
+	var spaceId string
+	if g, ok := req.(spaceIdGetter); ok {
+		spaceId = g.GetSpaceId()
+	}
+
+	var objectIds []string
+	if g, ok := req.(contextIdGetter); ok && g.GetContextId() != "" {
+		objectIds = append(objectIds, g.GetContextId())
+	}
+	if g, ok := req.(objectIdGetter); ok && g.GetObjectId() != "" {
+		objectIds = appendUnique(objectIds, g.GetObjectId())
+	}
+	if g, ok := req.(objectIdsGetter); ok {
+		for _, id := range g.GetObjectIds() {
+			if id != "" {
+				objectIds = appendUnique(objectIds, id)
+			}
+		}
+	}
+
+	return spaceId, objectIds
+}
+
+func appendUnique(list []string, s string) []string {
+	for _, v := range list {
+		if v == s {
+			return list
+		}
+	}
+	return append(list, s)
+}
@@ -0,0 +1,93 @@
+//go:build !nogrpcserver
+// +build !nogrpcserver
+
+package grpcserver
+
+import (
+	"context"
+	"crypto/subtle"
+	"fmt"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+
+	"github.com/anyproto/anytype-heart/core/wallet"
+	"github.com/anyproto/anytype-heart/pb"
+	"github.com/anyproto/anytype-heart/pb/service"
+
+	"github.com/anyproto/anytype-cli/core/portcheck"
+)
+
+const (
+	// probeMethod is the RPC liveness probes are sent as. It needs no session token.
+	probeMethod = "/anytype.ClientCommands/AppGetVersion"
+	// probeHeader carries the key that marks the server's own liveness probes. Only the server
+	// knows the key, so clients cannot use the header to keep their calls out of the audit log.
+	probeHeader = "x-anytype-probe"
+)
+
+type livenessProbeKey struct{}
+
+// isLivenessProbe reports whether ctx belongs to one of the server's liveness probes.
+func isLivenessProbe(ctx context.Context) bool {
+	probe, _ := ctx.Value(livenessProbeKey{}).(bool)
+	return probe
+}
+
+// Liveness sends one probe to the middleware through the gRPC listener, the way clients reach
+// it, and returns how long the answer took. The probe takes the middleware's application lock,
+// which account start and stop hold, and the component lock of the running account, so a
+// middleware deadlocked on either does not answer. Probes are not audited.
+func (s *Server) Liveness(ctx context.Context) (time.Duration, error) {
+	client, err := s.probeClient()
+	if err != nil {
+		return 0, err
+	}
+
+	ctx = metadata.AppendToOutgoingContext(ctx, probeHeader, s.probeKey)
+	started := time.Now()
+	resp, err := client.AppGetVersion(ctx, &pb.RpcAppGetVersionRequest{})
+	latency := time.Since(started)
+	if err != nil {
+		return latency, err
+	}
+	if resp.Error != nil && resp.Error.Code != pb.RpcAppGetVersionResponseError_NULL {
+		return latency, fmt.Errorf("%s", resp.Error.Description)
+	}
+	return latency, nil
+}
+
+// probeClient returns the client the server probes itself with. Its connection is opened once
+// and kept until Stop.
+func (s *Server) probeClient() (service.ClientCommandsClient, error) {
+	s.probeOnce.Do(func() {
+		s.probeConn, s.probeErr = grpc.NewClient("passthrough:///"+portcheck.DialAddr(s.grpcListener.Addr().String()), grpc.WithTransportCredentials(insecure.NewCredentials()))
+		if s.probeErr != nil {
+			s.probeErr = fmt.Errorf("failed to create probe client: %w", s.probeErr)
+		}
+	})
+	if s.probeErr != nil {
+		return nil, s.probeErr
+	}
+	return service.NewClientCommandsClient(s.probeConn), nil
+}
+
+// livenessInterceptor recognises the server's liveness probes, takes the middleware's locks for
+// them and marks their context so that they are not audited.
+func (s *Server) livenessInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	if info.FullMethod != probeMethod {
+		return handler(ctx, req)
+	}
+	md, _ := metadata.FromIncomingContext(ctx)
+	keys := md.Get(probeHeader)
+	if len(keys) != 1 || subtle.ConstantTimeCompare([]byte(keys[0]), []byte(s.probeKey)) != 1 {
+		return handler(ctx, req)
+	}
+
+	if a := s.mw.GetApp(); a != nil {
+		a.Component(wallet.CName)
+	}
+	return handler(context.WithValue(ctx, livenessProbeKey{}, true), req)
+}
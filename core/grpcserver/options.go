@@ -0,0 +1,97 @@
+//go:build !nogrpcserver
+// +build !nogrpcserver
+
+package grpcserver
+
+import (
+	"fmt"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/keepalive"
+)
+
+const (
+	DefaultMaxRecvMsgSize    = 20 * 1024 * 1024
+	DefaultKeepaliveTime     = 2 * time.Hour
+	DefaultKeepaliveTimeout  = 20 * time.Second
+	DefaultKeepaliveMinTime  = 5 * time.Minute
+	DefaultReadHeaderTimeout = 30 * time.Second
+)
+
+// Options holds the tunable limits of the gRPC and gRPC-Web servers.
+// Zero values for MaxSendMsgSize, MaxConcurrentStreams, MaxConnectionIdle and WebIdleTimeout
+// leave the corresponding limit at the library default (unlimited).
+type Options struct {
+	MaxRecvMsgSize       int
+	MaxSendMsgSize       int
+	MaxConcurrentStreams uint32
+
+	// Server-initiated pings: sent after KeepaliveTime of inactivity, connection closed
+	// if not acknowledged within KeepaliveTimeout
+	KeepaliveTime    time.Duration
+	KeepaliveTimeout time.Duration
+
+	// Enforcement of client pings: clients pinging more often than KeepaliveMinTime
+	// (or at all without active streams, unless permitted) are disconnected
+	KeepaliveMinTime             time.Duration
+	KeepalivePermitWithoutStream bool
+
+	MaxConnectionIdle time.Duration
+
+	WebReadHeaderTimeout time.Duration
+	WebIdleTimeout       time.Duration
+}
+
+// DefaultOptions returns the limits the server used before they became configurable.
+func DefaultOptions() Options {
+	return Options{
+		MaxRecvMsgSize:       DefaultMaxRecvMsgSize,
+		KeepaliveTime:        DefaultKeepaliveTime,
+		KeepaliveTimeout:     DefaultKeepaliveTimeout,
+		KeepaliveMinTime:     DefaultKeepaliveMinTime,
+		WebReadHeaderTimeout: DefaultReadHeaderTimeout,
+	}
+}
+
+// Validate checks that the options are within sensible bounds.
+func (o Options) Validate() error {
+	if o.MaxRecvMsgSize <= 0 {
+		return fmt.Errorf("max receive message size must be positive")
+	}
+	if o.MaxSendMsgSize < 0 {
+		return fmt.Errorf("max send message size cannot be negative")
+	}
+	if o.KeepaliveTime <= 0 || o.KeepaliveTimeout <= 0 {
+		return fmt.Errorf("keepalive time and timeout must be positive")
+	}
+	if o.KeepaliveMinTime < 0 || o.MaxConnectionIdle < 0 {
+		return fmt.Errorf("keepalive min time and max connection idle cannot be negative")
+	}
+	if o.WebReadHeaderTimeout < 0 || o.WebIdleTimeout < 0 {
+		return fmt.Errorf("gRPC-Web timeouts cannot be negative")
+	}
+	return nil
+}
+
+func (o Options) serverOptions() []grpc.ServerOption {
+	opts := []grpc.ServerOption{
+		grpc.MaxRecvMsgSize(o.MaxRecvMsgSize),
+		grpc.KeepaliveParams(keepalive.ServerParameters{
+			Time:              o.KeepaliveTime,
+			Timeout:           o.KeepaliveTimeout,
+			MaxConnectionIdle: o.MaxConnectionIdle,
+		}),
+		grpc.KeepaliveEnforcementPolicy(keepalive.EnforcementPolicy{
+			MinTime:             o.KeepaliveMinTime,
+			PermitWithoutStream: o.KeepalivePermitWithoutStream,
+		}),
+	}
+	if o.MaxSendMsgSize > 0 {
+		opts = append(opts, grpc.MaxSendMsgSize(o.MaxSendMsgSize))
+	}
+	if o.MaxConcurrentStreams > 0 {
+		opts = append(opts, grpc.MaxConcurrentStreams(o.MaxConcurrentStreams))
+	}
+	return opts
+}
@@ -0,0 +1,82 @@
+//go:build !nogrpcserver
+// +build !nogrpcserver
+
+package grpcserver
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/anyproto/anytype-heart/pb"
+)
+
+// probeInterval is the wait between readiness probes.
+const probeInterval = 100 * time.Millisecond
+
+// WaitMiddlewareReady blocks until the middleware answers AppGetVersion through the gRPC
+// listener, i.e. the way clients reach it, and returns its version.
+func (s *Server) WaitMiddlewareReady(ctx context.Context) (string, error) {
+	client, err := s.probeClient()
+	if err != nil {
+		return "", err
+	}
+
+	var version string
+	err = waitFor(ctx, func(ctx context.Context) error {
+		resp, err := client.AppGetVersion(ctx, &pb.RpcAppGetVersionRequest{})
+		if err != nil {
+			return err
+		}
+		if resp.Error != nil && resp.Error.Code != pb.RpcAppGetVersionResponseError_NULL {
+			return fmt.Errorf("%s", resp.Error.Description)
+		}
+		version = resp.Version
+		return nil
+	})
+	if err != nil {
+		return "", fmt.Errorf("middleware not ready: %w", err)
+	}
+	return version, nil
+}
+
+// WaitAPIReady blocks until a request sent through the API gateway is answered. While an account
+// runs with the API enabled, the answer must come from the middleware's API; before that the
+// gateway answers for it. Without a gateway it returns at once.
+func (s *Server) WaitAPIReady(ctx context.Context) error {
+	if s.apiGateway == nil {
+		return nil
+	}
+	err := waitFor(ctx, func(ctx context.Context) error {
+		upstream, err := s.apiGateway.Probe(ctx)
+		if err != nil {
+			return err
+		}
+		if !upstream && s.apiUpstream.enabled.Load() && s.mw.GetApp() != nil {
+			return fmt.Errorf("API server of the running account does not answer")
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("API gateway not ready: %w", err)
+	}
+	return nil
+}
+
+// waitFor runs probe until it succeeds or ctx is done, and returns the last probe error in the latter case.
+func waitFor(ctx context.Context, probe func(ctx context.Context) error) error {
+	for {
+		probeCtx, cancel := context.WithTimeout(ctx, time.Second)
+		err := probe(probeCtx)
+		cancel()
+		if err == nil {
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("%w (last error: %v)", ctx.Err(), err)
+		case <-time.After(probeInterval):
+		}
+	}
+}
@@ -5,11 +5,14 @@ package grpcserver
 
 import (
 	"context"
+	"crypto/rand"
 	"errors"
 	"fmt"
 	"net"
 	"net/http"
 	"os"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/anyproto/any-sync/app"
@@ -18,6 +21,7 @@ import (
 	"github.com/grpc-ecosystem/go-grpc-prometheus"
 	"github.com/improbable-eng/grpc-web/go/grpcweb"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/status"
 
 	"github.com/anyproto/anytype-heart/core"
 	"github.com/anyproto/anytype-heart/core/event"
@@ -26,6 +30,11 @@ import (
 	"github.com/anyproto/anytype-heart/pb/service"
 	"github.com/anyproto/anytype-heart/pkg/lib/logging"
 	"github.com/anyproto/anytype-heart/util/grpcprocess"
+
+	"github.com/anyproto/anytype-cli/core/apigateway"
+	"github.com/anyproto/anytype-cli/core/audit"
+	"github.com/anyproto/anytype-cli/core/config"
+	"github.com/anyproto/anytype-cli/core/portcheck"
 )
 
 var log = logging.Logger("anytype-heart")
@@ -33,69 +42,191 @@ var log = logging.Logger("anytype-heart")
 const grpcWebStartedMessagePrefix = "gRPC Web proxy started at: "
 
 type Server struct {
+	opts         Options
 	mw           *core.Middleware
 	grpcServer   *grpc.Server
 	webServer    *http.Server
 	grpcListener net.Listener
 	webListener  net.Listener
+	auditLog     *audit.Logger
+	apiGateway   *apigateway.Gateway
+	apiUpstream  *apiUpstream
+	stopExpiry   chan struct{}
+	events       *event.GrpcSender
+
+	// shutdownTimeout bounds draining requests and stopping the middleware in Stop
+	shutdownTimeout time.Duration
+	stopOnce        sync.Once
+	stopErr         error
+	// inflight counts the RPCs being handled
+	inflight atomic.Int64
+
+	// probeKey marks the server's own liveness probes, which go through probeConn
+	probeKey  string
+	probeOnce sync.Once
+	probeConn *grpc.ClientConn
+	probeErr  error
+
+	tlsCertFile string
+	tlsKeyFile  string
+
+	// Listeners opened by the caller, e.g. passed in by socket activation
+	presetGRPC    net.Listener
+	presetGRPCWeb net.Listener
+	presetAPI     net.Listener
 }
 
 func NewServer() *Server {
-	return &Server{}
+	return NewServerWithOptions(DefaultOptions())
 }
 
-func (s *Server) Start(grpcAddr, grpcWebAddr string) error {
-	app.StartWarningAfter = time.Second * 5
+// NewServerWithOptions creates a server with custom message size, keepalive and connection limits.
+func NewServerWithOptions(opts Options) *Server {
+	return &Server{opts: opts, shutdownTimeout: DefaultShutdownTimeout}
+}
+
+// SetShutdownTimeout overrides how long Stop waits for in-flight requests and the middleware.
+func (s *Server) SetShutdownTimeout(timeout time.Duration) {
+	s.shutdownTimeout = timeout
+}
+
+// SetAPITLS makes the API gateway serve HTTPS with the given certificate. It must be called before Start.
+func (s *Server) SetAPITLS(certFile, keyFile string) {
+	s.tlsCertFile = certFile
+	s.tlsKeyFile = keyFile
+}
 
-	if os.Getenv("ANYTYPE_LOG_LEVEL") == "" {
-		os.Setenv("ANYTYPE_LOG_LEVEL", "ERROR")
+// SetListeners makes Start serve on already open listeners instead of listening on the
+// given addresses. Nil listeners are opened as usual. It must be called before Start.
+func (s *Server) SetListeners(grpcListener, grpcWebListener, apiListener net.Listener) {
+	s.presetGRPC = grpcListener
+	s.presetGRPCWeb = grpcWebListener
+	s.presetAPI = apiListener
+}
+
+// ReloadAPICertificate re-reads the API gateway's TLS certificate, e.g. after it was renewed.
+// The new files apply to new connections; switching TLS on or off requires a restart.
+func (s *Server) ReloadAPICertificate(certFile, keyFile string) error {
+	if s.apiGateway == nil || s.tlsCertFile == "" {
+		return nil
 	}
+	if err := s.apiGateway.LoadCertificate(certFile, keyFile); err != nil {
+		return err
+	}
+	s.tlsCertFile = certFile
+	s.tlsKeyFile = keyFile
+	return nil
+}
+
+// SetLogLevel changes the middleware's log level, also while the server is running.
+func SetLogLevel(level string) {
+	// The middleware reads its log level from the environment when it starts
+	os.Setenv("ANYTYPE_LOG_LEVEL", level)
+	logging.SetLogLevels(level)
+}
+
+// Start launches the gRPC and gRPC-Web servers. If apiAddr is set, the server also takes
+// that address for the JSON API gateway, which enforces per-key limits in front of the middleware's API.
+func (s *Server) Start(grpcAddr, grpcWebAddr, apiAddr string) error {
+	if err := s.opts.Validate(); err != nil {
+		return fmt.Errorf("invalid server options: %w", err)
+	}
+
+	app.StartWarningAfter = time.Second * 5
+
+	SetLogLevel(config.GetLogLevel())
 
 	metrics.Service.InitWithKeys(metrics.DefaultInHouseKey)
 
 	log.Info("Starting anytype-heart...")
 	s.mw = core.New()
-	s.mw.SetEventSender(event.NewGrpcSender())
+	s.events = event.NewGrpcSender()
+	s.mw.SetEventSender(s.events)
 
 	var err error
-	s.grpcListener, err = net.Listen("tcp", grpcAddr)
+	s.grpcListener, err = listen(s.presetGRPC, grpcAddr)
 	if err != nil {
-		return fmt.Errorf("failed to listen on %s: %w", grpcAddr, err)
+		return err
 	}
 
-	s.webListener, err = net.Listen("tcp", grpcWebAddr)
+	s.webListener, err = listen(s.presetGRPCWeb, grpcWebAddr)
 	if err != nil {
 		s.grpcListener.Close()
-		return fmt.Errorf("failed to listen on %s: %w", grpcWebAddr, err)
+		return err
+	}
+
+	if apiAddr != "" {
+		limiter := apigateway.NewLimiter(apigateway.GetStore())
+		s.apiUpstream, err = newAPIUpstream(s.mw)
+		if err != nil {
+			err = fmt.Errorf("failed to allocate internal API address: %w", err)
+		} else if s.presetAPI != nil {
+			s.apiGateway = apigateway.NewWithListener(apiAddr, s.presetAPI, s.apiUpstream.Handler, limiter)
+		} else {
+			s.apiGateway, err = apigateway.New(apiAddr, s.apiUpstream.Handler, limiter)
+		}
+		if err == nil && s.tlsCertFile != "" {
+			if err = s.apiGateway.LoadCertificate(s.tlsCertFile, s.tlsKeyFile); err != nil {
+				s.apiGateway.Shutdown(context.Background())
+			}
+		}
+		if err != nil {
+			s.grpcListener.Close()
+			s.webListener.Close()
+			return err
+		}
+	}
+
+	s.probeKey = rand.Text()
+	unaryInterceptors := []grpc.UnaryServerInterceptor{s.trackUnary, s.livenessInterceptor}
+	streamInterceptors := []grpc.StreamServerInterceptor{s.trackStream}
+
+	if s.apiGateway != nil {
+		unaryInterceptors = append(unaryInterceptors, s.apiAddrInterceptor)
 	}
 
-	var unaryInterceptors []grpc.UnaryServerInterceptor
+	// Resolve the client process before auditing so the audit record can use it
+	processInfo := grpcprocess.ProcessInfoInterceptor(
+		"/anytype.ClientCommands/AccountLocalLinkNewChallenge",
+	)
+	unaryInterceptors = append(unaryInterceptors, processInfo)
+	streamInterceptors = append(streamInterceptors, streamInterceptor(processInfo))
+
+	var rpcAuditor *auditor
+	if os.Getenv("ANYTYPE_AUDIT_LOG") != "0" {
+		s.auditLog, err = audit.NewLogger(audit.GetFilePath(), audit.DefaultMaxSize, audit.DefaultMaxBackups)
+		if err != nil {
+			log.Errorf("audit log disabled: %v", err)
+		} else {
+			rpcAuditor = newAuditor(s.auditLog)
+			unaryInterceptors = append(unaryInterceptors, rpcAuditor.unaryInterceptor)
+			streamInterceptors = append(streamInterceptors, rpcAuditor.streamInterceptor)
+		}
+	}
 
 	if metrics.Enabled {
 		unaryInterceptors = append(unaryInterceptors, grpc_prometheus.UnaryServerInterceptor)
+		streamInterceptors = append(streamInterceptors, grpc_prometheus.StreamServerInterceptor)
 	}
 
 	unaryInterceptors = append(unaryInterceptors, metrics.UnaryTraceInterceptor)
-	unaryInterceptors = append(unaryInterceptors, func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (resp interface{}, err error) {
-		resp, err = s.mw.Authorize(ctx, req, info, handler)
-		if err != nil {
-			log.Errorf("authorize: %s", err)
-		}
-		return
-	})
+	streamInterceptors = append(streamInterceptors, streamTraceInterceptor)
+	unaryInterceptors = append(unaryInterceptors, s.authorize)
+	streamInterceptors = append(streamInterceptors, s.authorizeStream)
 
 	if os.Getenv("ANYTYPE_GRPC_NO_DEBUG_TIMEOUT") != "1" {
 		unaryInterceptors = append(unaryInterceptors, metrics.LongMethodsInterceptor)
+		streamInterceptors = append(streamInterceptors, streamLongMethodsInterceptor(metrics.LongMethodsInterceptor))
 	}
 
-	unaryInterceptors = append(unaryInterceptors, grpcprocess.ProcessInfoInterceptor(
-		"/anytype.ClientCommands/AccountLocalLinkNewChallenge",
-	))
-
-	s.grpcServer = grpc.NewServer(
-		grpc.MaxRecvMsgSize(20*1024*1024),
+	serverOptions := append(s.opts.serverOptions(),
 		grpc.UnaryInterceptor(grpc_middleware.ChainUnaryServer(unaryInterceptors...)),
+		grpc.StreamInterceptor(grpc_middleware.ChainStreamServer(streamInterceptors...)),
 	)
+	if rpcAuditor != nil {
+		serverOptions = append(serverOptions, grpc.StatsHandler(rpcAuditor))
+	}
+	s.grpcServer = grpc.NewServer(serverOptions...)
 
 	service.RegisterClientCommandsServer(s.grpcServer, s.mw)
 
@@ -112,7 +243,11 @@ func (s *Server) Start(grpcAddr, grpcWebAddr string) error {
 
 	s.webServer = &http.Server{
 		Handler:           webrpc,
-		ReadHeaderTimeout: 30 * time.Second,
+		ReadHeaderTimeout: s.opts.WebReadHeaderTimeout,
+		IdleTimeout:       s.opts.WebIdleTimeout,
+	}
+	if rpcAuditor != nil {
+		s.webServer.ConnContext = rpcAuditor.connContext
 	}
 
 	go func() {
@@ -131,30 +266,68 @@ func (s *Server) Start(grpcAddr, grpcWebAddr string) error {
 
 	api.SetMiddlewareParams(s.mw)
 
+	if s.apiGateway != nil {
+		log.Infof("Starting API gateway on %s (upstream %s)", s.apiGateway.PublicAddr(), s.apiUpstream.addr)
+		s.apiGateway.Serve()
+	}
+
+	s.stopExpiry = make(chan struct{})
+	go s.runAPIKeyExpiry(apigateway.GetStore(), s.stopExpiry)
+
 	return nil
 }
 
-func (s *Server) Stop() error {
-	log.Info("Shutting down servers...")
+func listen(preset net.Listener, addr string) (net.Listener, error) {
+	if preset != nil {
+		return preset, nil
+	}
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to listen on %s: %w", addr, portcheck.Diagnose(addr, err))
+	}
+	return ln, nil
+}
 
-	if s.grpcServer != nil {
-		s.grpcServer.GracefulStop()
+// apiAddrInterceptor moves the middleware's JSON API server behind the gateway: requests asking
+// it to listen on the gateway's public address make it listen on the internal address the
+// gateway forwards to. Other addresses are left to the middleware.
+func (s *Server) apiAddrInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	switch r := req.(type) {
+	case *pb.RpcAccountSelectRequest:
+		r.JsonApiListenAddr = s.routeAPI(r.JsonApiListenAddr)
+	case *pb.RpcAccountCreateRequest:
+		r.JsonApiListenAddr = s.routeAPI(r.JsonApiListenAddr)
+	case *pb.RpcAccountChangeJsonApiAddrRequest:
+		r.ListenAddr = s.routeAPI(r.ListenAddr)
 	}
+	return handler(ctx, req)
+}
 
-	if s.webServer != nil {
-		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-		defer cancel()
-		if err := s.webServer.Shutdown(ctx); err != nil {
-			log.Errorf("HTTP server shutdown error: %v", err)
-		}
+// routeAPI returns the listen address to pass to the middleware for a requested API address.
+func (s *Server) routeAPI(addr string) string {
+	owned := s.apiGateway.Owns(addr)
+	s.apiUpstream.enabled.Store(owned)
+	if owned {
+		return s.apiUpstream.addr
 	}
+	return addr
+}
 
-	if s.mw != nil {
-		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-		defer cancel()
-		_ = s.mw.AppShutdown(ctx, &pb.RpcAppShutdownRequest{})
+// authorize checks a call with the middleware's Authorize and logs rejected ones.
+func (s *Server) authorize(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (resp interface{}, err error) {
+	resp, err = s.mw.Authorize(ctx, req, info, handler)
+	if err != nil {
+		log.Errorf("authorize: %s", err)
 	}
+	return
+}
 
-	log.Info("Servers stopped")
-	return nil
+// streamTraceInterceptor logs the lifetime of streaming calls, which the unary
+// trace and long-method interceptors do not cover.
+func streamTraceInterceptor(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	start := time.Now()
+	log.Debugf("stream %s opened", info.FullMethod)
+	err := handler(srv, ss)
+	log.Debugf("stream %s closed after %s: %v", info.FullMethod, time.Since(start), status.Code(err))
+	return err
 }
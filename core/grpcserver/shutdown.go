@@ -0,0 +1,169 @@
+//go:build !nogrpcserver
+// +build !nogrpcserver
+
+package grpcserver
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc"
+
+	"github.com/anyproto/anytype-heart/pb"
+)
+
+// DefaultShutdownTimeout is how long Stop waits for in-flight requests and the middleware to finish
+const DefaultShutdownTimeout = 30 * time.Second
+
+func (s *Server) trackUnary(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	s.inflight.Add(1)
+	defer s.inflight.Add(-1)
+	return handler(ctx, req)
+}
+
+func (s *Server) trackStream(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	s.inflight.Add(1)
+	defer s.inflight.Add(-1)
+	return handler(srv, ss)
+}
+
+// Stop shuts the server down in stages: it stops accepting connections and requests, ends the
+// clients' event streams, waits for in-flight requests and then cuts off the rest, and finally
+// stops the middleware. All stages share the shutdown timeout. Only the first call stops the
+// server; later calls return its result.
+func (s *Server) Stop() error {
+	s.stopOnce.Do(func() {
+		s.stopErr = s.stop()
+	})
+	return s.stopErr
+}
+
+func (s *Server) stop() error {
+	started := time.Now()
+	log.Infof("Shutting down (timeout %s)", s.shutdownTimeout)
+
+	if s.stopExpiry != nil {
+		close(s.stopExpiry)
+		s.stopExpiry = nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), s.shutdownTimeout)
+	defer cancel()
+
+	// GracefulStop and Shutdown close the listeners at once and then wait for running requests
+	grpcDone := make(chan struct{})
+	if s.grpcServer != nil {
+		go func() {
+			s.grpcServer.GracefulStop()
+			close(grpcDone)
+		}()
+	} else {
+		close(grpcDone)
+	}
+	var httpWg sync.WaitGroup
+	shutdownHTTP := func(name string, shutdown func(context.Context) error) {
+		httpWg.Add(1)
+		go func() {
+			defer httpWg.Done()
+			if err := shutdown(ctx); err != nil && ctx.Err() == nil {
+				log.Errorf("%s shutdown error: %v", name, err)
+			}
+		}()
+	}
+	if s.apiGateway != nil {
+		shutdownHTTP("API gateway", s.apiGateway.Shutdown)
+	}
+	if s.webServer != nil {
+		shutdownHTTP("gRPC-Web server", s.webServer.Shutdown)
+	}
+	log.Infof("Stopped accepting new requests")
+
+	// Event streams only end when the client disconnects, so close them to let clients reconnect elsewhere
+	if n := s.closeEventStreams(); n > 0 {
+		log.Infof("Closed %d event stream(s)", n)
+	}
+
+	if n := s.inflight.Load(); n > 0 {
+		log.Infof("Waiting up to %s for %d in-flight request(s)", s.shutdownTimeout, n)
+	}
+	httpDone := make(chan struct{})
+	go func() {
+		httpWg.Wait()
+		close(httpDone)
+	}()
+	for _, done := range []chan struct{}{grpcDone, httpDone} {
+		select {
+		case <-done:
+		case <-ctx.Done():
+		}
+	}
+	if ctx.Err() != nil {
+		log.Warnf("%d request(s) still running after %s, forcing stop", s.inflight.Load(), s.shutdownTimeout)
+		s.forceStop()
+		<-grpcDone
+		<-httpDone
+	} else {
+		log.Infof("Requests drained in %s", time.Since(started).Round(time.Millisecond))
+	}
+
+	if s.mw != nil {
+		// The middleware gets what is left of the timeout. AppShutdown ignores its context, so
+		// it is left running if it misses the deadline
+		mwStarted := time.Now()
+		mwDone := make(chan struct{})
+		go func() {
+			_ = s.mw.AppShutdown(ctx, &pb.RpcAppShutdownRequest{})
+			close(mwDone)
+		}()
+		select {
+		case <-mwDone:
+			log.Infof("Middleware stopped in %s", time.Since(mwStarted).Round(time.Millisecond))
+		case <-ctx.Done():
+			log.Warnf("Middleware did not stop within the shutdown timeout of %s, giving up on it", s.shutdownTimeout)
+		}
+	}
+
+	if s.probeConn != nil {
+		s.probeConn.Close()
+	}
+	if s.auditLog != nil {
+		if err := s.auditLog.Close(); err != nil {
+			log.Errorf("audit log close error: %v", err)
+		}
+	}
+
+	log.Infof("Server stopped in %s", time.Since(started).Round(time.Millisecond))
+	return nil
+}
+
+// closeEventStreams ends all ListenSessionEvents streams and returns how many there were.
+func (s *Server) closeEventStreams() int {
+	if s.events == nil {
+		return 0
+	}
+	s.events.ServerMutex.RLock()
+	tokens := make([]string, 0, len(s.events.Servers))
+	for token := range s.events.Servers {
+		tokens = append(tokens, token)
+	}
+	s.events.ServerMutex.RUnlock()
+
+	for _, token := range tokens {
+		s.events.CloseSession(token)
+	}
+	return len(tokens)
+}
+
+// forceStop closes all connections, cancelling the requests still running on them.
+func (s *Server) forceStop() {
+	if s.grpcServer != nil {
+		s.grpcServer.Stop()
+	}
+	if s.webServer != nil {
+		s.webServer.Close()
+	}
+	if s.apiGateway != nil {
+		s.apiGateway.Close()
+	}
+}
@@ -0,0 +1,96 @@
+//go:build !nogrpcserver
+// +build !nogrpcserver
+
+package grpcserver
+
+import (
+	"context"
+	"sync"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+// The middleware only ships unary interceptors. The stream interceptors below run the same
+// ones for each stream, so streams get the same checks as unary calls.
+
+type tokenGetter interface{ GetToken() string }
+
+// contextStream is a server stream with a context replaced by an interceptor.
+type contextStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *contextStream) Context() context.Context {
+	return s.ctx
+}
+
+// streamInterceptor runs a unary interceptor around the whole stream, without a request.
+func streamInterceptor(unary grpc.UnaryServerInterceptor) grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		unaryInfo := &grpc.UnaryServerInfo{Server: srv, FullMethod: info.FullMethod}
+		_, err := unary(ss.Context(), nil, unaryInfo, func(ctx context.Context, _ interface{}) (interface{}, error) {
+			return nil, handler(srv, &contextStream{ServerStream: ss, ctx: ctx})
+		})
+		return err
+	}
+}
+
+// streamLongMethodsInterceptor reports streams that take too long, like LongMethodsInterceptor does
+// for unary calls. Server streams such as ListenSessionEvents are open for the whole session and
+// are left out.
+func streamLongMethodsInterceptor(unary grpc.UnaryServerInterceptor) grpc.StreamServerInterceptor {
+	wrapped := streamInterceptor(unary)
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		if info.IsServerStream {
+			return handler(srv, ss)
+		}
+		return wrapped(srv, ss, info, handler)
+	}
+}
+
+// authorizeStream checks a stream with the middleware's Authorize once its first request
+// arrives. Streams carry their session token in the request rather than in the metadata,
+// so the token is taken from there when the metadata has none.
+func (s *Server) authorizeStream(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	return handler(srv, &authorizedStream{
+		ServerStream: ss,
+		authorize: func(ctx context.Context, req interface{}) error {
+			_, err := s.authorize(ctx, req, &grpc.UnaryServerInfo{Server: srv, FullMethod: info.FullMethod},
+				func(context.Context, interface{}) (interface{}, error) { return nil, nil })
+			return err
+		},
+	})
+}
+
+type authorizedStream struct {
+	grpc.ServerStream
+	authorize func(ctx context.Context, req interface{}) error
+
+	once sync.Once
+	err  error
+}
+
+func (s *authorizedStream) RecvMsg(m interface{}) error {
+	if err := s.ServerStream.RecvMsg(m); err != nil {
+		return err
+	}
+	s.once.Do(func() {
+		ctx := s.Context()
+		md, _ := metadata.FromIncomingContext(ctx)
+		if g, ok := m.(tokenGetter); ok && len(md.Get("token")) == 0 && g.GetToken() != "" {
+			md = metadata.Join(md, metadata.Pairs("token", g.GetToken()))
+			ctx = metadata.NewIncomingContext(ctx, md)
+		}
+		s.err = s.authorize(ctx, m)
+	})
+	return s.err
+}
+
+func (s *authorizedStream) SendMsg(m interface{}) error {
+	if s.err != nil {
+		return s.err
+	}
+	return s.ServerStream.SendMsg(m)
+}
@@ -0,0 +1,72 @@
+package core
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/anyproto/anytype-cli/core/config"
+	"github.com/anyproto/anytype-cli/core/credentials"
+)
+
+const (
+	// MinBackupPassphraseLength is the shortest passphrase accepted for account key backups.
+	MinBackupPassphraseLength = 8
+	// KeyBackupPassphraseEnvVar holds the backup passphrase for non-interactive export and import.
+	KeyBackupPassphraseEnvVar = "ANYTYPE_KEY_PASSPHRASE"
+)
+
+// KeyBackup is the content of an account key backup before encryption.
+type KeyBackup struct {
+	AccountKey string    `json:"account_key"`
+	AccountId  string    `json:"account_id,omitempty"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// ExportAccountKey encrypts the stored account key with passphrase. The result can be
+// written to a file or rendered as a QR code and restored with ImportAccountKey.
+func ExportAccountKey(passphrase string) ([]byte, error) {
+	if len(passphrase) < MinBackupPassphraseLength {
+		return nil, fmt.Errorf("passphrase must be at least %d characters", MinBackupPassphraseLength)
+	}
+
+	accountKey, _, err := GetStoredAccountKey()
+	if err != nil {
+		if errors.Is(err, ErrNotFound) {
+			return nil, fmt.Errorf("no stored account key; log in first")
+		}
+		return nil, fmt.Errorf("failed to get stored account key: %w", err)
+	}
+
+	backup := KeyBackup{AccountKey: accountKey, CreatedAt: time.Now().UTC().Truncate(time.Second)}
+	if cfg, err := config.LoadStoredConfig(); err == nil {
+		backup.AccountId = cfg.AccountId
+	}
+
+	plain, err := json.Marshal(backup)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal backup: %w", err)
+	}
+	return credentials.Seal(plain, passphrase)
+}
+
+// ImportAccountKey decrypts a backup created by ExportAccountKey and validates the account key in it.
+func ImportAccountKey(data []byte, passphrase string) (*KeyBackup, error) {
+	plain, err := credentials.Open(data, passphrase)
+	if err != nil {
+		if errors.Is(err, credentials.ErrWrongPassphrase) {
+			return nil, fmt.Errorf("wrong passphrase or corrupted backup")
+		}
+		return nil, err
+	}
+
+	var backup KeyBackup
+	if err := json.Unmarshal(plain, &backup); err != nil {
+		return nil, fmt.Errorf("failed to parse backup: %w", err)
+	}
+	if err := ValidateAccountKey(backup.AccountKey); err != nil {
+		return nil, fmt.Errorf("backup contains an invalid account key: %w", err)
+	}
+	return &backup, nil
+}
@@ -0,0 +1,43 @@
+package core
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestAccountKeyBackup(t *testing.T) {
+	setupTestHome(t)
+
+	keyringUnavailable = true
+	defer func() { keyringUnavailable = false }()
+
+	accountKey := "bNYSkBlOzNMKpDupAgL3g31Hnq7JpeX45O6MCpUqNdt16Avbgy5T5oQECKvAoy3+E4wHGPpCRCVWZQQCXRh7xw=="
+	if _, err := SaveAccountKey(accountKey); err != nil {
+		t.Fatalf("SaveAccountKey failed: %v", err)
+	}
+	defer func() { _ = DeleteStoredAccountKey() }()
+
+	if _, err := ExportAccountKey("short"); err == nil {
+		t.Error("Expected error for short passphrase")
+	}
+
+	data, err := ExportAccountKey("correct horse battery staple")
+	if err != nil {
+		t.Fatalf("ExportAccountKey failed: %v", err)
+	}
+	if strings.Contains(string(data), accountKey) {
+		t.Fatal("Backup contains the account key in plain text")
+	}
+
+	backup, err := ImportAccountKey(data, "correct horse battery staple")
+	if err != nil {
+		t.Fatalf("ImportAccountKey failed: %v", err)
+	}
+	if backup.AccountKey != accountKey {
+		t.Errorf("Expected account key %q, got %q", accountKey, backup.AccountKey)
+	}
+
+	if _, err := ImportAccountKey(data, "wrong passphrase"); err == nil || !strings.Contains(err.Error(), "wrong passphrase") {
+		t.Errorf("Expected wrong passphrase error, got %v", err)
+	}
+}
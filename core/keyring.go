@@ -4,18 +4,13 @@ import (
 	"errors"
 
 	"github.com/anyproto/anytype-cli/core/config"
-	"github.com/zalando/go-keyring"
-)
-
-const (
-	keyringService        = "anytype-cli"
-	keyringTokenUser      = "session-token"
-	keyringAccountKeyUser = "account-key"
+	"github.com/anyproto/anytype-cli/core/credentials"
+	"github.com/anyproto/anytype-cli/core/output"
 )
 
 var (
 	keyringUnavailable = false
-	ErrNotFound        = errors.New("credentials not found")
+	ErrNotFound        = credentials.ErrNotFound
 )
 
 // isKeyringAvailable checks if the keyring is accessible
@@ -24,99 +19,136 @@ func isKeyringAvailable() bool {
 		return false
 	}
 
-	err := keyring.Set(keyringService, "test", "test")
-	if err != nil {
+	if !credentials.KeyringAvailable() {
 		keyringUnavailable = true
 		return false
 	}
-	_ = keyring.Delete(keyringService, "test")
 	return true
 }
 
-// SaveSessionToken saves the session token to the keyring if available, otherwise to the config file.
-// Returns true if saved to keyring, false if saved to config file.
-func SaveSessionToken(token string) (bool, error) {
-	if isKeyringAvailable() {
-		return true, keyring.Set(keyringService, keyringTokenUser, token)
+// credentialBackends returns the configured backend name, the backend new credentials are
+// written to, and the backends they are read from in order. In auto mode the keyring is used
+// when available, with the config file as fallback.
+func credentialBackends() (string, credentials.Backend, []credentials.Backend, error) {
+	cfg, err := config.GetEffectiveCredentialsConfig()
+	if err != nil {
+		return "", nil, nil, err
+	}
+	name, err := credentials.ConfiguredBackend(cfg)
+	if err != nil {
+		return "", nil, nil, err
 	}
 
-	return false, config.SetSessionTokenToConfig(token)
-}
-
-// GetStoredSessionToken retrieves the session token from keyring if available, otherwise from config file.
-// Returns the token, true if retrieved from keyring (false if from config file), and any error.
-func GetStoredSessionToken() (string, bool, error) {
-	if isKeyringAvailable() {
-		token, err := keyring.Get(keyringService, keyringTokenUser)
-		if err == nil {
-			return token, true, nil
-		}
-		if !errors.Is(err, keyring.ErrNotFound) {
-			keyringUnavailable = true
+	if name == credentials.BackendAuto {
+		file := credentials.NewConfigFile()
+		if isKeyringAvailable() {
+			kr := credentials.NewKeyring()
+			return name, kr, []credentials.Backend{kr, file}, nil
 		}
+		return name, file, []credentials.Backend{file}, nil
 	}
 
-	token, _ := config.GetSessionTokenFromConfig()
-	if token == "" {
-		return "", false, ErrNotFound
+	b, err := credentials.New(name, cfg)
+	if err != nil {
+		return "", nil, nil, err
 	}
-	return token, false, nil
+	return name, b, []credentials.Backend{b}, nil
 }
 
-func DeleteStoredSessionToken() error {
-	var keyringErr error
-	if isKeyringAvailable() {
-		keyringErr = keyring.Delete(keyringService, keyringTokenUser)
+func saveCredential(name, value string) (string, error) {
+	configured, b, _, err := credentialBackends()
+	if err != nil {
+		return "", err
+	}
+	if err := b.Set(name, value); err != nil {
+		return "", err
 	}
 
-	configErr := config.SetSessionTokenToConfig("")
-
-	if keyringErr != nil && configErr != nil {
-		return configErr
+	switch b.Name() {
+	case credentials.BackendFile:
+		if configured == credentials.BackendAuto {
+			output.Warning("System keyring unavailable (requires D-Bus on Linux, Keychain on macOS, Credential Manager on Windows)")
+		}
+		output.Warning("Storing credentials in config file: %s (insecure)", config.GetConfigManager().GetFilePath())
+	case credentials.BackendEnv:
+		output.Warning("%s is not persisted by the env backend; set %s to make it available to other commands", name, credentials.EnvVar(name))
 	}
-	return nil
+	return b.Name(), nil
 }
 
-// SaveAccountKey saves the account key to the keyring if available, otherwise to the config file.
-// Returns true if saved to keyring, false if saved to config file.
-func SaveAccountKey(accountKey string) (bool, error) {
-	if isKeyringAvailable() {
-		return true, keyring.Set(keyringService, keyringAccountKeyUser, accountKey)
+func getCredential(name string) (string, string, error) {
+	configured, _, readers, err := credentialBackends()
+	if err != nil {
+		return "", "", err
 	}
 
-	return false, config.SetAccountKeyToConfig(accountKey)
-}
-
-// GetStoredAccountKey retrieves the account key from keyring if available, otherwise from config file.
-// Returns the account key, true if retrieved from keyring (false if from config file), and any error.
-func GetStoredAccountKey() (string, bool, error) {
-	if isKeyringAvailable() {
-		key, err := keyring.Get(keyringService, keyringAccountKeyUser)
+	for _, b := range readers {
+		value, err := b.Get(name)
 		if err == nil {
-			return key, true, nil
+			return value, b.Name(), nil
+		}
+		if errors.Is(err, credentials.ErrNotFound) {
+			continue
 		}
-		if !errors.Is(err, keyring.ErrNotFound) {
+		if configured != credentials.BackendAuto {
+			return "", "", err
+		}
+		if b.Name() == credentials.BackendKeyring {
 			keyringUnavailable = true
 		}
 	}
-
-	key, _ := config.GetAccountKeyFromConfig()
-	if key == "" {
-		return "", false, ErrNotFound
-	}
-	return key, false, nil
+	return "", "", ErrNotFound
 }
 
-func DeleteStoredAccountKey() error {
-	var keyringErr error
-	if isKeyringAvailable() {
-		keyringErr = keyring.Delete(keyringService, keyringAccountKeyUser)
+func deleteCredential(name string) error {
+	_, _, readers, err := credentialBackends()
+	if err != nil {
+		return err
 	}
 
-	configErr := config.SetAccountKeyToConfig("")
-
-	if keyringErr != nil && configErr != nil {
-		return configErr
+	var lastErr error
+	deleted := false
+	for _, b := range readers {
+		if err := b.Delete(name); err != nil {
+			lastErr = err
+			continue
+		}
+		deleted = true
+	}
+	if !deleted {
+		return lastErr
 	}
 	return nil
 }
+
+// SaveSessionToken saves the session token to the configured credentials backend.
+// Returns the name of the backend it was saved to.
+func SaveSessionToken(token string) (string, error) {
+	return saveCredential(credentials.SessionToken, token)
+}
+
+// GetStoredSessionToken retrieves the session token from the configured credentials backend.
+// Returns the token, the name of the backend it was found in, and any error.
+func GetStoredSessionToken() (string, string, error) {
+	return getCredential(credentials.SessionToken)
+}
+
+func DeleteStoredSessionToken() error {
+	return deleteCredential(credentials.SessionToken)
+}
+
+// SaveAccountKey saves the account key to the configured credentials backend.
+// Returns the name of the backend it was saved to.
+func SaveAccountKey(accountKey string) (string, error) {
+	return saveCredential(credentials.AccountKey, accountKey)
+}
+
+// GetStoredAccountKey retrieves the account key from the configured credentials backend.
+// Returns the account key, the name of the backend it was found in, and any error.
+func GetStoredAccountKey() (string, string, error) {
+	return getCredential(credentials.AccountKey)
+}
+
+func DeleteStoredAccountKey() error {
+	return deleteCredential(credentials.AccountKey)
+}
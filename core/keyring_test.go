@@ -6,6 +6,7 @@ import (
 	"testing"
 
 	"github.com/anyproto/anytype-cli/core/config"
+	"github.com/anyproto/anytype-cli/core/credentials"
 )
 
 func setupTestHome(t *testing.T) string {
@@ -27,12 +28,12 @@ func TestKeyringFallback(t *testing.T) {
 
 	testAccountKey := "test-account-key-12345"
 
-	savedToKeyring, err := SaveAccountKey(testAccountKey)
+	backend, err := SaveAccountKey(testAccountKey)
 	if err != nil {
 		t.Fatalf("SaveAccountKey failed: %v", err)
 	}
-	if savedToKeyring {
-		t.Error("Expected SaveAccountKey to return false (config fallback) when keyring unavailable, got true")
+	if backend != credentials.BackendFile {
+		t.Errorf("Expected SaveAccountKey to use the config file backend when keyring unavailable, got %q", backend)
 	}
 
 	configMgr := config.GetConfigManager()
@@ -46,7 +47,7 @@ func TestKeyringFallback(t *testing.T) {
 		t.Errorf("Expected account key %q, got %q", testAccountKey, cfg.AccountKey)
 	}
 
-	retrievedKey, fromKeyring, err := GetStoredAccountKey()
+	retrievedKey, backend, err := GetStoredAccountKey()
 	if err != nil {
 		t.Fatalf("GetStoredAccountKey failed: %v", err)
 	}
@@ -55,8 +56,8 @@ func TestKeyringFallback(t *testing.T) {
 		t.Errorf("Expected retrieved key %q, got %q", testAccountKey, retrievedKey)
 	}
 
-	if fromKeyring {
-		t.Error("Expected fromKeyring to be false (config fallback), got true")
+	if backend != credentials.BackendFile {
+		t.Errorf("Expected config file backend, got %q", backend)
 	}
 
 	err = DeleteStoredAccountKey()
@@ -88,12 +89,12 @@ func TestTokenFallback(t *testing.T) {
 
 	testToken := "test-session-token-67890"
 
-	savedToKeyring, err := SaveSessionToken(testToken)
+	backend, err := SaveSessionToken(testToken)
 	if err != nil {
 		t.Fatalf("SaveSessionToken failed: %v", err)
 	}
-	if savedToKeyring {
-		t.Error("Expected SaveSessionToken to return false (config fallback) when keyring unavailable, got true")
+	if backend != credentials.BackendFile {
+		t.Errorf("Expected SaveSessionToken to use the config file backend when keyring unavailable, got %q", backend)
 	}
 
 	configMgr := config.GetConfigManager()
@@ -107,7 +108,7 @@ func TestTokenFallback(t *testing.T) {
 		t.Errorf("Expected session token %q, got %q", testToken, cfg.SessionToken)
 	}
 
-	retrievedToken, fromKeyring, err := GetStoredSessionToken()
+	retrievedToken, backend, err := GetStoredSessionToken()
 	if err != nil {
 		t.Fatalf("GetStoredSessionToken failed: %v", err)
 	}
@@ -116,8 +117,8 @@ func TestTokenFallback(t *testing.T) {
 		t.Errorf("Expected retrieved token %q, got %q", testToken, retrievedToken)
 	}
 
-	if fromKeyring {
-		t.Error("Expected fromKeyring to be false (config fallback), got true")
+	if backend != credentials.BackendFile {
+		t.Errorf("Expected config file backend, got %q", backend)
 	}
 
 	err = DeleteStoredSessionToken()
@@ -148,12 +149,12 @@ func TestConfigFilePermissions(t *testing.T) {
 	defer func() { keyringUnavailable = false }()
 
 	testAccountKey := "test-account-key-permissions"
-	savedToKeyring, err := SaveAccountKey(testAccountKey)
+	backend, err := SaveAccountKey(testAccountKey)
 	if err != nil {
 		t.Fatalf("SaveAccountKey failed: %v", err)
 	}
-	if savedToKeyring {
-		t.Error("Expected SaveAccountKey to return false (config fallback) when keyring unavailable, got true")
+	if backend != credentials.BackendFile {
+		t.Errorf("Expected SaveAccountKey to use the config file backend when keyring unavailable, got %q", backend)
 	}
 
 	configMgr := config.GetConfigManager()
@@ -0,0 +1,100 @@
+package portcheck
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"os/user"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// tcpListen is the state of a listening socket in /proc/net/tcp
+const tcpListen = "0A"
+
+// socketEntry is a listening socket from /proc/net/tcp or /proc/net/tcp6.
+type socketEntry struct {
+	uid   string
+	inode string
+}
+
+// FindOwner returns the process listening on the TCP port. It looks the socket up in
+// /proc/net/tcp and then searches the open files of all processes for it; processes of other
+// users cannot be searched, in which case only the user is known. It returns nil if no
+// listening socket was found.
+func FindOwner(port int) (*Process, error) {
+	var entries []socketEntry
+	for _, name := range []string{"/proc/net/tcp", "/proc/net/tcp6"} {
+		f, err := os.Open(name)
+		if err != nil {
+			continue
+		}
+		found, err := parseListeners(f, port)
+		f.Close()
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", name, err)
+		}
+		entries = append(entries, found...)
+	}
+	if len(entries) == 0 {
+		return nil, nil
+	}
+
+	for _, e := range entries {
+		if pid := findSocketPID(e.inode); pid != 0 {
+			return describePID(pid), nil
+		}
+	}
+	p := &Process{User: entries[0].uid}
+	if u, err := user.LookupId(entries[0].uid); err == nil {
+		p.User = u.Username
+	}
+	return p, nil
+}
+
+// parseListeners returns the listening sockets on port from the content of /proc/net/tcp.
+func parseListeners(r io.Reader, port int) ([]socketEntry, error) {
+	var entries []socketEntry
+	scanner := bufio.NewScanner(r)
+	scanner.Scan() // header
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 10 || fields[3] != tcpListen {
+			continue
+		}
+		_, hexPort, ok := strings.Cut(fields[1], ":")
+		if !ok {
+			continue
+		}
+		if n, err := strconv.ParseUint(hexPort, 16, 16); err != nil || int(n) != port {
+			continue
+		}
+		entries = append(entries, socketEntry{uid: fields[7], inode: fields[9]})
+	}
+	return entries, scanner.Err()
+}
+
+func findSocketPID(inode string) int {
+	target := "socket:[" + inode + "]"
+	fds, _ := filepath.Glob("/proc/[0-9]*/fd/*")
+	for _, fd := range fds {
+		if link, err := os.Readlink(fd); err == nil && link == target {
+			pid, _ := strconv.Atoi(strings.Split(fd, "/")[2])
+			return pid
+		}
+	}
+	return 0
+}
+
+func describePID(pid int) *Process {
+	p := &Process{PID: pid}
+	if comm, err := os.ReadFile(fmt.Sprintf("/proc/%d/comm", pid)); err == nil {
+		p.Name = strings.TrimSpace(string(comm))
+	}
+	if cmdline, err := os.ReadFile(fmt.Sprintf("/proc/%d/cmdline", pid)); err == nil {
+		p.Command = strings.TrimSpace(strings.ReplaceAll(string(cmdline), "\x00", " "))
+	}
+	return p
+}
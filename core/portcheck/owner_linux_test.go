@@ -0,0 +1,27 @@
+package portcheck
+
+import (
+	"strings"
+	"testing"
+)
+
+const procNetTCP = `  sl  local_address rem_address   st tx_queue rx_queue tr tm->when retrnsmt   uid  timeout inode
+   0: 0100007F:7A32 00000000:0000 0A 00000000:00000000 00:00000000 00000000  1000        0 4242 1 0000000000000000 100 0 0 10 0
+   1: 0100007F:7A32 0100007F:C350 01 00000000:00000000 00:00000000 00000000  1000        0 4343 1 0000000000000000 20 4 30 10 -1
+   2: 00000000:0016 00000000:0000 0A 00000000:00000000 00:00000000 00000000     0        0 1111 1 0000000000000000 100 0 0 10 0
+`
+
+func TestParseListeners(t *testing.T) {
+	entries, err := parseListeners(strings.NewReader(procNetTCP), 31282)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 || entries[0].inode != "4242" || entries[0].uid != "1000" {
+		t.Errorf("entries = %+v, want the listening socket 4242", entries)
+	}
+
+	entries, _ = parseListeners(strings.NewReader(procNetTCP), 31010)
+	if len(entries) != 0 {
+		t.Errorf("entries for an unused port = %+v", entries)
+	}
+}
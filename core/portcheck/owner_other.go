@@ -0,0 +1,8 @@
+//go:build !linux
+
+package portcheck
+
+// FindOwner is only implemented on Linux and returns nil elsewhere.
+func FindOwner(port int) (*Process, error) {
+	return nil, nil
+}
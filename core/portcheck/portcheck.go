@@ -0,0 +1,175 @@
+// Package portcheck explains why a listen address is unavailable and picks free ports instead.
+package portcheck
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"strconv"
+	"syscall"
+)
+
+// maxScan is the number of ports after a busy one that SelectFree tries
+const maxScan = 100
+
+// Process is a process listening on a port. Fields it could not determine are empty.
+type Process struct {
+	PID     int
+	Name    string
+	Command string
+	User    string
+}
+
+func (p *Process) String() string {
+	switch {
+	case p.PID != 0 && p.Command != "":
+		return fmt.Sprintf("%s (PID %d: %s)", p.Name, p.PID, p.Command)
+	case p.PID != 0:
+		return fmt.Sprintf("%s (PID %d)", p.Name, p.PID)
+	case p.User != "":
+		return fmt.Sprintf("a process of user %s", p.User)
+	}
+	return "another process"
+}
+
+// InUseError reports a listen address whose port another process uses.
+type InUseError struct {
+	Addr string
+	// Owner is nil if the process could not be determined, e.g. on other platforms
+	Owner *Process
+	Err   error
+}
+
+func (e *InUseError) Error() string {
+	owner := "another process"
+	if e.Owner != nil {
+		owner = e.Owner.String()
+	}
+	return fmt.Sprintf("%s is already in use by %s", e.Addr, owner)
+}
+
+func (e *InUseError) Unwrap() error {
+	return e.Err
+}
+
+// isAddrInUse reports whether err is EADDRINUSE or its Windows counterpart WSAEADDRINUSE.
+func isAddrInUse(err error) bool {
+	return errors.Is(err, syscall.EADDRINUSE) || errors.Is(err, syscall.Errno(10048))
+}
+
+// Diagnose turns a failure to listen on addr into an InUseError naming the process that owns
+// the port, where the platform allows finding it. Other errors are returned unchanged.
+func Diagnose(addr string, err error) error {
+	if !isAddrInUse(err) {
+		return err
+	}
+	inUse := &InUseError{Addr: addr, Err: err}
+	if _, port, splitErr := net.SplitHostPort(addr); splitErr == nil {
+		if n, convErr := strconv.Atoi(port); convErr == nil {
+			inUse.Owner, _ = FindOwner(n)
+		}
+	}
+	return inUse
+}
+
+// DialAddr returns an address to reach a listener on; wildcard addresses are reached over loopback.
+func DialAddr(addr string) string {
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		return addr
+	}
+	ip := net.ParseIP(host)
+	switch {
+	case host == "":
+		host = "127.0.0.1"
+	case ip != nil && ip.IsUnspecified() && ip.To4() != nil:
+		host = "127.0.0.1"
+	case ip != nil && ip.IsUnspecified():
+		host = "::1"
+	}
+	return net.JoinHostPort(host, port)
+}
+
+// Listen listens on addr and diagnoses a failure like Diagnose.
+func Listen(addr string) (net.Listener, error) {
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, Diagnose(addr, err)
+	}
+	return ln, nil
+}
+
+// Check returns an error if addr cannot be listened on, diagnosed like Diagnose.
+func Check(addr string) error {
+	ln, err := Listen(addr)
+	if err != nil {
+		return err
+	}
+	return ln.Close()
+}
+
+// SelectFree returns addrs with every address whose port is in use replaced by the same host
+// on the next free port. Ports in avoid, e.g. those of other profiles, are skipped as well, and
+// no two returned addresses share a port. Errors other than a busy port are returned.
+func SelectFree(addrs []string, avoid []string) ([]string, error) {
+	used := map[int]bool{}
+	for _, addr := range avoid {
+		if port, err := portOf(addr); err == nil {
+			used[port] = true
+		}
+	}
+
+	selected := make([]string, len(addrs))
+	for i, addr := range addrs {
+		host, _, err := net.SplitHostPort(addr)
+		if err != nil {
+			return nil, err
+		}
+		port, err := portOf(addr)
+		if err != nil {
+			return nil, err
+		}
+		if port == 0 {
+			selected[i] = addr
+			continue
+		}
+
+		var inUse *InUseError
+		err = Check(addr)
+		switch {
+		case err == nil && !used[port]:
+			selected[i] = addr
+		case err == nil || errors.As(err, &inUse):
+			next, err := nextFree(host, port, used)
+			if err != nil {
+				return nil, fmt.Errorf("no free port for %s: %w", addr, err)
+			}
+			selected[i] = net.JoinHostPort(host, strconv.Itoa(next))
+		default:
+			return nil, err
+		}
+		p, _ := portOf(selected[i])
+		used[p] = true
+	}
+	return selected, nil
+}
+
+func nextFree(host string, port int, used map[int]bool) (int, error) {
+	for p := port + 1; p <= port+maxScan && p <= 65535; p++ {
+		if used[p] {
+			continue
+		}
+		if Check(net.JoinHostPort(host, strconv.Itoa(p))) == nil {
+			return p, nil
+		}
+	}
+	return 0, fmt.Errorf("ports %d-%d are all in use", port+1, port+maxScan)
+}
+
+func portOf(addr string) (int, error) {
+	_, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		return 0, err
+	}
+	return strconv.Atoi(port)
+}
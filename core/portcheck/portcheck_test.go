@@ -0,0 +1,122 @@
+package portcheck
+
+import (
+	"errors"
+	"net"
+	"os"
+	"runtime"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func listenLocal(t *testing.T) (net.Listener, int) {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ln.Close() })
+	return ln, ln.Addr().(*net.TCPAddr).Port
+}
+
+func TestCheck(t *testing.T) {
+	ln, port := listenLocal(t)
+
+	err := Check(ln.Addr().String())
+	var inUse *InUseError
+	if !errors.As(err, &inUse) {
+		t.Fatalf("Check error = %v, want InUseError", err)
+	}
+	if runtime.GOOS == "linux" {
+		if inUse.Owner == nil || inUse.Owner.PID != os.Getpid() {
+			t.Errorf("owner = %+v, want this process", inUse.Owner)
+		}
+		if !strings.Contains(err.Error(), "PID "+strconv.Itoa(os.Getpid())) {
+			t.Errorf("error %q does not name the PID", err)
+		}
+	}
+
+	ln.Close()
+	if err := Check(net.JoinHostPort("127.0.0.1", strconv.Itoa(port))); err != nil {
+		t.Errorf("Check after close = %v", err)
+	}
+}
+
+func TestListen(t *testing.T) {
+	ln, err := Listen("127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Listen failed: %v", err)
+	}
+	defer ln.Close()
+
+	_, err = Listen(ln.Addr().String())
+	var inUse *InUseError
+	if !errors.As(err, &inUse) || inUse.Addr != ln.Addr().String() {
+		t.Errorf("Listen on a busy port error = %v, want InUseError for %s", err, ln.Addr())
+	}
+}
+
+func TestSelectFree(t *testing.T) {
+	busy, port := listenLocal(t)
+	free := net.JoinHostPort("127.0.0.1", strconv.Itoa(port+1))
+
+	got, err := SelectFree([]string{busy.Addr().String(), free, "127.0.0.1:0"}, nil)
+	if err != nil {
+		t.Fatalf("SelectFree failed: %v", err)
+	}
+	if got[0] == busy.Addr().String() {
+		t.Errorf("busy address %s was kept", got[0])
+	}
+	if got[2] != "127.0.0.1:0" {
+		t.Errorf("port 0 was replaced by %s", got[2])
+	}
+	if got[0] == got[1] {
+		t.Errorf("two addresses share %s", got[0])
+	}
+	for _, addr := range got[:2] {
+		if err := Check(addr); err != nil {
+			t.Errorf("selected %s is not free: %v", addr, err)
+		}
+	}
+
+	got, err = SelectFree([]string{free}, []string{free})
+	if err != nil {
+		t.Fatalf("SelectFree failed: %v", err)
+	}
+	if got[0] == free {
+		t.Errorf("avoided address %s was kept", free)
+	}
+}
+
+func TestProcessString(t *testing.T) {
+	tests := []struct {
+		p    Process
+		want string
+	}{
+		{Process{PID: 7, Name: "anytype", Command: "anytype serve"}, "anytype (PID 7: anytype serve)"},
+		{Process{PID: 7, Name: "anytype"}, "anytype (PID 7)"},
+		{Process{User: "bot"}, "a process of user bot"},
+		{Process{}, "another process"},
+	}
+	for _, tt := range tests {
+		if got := tt.p.String(); got != tt.want {
+			t.Errorf("String() = %q, want %q", got, tt.want)
+		}
+	}
+}
+
+func TestDialAddr(t *testing.T) {
+	tests := map[string]string{
+		"127.0.0.1:31010": "127.0.0.1:31010",
+		"0.0.0.0:31010":   "127.0.0.1:31010",
+		":31010":          "127.0.0.1:31010",
+		"[::]:31010":      "[::1]:31010",
+		"example.com:80":  "example.com:80",
+	}
+	for addr, want := range tests {
+		if got := DialAddr(addr); got != want {
+			t.Errorf("DialAddr(%q) = %q, want %q", addr, got, want)
+		}
+	}
+}
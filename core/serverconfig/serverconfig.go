@@ -0,0 +1,340 @@
+package serverconfig
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/anyproto/anytype-cli/core/config"
+)
+
+const (
+	DefaultAutoLoginRetryDelay    = 2 * time.Second
+	DefaultAutoLoginMaxRetryDelay = 5 * time.Minute
+
+	DefaultWatchdogInterval = 30 * time.Second
+	DefaultWatchdogTimeout  = 10 * time.Second
+	DefaultWatchdogFailures = 3
+)
+
+// Config is the declarative server configuration read by `serve --config`. Command-line flags
+// take precedence over the file; settings it leaves out come from the environment and the profile's config.
+type Config struct {
+	ListenAddress        string `yaml:"listenAddress"`
+	GRPCListenAddress    string `yaml:"grpcListenAddress"`
+	GRPCWebListenAddress string `yaml:"grpcWebListenAddress"`
+	DataDir              string `yaml:"dataDir"`
+
+	Log       Log       `yaml:"log"`
+	GRPC      GRPC      `yaml:"grpc"`
+	TLS       TLS       `yaml:"tls"`
+	Webhooks  []Webhook `yaml:"webhooks"`
+	AutoLogin AutoLogin `yaml:"autoLogin"`
+	Startup   Startup   `yaml:"startup"`
+	Shutdown  Shutdown  `yaml:"shutdown"`
+	Watchdog  Watchdog  `yaml:"watchdog"`
+}
+
+// Log sets the log level and, when the server runs as a service without journald, how its
+// log file is rotated. Zero values keep the defaults, except for MaxBackups, where 0 keeps no
+// rotated files and only an unset value keeps the default.
+type Log struct {
+	Level      string        `yaml:"level"`
+	MaxSizeMB  int           `yaml:"maxSizeMB"`
+	MaxBackups *int          `yaml:"maxBackups"`
+	MaxAge     time.Duration `yaml:"maxAge"`
+}
+
+// GRPC mirrors the --grpc-* server limit flags. Unset fields keep the flag value.
+type GRPC struct {
+	MaxRecvMsgSize               *int           `yaml:"maxRecvMsgSize"`
+	MaxSendMsgSize               *int           `yaml:"maxSendMsgSize"`
+	MaxConcurrentStreams         *uint32        `yaml:"maxConcurrentStreams"`
+	KeepaliveTime                *time.Duration `yaml:"keepaliveTime"`
+	KeepaliveTimeout             *time.Duration `yaml:"keepaliveTimeout"`
+	KeepaliveMinTime             *time.Duration `yaml:"keepaliveMinTime"`
+	KeepalivePermitWithoutStream *bool          `yaml:"keepalivePermitWithoutStream"`
+	MaxConnectionIdle            *time.Duration `yaml:"maxConnectionIdle"`
+	WebIdleTimeout               *time.Duration `yaml:"webIdleTimeout"`
+}
+
+// rotation returns the settings of l other than the level.
+func (l Log) rotation() Log {
+	l.Level = ""
+	return l
+}
+
+// TLS serves the JSON API over HTTPS with the given certificate and key.
+type TLS struct {
+	CertFile string `yaml:"certFile"`
+	KeyFile  string `yaml:"keyFile"`
+}
+
+// Enabled reports whether a certificate is configured.
+func (t TLS) Enabled() bool {
+	return t.CertFile != ""
+}
+
+// Startup mirrors the --start-timeout and --ready-timeout flags. Unset fields keep the flag value.
+type Startup struct {
+	Timeout      *time.Duration `yaml:"timeout"`
+	ReadyTimeout *time.Duration `yaml:"readyTimeout"`
+}
+
+// Shutdown mirrors the --shutdown-timeout flag. An unset timeout keeps the flag value.
+type Shutdown struct {
+	Timeout *time.Duration `yaml:"timeout"`
+}
+
+// Webhook receives a POST with a JSON body for each matching server event.
+// If Secret is set, the body is signed with HMAC-SHA256 in the X-Anytype-Signature header.
+type Webhook struct {
+	URL    string   `yaml:"url"`
+	Events []string `yaml:"events"`
+	Secret string   `yaml:"secret"`
+}
+
+// AutoLogin controls how the server logs in with the stored account key on start. Failed
+// attempts are retried with a delay that doubles from RetryDelay up to MaxRetryDelay.
+type AutoLogin struct {
+	// Enabled defaults to true
+	Enabled *bool `yaml:"enabled"`
+	// Attempts limits the number of attempts; 0 retries until the login succeeds
+	Attempts      int           `yaml:"attempts"`
+	RetryDelay    time.Duration `yaml:"retryDelay"`
+	MaxRetryDelay time.Duration `yaml:"maxRetryDelay"`
+}
+
+// IsEnabled reports whether auto-login is enabled, which is the default.
+func (a AutoLogin) IsEnabled() bool {
+	return a.Enabled == nil || *a.Enabled
+}
+
+// MaxAttempts returns the maximum number of login attempts; 0 means no limit.
+func (a AutoLogin) MaxAttempts() int {
+	return max(a.Attempts, 0)
+}
+
+// Delay returns the delay after the first failed attempt, or the default if unset.
+func (a AutoLogin) Delay() time.Duration {
+	if a.RetryDelay <= 0 {
+		return DefaultAutoLoginRetryDelay
+	}
+	return a.RetryDelay
+}
+
+// MaxDelay returns the longest delay between attempts, or the default if unset.
+func (a AutoLogin) MaxDelay() time.Duration {
+	if a.MaxRetryDelay <= 0 {
+		return max(DefaultAutoLoginMaxRetryDelay, a.Delay())
+	}
+	return max(a.MaxRetryDelay, a.Delay())
+}
+
+// Watchdog restarts a server whose middleware stops answering. Every Interval the server calls
+// a cheap RPC; after Failures consecutive calls that fail or take longer than Timeout it writes
+// goroutine dumps to the logs directory and exits, so that the service manager restarts it.
+type Watchdog struct {
+	// Enabled defaults to true
+	Enabled  *bool         `yaml:"enabled"`
+	Interval time.Duration `yaml:"interval"`
+	Timeout  time.Duration `yaml:"timeout"`
+	Failures int           `yaml:"failures"`
+}
+
+// IsEnabled reports whether the watchdog is enabled, which is the default.
+func (w Watchdog) IsEnabled() bool {
+	return w.Enabled == nil || *w.Enabled
+}
+
+// CheckInterval returns the time between checks, or the default if unset.
+func (w Watchdog) CheckInterval() time.Duration {
+	if w.Interval <= 0 {
+		return DefaultWatchdogInterval
+	}
+	return w.Interval
+}
+
+// LatencyLimit returns how long a check may take before it counts as failed, or the default if unset.
+func (w Watchdog) LatencyLimit() time.Duration {
+	if w.Timeout <= 0 {
+		return DefaultWatchdogTimeout
+	}
+	return w.Timeout
+}
+
+// MaxFailures returns the number of consecutive failed checks that restart the server, or the default if unset.
+func (w Watchdog) MaxFailures() int {
+	if w.Failures <= 0 {
+		return DefaultWatchdogFailures
+	}
+	return w.Failures
+}
+
+// TripTime returns the longest time the watchdog takes to restart the server after the
+// middleware stops answering.
+func (w Watchdog) TripTime() time.Duration {
+	return time.Duration(w.MaxFailures()) * (w.CheckInterval() + w.LatencyLimit())
+}
+
+// Default returns the configuration used when serve runs without --config.
+func Default() *Config {
+	return &Config{}
+}
+
+// Load reads and validates a server config file. Unknown keys are rejected and relative
+// paths are resolved against the file's directory.
+func Load(path string) (*Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read server config: %w", err)
+	}
+
+	cfg, err := Parse(data)
+	if err != nil {
+		return nil, fmt.Errorf("invalid server config %s: %w", path, err)
+	}
+	cfg.resolvePaths(filepath.Dir(path))
+	return cfg, nil
+}
+
+// Parse decodes and validates a server config.
+func Parse(data []byte) (*Config, error) {
+	cfg := Default()
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	if err := dec.Decode(cfg); err != nil && !errors.Is(err, io.EOF) {
+		return nil, err
+	}
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+func (c *Config) resolvePaths(dir string) {
+	for _, p := range []*string{&c.DataDir, &c.TLS.CertFile, &c.TLS.KeyFile} {
+		if *p != "" && !filepath.IsAbs(*p) {
+			*p = filepath.Join(dir, *p)
+		}
+	}
+}
+
+// Validate checks the settings using the same rules as the matching config keys.
+func (c *Config) Validate() error {
+	for key, value := range c.Settings() {
+		k, err := config.LookupKey(key)
+		if err != nil {
+			return err
+		}
+		if k.Validate != nil {
+			if err := k.Validate(value); err != nil {
+				return err
+			}
+		}
+	}
+
+	if (c.TLS.CertFile == "") != (c.TLS.KeyFile == "") {
+		return fmt.Errorf("tls: certFile and keyFile must be set together")
+	}
+
+	for i, hook := range c.Webhooks {
+		u, err := url.Parse(hook.URL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("webhooks[%d]: invalid url %q: expected an http or https URL", i, hook.URL)
+		}
+		for _, event := range hook.Events {
+			if !slices.Contains(Events, event) {
+				return fmt.Errorf("webhooks[%d]: unknown event %q", i, event)
+			}
+		}
+	}
+
+	if c.Log.MaxSizeMB < 0 || (c.Log.MaxBackups != nil && *c.Log.MaxBackups < 0) || c.Log.MaxAge < 0 {
+		return fmt.Errorf("log: rotation limits must not be negative")
+	}
+	if c.AutoLogin.Attempts < 0 {
+		return fmt.Errorf("autoLogin: attempts must not be negative")
+	}
+	if c.AutoLogin.RetryDelay < 0 || c.AutoLogin.MaxRetryDelay < 0 {
+		return fmt.Errorf("autoLogin: retry delays must not be negative")
+	}
+	if c.Watchdog.Interval < 0 || c.Watchdog.Timeout < 0 || c.Watchdog.Failures < 0 {
+		return fmt.Errorf("watchdog: interval, timeout and failures must not be negative")
+	}
+	for name, d := range map[string]*time.Duration{"timeout": c.Startup.Timeout, "readyTimeout": c.Startup.ReadyTimeout} {
+		if d != nil && *d <= 0 {
+			return fmt.Errorf("startup: %s must be positive", name)
+		}
+	}
+	if c.Shutdown.Timeout != nil && *c.Shutdown.Timeout <= 0 {
+		return fmt.Errorf("shutdown: timeout must be positive")
+	}
+	return nil
+}
+
+// Settings returns the values the file sets for config keys, by key name.
+func (c *Config) Settings() map[string]string {
+	settings := make(map[string]string)
+	for key, value := range map[string]string{
+		"listenAddress":        c.ListenAddress,
+		"grpcListenAddress":    c.GRPCListenAddress,
+		"grpcWebListenAddress": c.GRPCWebListenAddress,
+		"dataDir":              c.DataDir,
+		"logLevel":             c.Log.Level,
+	} {
+		if value != "" {
+			settings[key] = value
+		}
+	}
+	return settings
+}
+
+// Apply records the file's settings as overrides of the config keys for the rest of the process.
+// Keys in skip, typically those given as flags, are left alone.
+func (c *Config) Apply(skip func(key string) bool) error {
+	for key, value := range c.Settings() {
+		if skip != nil && skip(key) {
+			continue
+		}
+		if err := config.SetFlagValue(key, value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// RestartRequired lists the settings that differ between old and new but cannot be changed
+// while the server is running. Log level, TLS certificate files, webhooks and auto-login are reloadable.
+func RestartRequired(old, new *Config) []string {
+	var changed []string
+	for _, s := range []struct {
+		name     string
+		old, new any
+	}{
+		{"listenAddress", old.ListenAddress, new.ListenAddress},
+		{"grpcListenAddress", old.GRPCListenAddress, new.GRPCListenAddress},
+		{"grpcWebListenAddress", old.GRPCWebListenAddress, new.GRPCWebListenAddress},
+		{"dataDir", old.DataDir, new.DataDir},
+		{"grpc", old.GRPC, new.GRPC},
+		{"log rotation", old.Log.rotation(), new.Log.rotation()},
+		{"startup", old.Startup, new.Startup},
+		{"shutdown", old.Shutdown, new.Shutdown},
+		{"watchdog", old.Watchdog, new.Watchdog},
+		{"tls", old.TLS.Enabled(), new.TLS.Enabled()},
+	} {
+		if !reflect.DeepEqual(s.old, s.new) {
+			changed = append(changed, s.name)
+		}
+	}
+	return changed
+}
@@ -0,0 +1,198 @@
+package serverconfig
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    string
+		wantErr string
+	}{
+		{name: "empty file", data: ""},
+		{
+			name: "full config",
+			data: `
+listenAddress: 127.0.0.1:8080
+grpcListenAddress: 127.0.0.1:9010
+grpcWebListenAddress: 127.0.0.1:9011
+dataDir: /srv/anytype
+log:
+  level: info
+  maxSizeMB: 20
+  maxBackups: 3
+  maxAge: 72h
+grpc:
+  maxRecvMsgSize: 1048576
+  keepaliveTime: 30m
+tls:
+  certFile: cert.pem
+  keyFile: key.pem
+webhooks:
+  - url: https://example.com/hook
+    events: [server.started, autologin.failed]
+    secret: s3cret
+autoLogin:
+  enabled: false
+  attempts: 5
+  retryDelay: 10s
+  maxRetryDelay: 10m
+startup:
+  timeout: 10s
+  readyTimeout: 2m
+shutdown:
+  timeout: 45s
+watchdog:
+  interval: 15s
+  timeout: 5s
+  failures: 4
+`,
+		},
+		{name: "unknown key", data: "listenAdress: 127.0.0.1:8080\n", wantErr: "not found"},
+		{name: "invalid address", data: "listenAddress: localhost\n", wantErr: "invalid address"},
+		{name: "invalid log level", data: "log:\n  level: verbose\n", wantErr: "invalid log level"},
+		{name: "tls without key", data: "tls:\n  certFile: cert.pem\n", wantErr: "set together"},
+		{name: "webhook url", data: "webhooks:\n  - url: ftp://example.com\n", wantErr: "invalid url"},
+		{name: "webhook event", data: "webhooks:\n  - url: http://example.com\n    events: [login]\n", wantErr: "unknown event"},
+		{name: "negative log size", data: "log:\n  maxSizeMB: -1\n", wantErr: "rotation limits"},
+		{name: "negative log backups", data: "log:\n  maxBackups: -1\n", wantErr: "rotation limits"},
+		{name: "negative attempts", data: "autoLogin:\n  attempts: -1\n", wantErr: "attempts"},
+		{name: "negative watchdog failures", data: "watchdog:\n  failures: -1\n", wantErr: "watchdog"},
+		{name: "zero ready timeout", data: "startup:\n  readyTimeout: 0s\n", wantErr: "readyTimeout must be positive"},
+		{name: "zero shutdown timeout", data: "shutdown:\n  timeout: 0s\n", wantErr: "shutdown: timeout must be positive"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Parse([]byte(tt.data))
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("Parse failed: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("Parse error = %v, want error containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestParseValues(t *testing.T) {
+	cfg, err := Parse([]byte(`
+log:
+  level: warn
+grpc:
+  keepaliveTime: 30m
+autoLogin:
+  enabled: false
+`))
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+
+	if cfg.GRPC.KeepaliveTime == nil || *cfg.GRPC.KeepaliveTime != 30*time.Minute {
+		t.Errorf("grpc.keepaliveTime = %v, want 30m", cfg.GRPC.KeepaliveTime)
+	}
+	if cfg.GRPC.MaxRecvMsgSize != nil {
+		t.Errorf("grpc.maxRecvMsgSize = %v, want unset", *cfg.GRPC.MaxRecvMsgSize)
+	}
+	if cfg.AutoLogin.IsEnabled() {
+		t.Error("autoLogin should be disabled")
+	}
+	if got := cfg.AutoLogin.MaxAttempts(); got != 0 {
+		t.Errorf("MaxAttempts() = %d, want 0 (unlimited)", got)
+	}
+	if !cfg.Watchdog.IsEnabled() || cfg.Watchdog.CheckInterval() != DefaultWatchdogInterval || cfg.Watchdog.MaxFailures() != DefaultWatchdogFailures {
+		t.Errorf("watchdog = %+v, want enabled with the defaults", cfg.Watchdog)
+	}
+	if got := cfg.Watchdog.TripTime(); got != 2*time.Minute {
+		t.Errorf("watchdog trip time = %s, want 2m0s", got)
+	}
+	if got := cfg.Settings(); len(got) != 1 || got["logLevel"] != "warn" {
+		t.Errorf("Settings() = %v, want only logLevel", got)
+	}
+}
+
+func TestLoadResolvesRelativePaths(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "anytype.yaml")
+	data := "dataDir: data\ntls:\n  certFile: tls/cert.pem\n  keyFile: /etc/anytype/key.pem\n"
+	if err := os.WriteFile(path, []byte(data), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if want := filepath.Join(dir, "data"); cfg.DataDir != want {
+		t.Errorf("DataDir = %s, want %s", cfg.DataDir, want)
+	}
+	if want := filepath.Join(dir, "tls", "cert.pem"); cfg.TLS.CertFile != want {
+		t.Errorf("TLS.CertFile = %s, want %s", cfg.TLS.CertFile, want)
+	}
+	if cfg.TLS.KeyFile != "/etc/anytype/key.pem" {
+		t.Errorf("TLS.KeyFile = %s, want absolute path unchanged", cfg.TLS.KeyFile)
+	}
+}
+
+func TestLogMaxBackupsZero(t *testing.T) {
+	cfg, err := Parse([]byte("log:\n  maxBackups: 0\n"))
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	if cfg.Log.MaxBackups == nil || *cfg.Log.MaxBackups != 0 {
+		t.Errorf("maxBackups = %v, want an explicit 0", cfg.Log.MaxBackups)
+	}
+}
+
+func TestRestartRequired(t *testing.T) {
+	size := 1024
+	timeout := time.Minute
+	backups := 2
+	base := func() *Config {
+		return &Config{
+			ListenAddress: "127.0.0.1:8080",
+			Log:           Log{Level: "INFO"},
+			TLS:           TLS{CertFile: "a.pem", KeyFile: "a.key"},
+		}
+	}
+
+	tests := []struct {
+		name   string
+		change func(*Config)
+		want   []string
+	}{
+		{name: "unchanged", change: func(*Config) {}},
+		{name: "reloadable settings", change: func(c *Config) {
+			c.Log.Level = "DEBUG"
+			c.TLS = TLS{CertFile: "b.pem", KeyFile: "b.key"}
+			c.Webhooks = []Webhook{{URL: "http://example.com"}}
+			c.AutoLogin.Attempts = 10
+		}},
+		{name: "listen address", change: func(c *Config) { c.ListenAddress = "127.0.0.1:9090" }, want: []string{"listenAddress"}},
+		{name: "grpc limits", change: func(c *Config) { c.GRPC.MaxRecvMsgSize = &size }, want: []string{"grpc"}},
+		{name: "log rotation", change: func(c *Config) { c.Log.MaxBackups = &backups }, want: []string{"log rotation"}},
+		{name: "tls disabled", change: func(c *Config) { c.TLS = TLS{} }, want: []string{"tls"}},
+		{name: "startup timeout", change: func(c *Config) { c.Startup.ReadyTimeout = &timeout }, want: []string{"startup"}},
+		{name: "shutdown timeout", change: func(c *Config) { c.Shutdown.Timeout = &timeout }, want: []string{"shutdown"}},
+		{name: "watchdog", change: func(c *Config) { c.Watchdog.Failures = 5 }, want: []string{"watchdog"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := base()
+			tt.change(cfg)
+			if got := RestartRequired(base(), cfg); !slices.Equal(got, tt.want) {
+				t.Errorf("RestartRequired() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,136 @@
+package serverconfig
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/anyproto/anytype-cli/core/config"
+	"github.com/anyproto/anytype-cli/core/output"
+)
+
+// Server events delivered to webhooks.
+const (
+	EventStarted        = "server.started"
+	EventStopping       = "server.stopping"
+	EventReloaded       = "server.reloaded"
+	EventLoginSucceeded = "autologin.succeeded"
+	EventLoginFailed    = "autologin.failed"
+	EventWatchdog       = "watchdog.restart"
+)
+
+const (
+	// SignatureHeader carries the HMAC-SHA256 of the body for webhooks with a secret
+	SignatureHeader = "X-Anytype-Signature"
+
+	webhookRequestTimeout = 10 * time.Second
+)
+
+// Events lists the events a webhook can subscribe to.
+var Events = []string{EventStarted, EventStopping, EventReloaded, EventLoginSucceeded, EventLoginFailed, EventWatchdog}
+
+// Payload is the JSON body posted to webhooks.
+type Payload struct {
+	Event   string            `json:"event"`
+	Time    time.Time         `json:"time"`
+	Profile string            `json:"profile"`
+	Data    map[string]string `json:"data,omitempty"`
+}
+
+// Notifier delivers server events to the configured webhooks in the background.
+// Failed deliveries are logged and not retried.
+type Notifier struct {
+	mu     sync.RWMutex
+	hooks  []Webhook
+	client *http.Client
+	wg     sync.WaitGroup
+}
+
+func NewNotifier(hooks []Webhook) *Notifier {
+	return &Notifier{
+		hooks:  hooks,
+		client: &http.Client{Timeout: webhookRequestTimeout},
+	}
+}
+
+// SetWebhooks replaces the webhooks for subsequent events.
+func (n *Notifier) SetWebhooks(hooks []Webhook) {
+	n.mu.Lock()
+	n.hooks = hooks
+	n.mu.Unlock()
+}
+
+// Notify posts an event to every webhook subscribed to it. Webhooks without events receive all events.
+func (n *Notifier) Notify(event string, data map[string]string) {
+	n.mu.RLock()
+	hooks := n.hooks
+	n.mu.RUnlock()
+
+	body, err := json.Marshal(Payload{
+		Event:   event,
+		Time:    time.Now().UTC(),
+		Profile: config.ActiveProfile(),
+		Data:    data,
+	})
+	if err != nil {
+		output.Warning("failed to encode webhook payload: %v", err)
+		return
+	}
+
+	for _, hook := range hooks {
+		if len(hook.Events) > 0 && !slices.Contains(hook.Events, event) {
+			continue
+		}
+		n.wg.Add(1)
+		go func(hook Webhook) {
+			defer n.wg.Done()
+			if err := n.deliver(hook, body); err != nil {
+				output.Warning("webhook %s: %v", hook.URL, err)
+			}
+		}(hook)
+	}
+}
+
+func (n *Notifier) deliver(hook Webhook, body []byte) error {
+	ctx, cancel := context.WithTimeout(context.Background(), webhookRequestTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, hook.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if hook.Secret != "" {
+		req.Header.Set(SignatureHeader, Sign(hook.Secret, body))
+	}
+
+	resp, err := n.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("unexpected status %s", resp.Status)
+	}
+	return nil
+}
+
+// Sign returns the X-Anytype-Signature value for a body: "sha256=" followed by the hex HMAC.
+func Sign(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Wait blocks until all pending deliveries have finished.
+func (n *Notifier) Wait() {
+	n.wg.Wait()
+}
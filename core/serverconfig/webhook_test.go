@@ -0,0 +1,56 @@
+package serverconfig
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+)
+
+func TestNotifier(t *testing.T) {
+	var (
+		mu       sync.Mutex
+		received = make(map[string][]Payload)
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if r.URL.Path == "/signed" && r.Header.Get(SignatureHeader) != Sign("s3cret", body) {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		var payload Payload
+		if err := json.Unmarshal(body, &payload); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		mu.Lock()
+		received[r.URL.Path] = append(received[r.URL.Path], payload)
+		mu.Unlock()
+	}))
+	defer server.Close()
+
+	n := NewNotifier([]Webhook{
+		{URL: server.URL + "/all"},
+		{URL: server.URL + "/signed", Events: []string{EventLoginFailed}, Secret: "s3cret"},
+	})
+	n.Notify(EventStarted, map[string]string{"apiAddress": "127.0.0.1:31012"})
+	n.Notify(EventLoginFailed, nil)
+	n.Wait()
+
+	if got := len(received["/all"]); got != 2 {
+		t.Errorf("webhook without events received %d events, want 2", got)
+	}
+	signed := received["/signed"]
+	if len(signed) != 1 || signed[0].Event != EventLoginFailed {
+		t.Errorf("filtered webhook received %v, want only %s", signed, EventLoginFailed)
+	}
+
+	n.SetWebhooks(nil)
+	n.Notify(EventStopping, nil)
+	n.Wait()
+	if got := len(received["/all"]); got != 2 {
+		t.Errorf("removed webhook received %d events, want 2", got)
+	}
+}
@@ -0,0 +1,110 @@
+package serverstatus
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/anyproto/anytype-cli/core/config"
+)
+
+const (
+	// RestartsFileName is the restart history in the profile's config directory
+	RestartsFileName = "restarts.json"
+
+	// maxRestarts is the number of restarts kept in the history
+	maxRestarts = 20
+)
+
+// Restart records why a server process exited without being stopped.
+type Restart struct {
+	// PID is the process that exited
+	PID   int       `json:"pid"`
+	At    time.Time `json:"at"`
+	Cause string    `json:"cause"`
+	// Dumps are goroutine dumps written before the process exited
+	Dumps []string `json:"dumps,omitempty"`
+}
+
+// GetRestartsFilePath returns the restart history of the active profile.
+func GetRestartsFilePath() string {
+	return filepath.Join(config.GetConfigDir(), RestartsFileName)
+}
+
+// ReadRestarts returns the recorded restarts, oldest first. A missing history is empty.
+func ReadRestarts() ([]Restart, error) {
+	return readRestarts(GetRestartsFilePath())
+}
+
+func readRestarts(path string) ([]Restart, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	var restarts []Restart
+	if err := json.Unmarshal(data, &restarts); err != nil {
+		return nil, fmt.Errorf("failed to parse restart history: %w", err)
+	}
+	return restarts, nil
+}
+
+// RecordRestart appends r to the restart history, keeping the most recent entries. A restart
+// of a process that is already recorded is ignored.
+func RecordRestart(r Restart) error {
+	path := GetRestartsFilePath()
+	unlock, err := config.LockFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to lock restart history: %w", err)
+	}
+	defer unlock()
+
+	restarts, err := readRestarts(path)
+	if err != nil {
+		return err
+	}
+	if n := len(restarts); n > 0 && restarts[n-1].PID == r.PID {
+		return nil
+	}
+	if r.At.IsZero() {
+		r.At = time.Now().UTC().Truncate(time.Second)
+	}
+	restarts = append(restarts, r)
+	if len(restarts) > maxRestarts {
+		restarts = restarts[len(restarts)-maxRestarts:]
+	}
+
+	data, err := json.MarshalIndent(restarts, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal restart history: %w", err)
+	}
+	if err := config.WriteFileAtomic(path, data, 0600); err != nil {
+		return fmt.Errorf("failed to write restart history: %w", err)
+	}
+	return nil
+}
+
+// RecordUncleanExit records a restart if a previous server left its status file behind, which
+// means it exited without stopping: it crashed, was killed or restarted itself. Call it before
+// the new server writes its status.
+func RecordUncleanExit() error {
+	prev, err := Read()
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil
+		}
+		return err
+	}
+	if prev.PID == os.Getpid() {
+		return nil
+	}
+	return RecordRestart(Restart{
+		PID:   prev.PID,
+		Cause: "the previous server exited without shutting down (crashed or was killed)",
+	})
+}
@@ -0,0 +1,175 @@
+package serverstatus
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/anyproto/anytype-cli/core/config"
+)
+
+// FileName is the name of the status file in the profile's config directory
+const FileName = "server-status.json"
+
+// AuthState is the state of the server's account session.
+type AuthState string
+
+const (
+	// AuthIdle means no login was attempted, e.g. because no account key is stored
+	AuthIdle AuthState = "idle"
+	// AuthLoggingIn means a login attempt is in progress
+	AuthLoggingIn AuthState = "logging-in"
+	// AuthLoggedIn means the account is logged in
+	AuthLoggedIn AuthState = "logged-in"
+	// AuthFailed means the last attempt failed; RetryAt is set if another attempt is scheduled
+	AuthFailed AuthState = "failed"
+)
+
+// Auth describes the server's account session.
+type Auth struct {
+	State     AuthState `json:"state"`
+	AccountId string    `json:"accountId,omitempty"`
+	// Reason explains an idle or failed state
+	Reason   string    `json:"reason,omitempty"`
+	Attempts int       `json:"attempts,omitempty"`
+	RetryAt  time.Time `json:"retryAt,omitzero"`
+	Since    time.Time `json:"since"`
+}
+
+// Status is written by a running server so that other processes can see what it is doing.
+type Status struct {
+	PID            int       `json:"pid"`
+	Profile        string    `json:"profile"`
+	StartedAt      time.Time `json:"startedAt"`
+	GRPCAddress    string    `json:"grpcAddress"`
+	GRPCWebAddress string    `json:"grpcWebAddress"`
+	APIAddress     string    `json:"apiAddress,omitempty"`
+	CLIVersion     string    `json:"cliVersion,omitempty"`
+	HeartVersion   string    `json:"heartVersion,omitempty"`
+	Auth           Auth      `json:"auth"`
+	// LastLoginError is kept after a later login succeeds
+	LastLoginError *LoginError `json:"lastLoginError,omitempty"`
+}
+
+// LoginError records a failed login attempt.
+type LoginError struct {
+	Reason string    `json:"reason"`
+	At     time.Time `json:"at"`
+}
+
+// GetFilePath returns the status file of the active profile.
+func GetFilePath() string {
+	return filepath.Join(config.GetConfigDir(), FileName)
+}
+
+// Read returns the status written by the server. The error wraps os.ErrNotExist if no
+// server has written one. A server that exited abnormally may leave a stale file behind,
+// so callers should also check that the server is reachable.
+func Read() (*Status, error) {
+	return read(GetFilePath())
+}
+
+func read(path string) (*Status, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var s Status
+	if err := json.Unmarshal(data, &s); err != nil {
+		return nil, fmt.Errorf("failed to parse server status: %w", err)
+	}
+	return &s, nil
+}
+
+// Write replaces the status file.
+func Write(s *Status) error {
+	path := GetFilePath()
+	unlock, err := config.LockFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to lock server status: %w", err)
+	}
+	defer unlock()
+	return write(path, s)
+}
+
+func write(path string, s *Status) error {
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal server status: %w", err)
+	}
+	if err := config.WriteFileAtomic(path, data, 0600); err != nil {
+		return fmt.Errorf("failed to write server status: %w", err)
+	}
+	return nil
+}
+
+// SetAuth records a new auth state for the running server. Both the server and CLI commands
+// that log in or out through it call this. Without a status file, i.e. no server, it does nothing.
+func SetAuth(auth Auth) error {
+	path := GetFilePath()
+	unlock, err := config.LockFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to lock server status: %w", err)
+	}
+	defer unlock()
+
+	s, err := read(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil
+		}
+		return err
+	}
+	if auth.Since.IsZero() {
+		auth.Since = time.Now().UTC().Truncate(time.Second)
+	}
+	s.Auth = auth
+	if auth.State == AuthFailed {
+		s.LastLoginError = &LoginError{Reason: auth.Reason, At: auth.Since}
+	}
+	return write(path, s)
+}
+
+// Remove deletes the status file when the server stops.
+func Remove() error {
+	if err := os.Remove(GetFilePath()); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+// Describe returns a one-line description of an auth state for status output.
+func (a Auth) Describe() string {
+	switch a.State {
+	case AuthLoggedIn:
+		if a.AccountId != "" {
+			return "logged in to account " + a.AccountId
+		}
+		return "logged in"
+	case AuthLoggingIn:
+		if a.Attempts > 1 {
+			return fmt.Sprintf("logging in (attempt %d)", a.Attempts)
+		}
+		return "logging in"
+	case AuthFailed:
+		desc := "login failed"
+		if a.Attempts > 1 {
+			desc = fmt.Sprintf("login failed after %d attempts", a.Attempts)
+		}
+		if a.Reason != "" {
+			desc += ": " + a.Reason
+		}
+		if !a.RetryAt.IsZero() {
+			desc += fmt.Sprintf(" (next attempt at %s)", a.RetryAt.Local().Format("15:04:05"))
+		}
+		return desc
+	default:
+		if a.Reason != "" {
+			return "not logged in: " + a.Reason
+		}
+		return "not logged in"
+	}
+}
@@ -0,0 +1,107 @@
+package serverstatus
+
+import (
+	"errors"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSetAuth(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+
+	if err := SetAuth(Auth{State: AuthLoggedIn}); err != nil {
+		t.Fatalf("SetAuth without a server failed: %v", err)
+	}
+	if _, err := Read(); !errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("Read error = %v, want not exist", err)
+	}
+
+	if err := Write(&Status{PID: 42, GRPCAddress: "127.0.0.1:31010", Auth: Auth{State: AuthIdle}}); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+	if err := SetAuth(Auth{State: AuthFailed, Reason: "network unreachable"}); err != nil {
+		t.Fatalf("SetAuth failed: %v", err)
+	}
+	if err := SetAuth(Auth{State: AuthLoggedIn, AccountId: "account"}); err != nil {
+		t.Fatalf("SetAuth failed: %v", err)
+	}
+
+	s, err := Read()
+	if err != nil {
+		t.Fatalf("Read failed: %v", err)
+	}
+	if s.PID != 42 || s.Auth.State != AuthLoggedIn || s.Auth.AccountId != "account" || s.Auth.Since.IsZero() {
+		t.Errorf("status = %+v", s)
+	}
+	if s.LastLoginError == nil || s.LastLoginError.Reason != "network unreachable" {
+		t.Errorf("LastLoginError = %+v, want the earlier failure", s.LastLoginError)
+	}
+
+	if err := Remove(); err != nil {
+		t.Fatalf("Remove failed: %v", err)
+	}
+	if _, err := Read(); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("status file still exists after Remove: %v", err)
+	}
+}
+
+func TestAuthDescribe(t *testing.T) {
+	tests := []struct {
+		auth Auth
+		want string
+	}{
+		{Auth{State: AuthLoggedIn, AccountId: "abc"}, "logged in to account abc"},
+		{Auth{State: AuthLoggingIn, Attempts: 3}, "logging in (attempt 3)"},
+		{Auth{State: AuthFailed, Attempts: 4, Reason: "timeout", RetryAt: time.Now()}, "login failed after 4 attempts: timeout (next attempt at"},
+		{Auth{State: AuthIdle, Reason: "no stored account key"}, "not logged in: no stored account key"},
+	}
+	for _, tt := range tests {
+		if got := tt.auth.Describe(); !strings.HasPrefix(got, tt.want) {
+			t.Errorf("Describe(%+v) = %q, want prefix %q", tt.auth, got, tt.want)
+		}
+	}
+}
+
+func TestRecordRestart(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+
+	if err := RecordUncleanExit(); err != nil {
+		t.Fatalf("RecordUncleanExit without a previous server failed: %v", err)
+	}
+
+	// The watchdog records its own restart before exiting and leaves the status file behind
+	if err := Write(&Status{PID: 42}); err != nil {
+		t.Fatal(err)
+	}
+	if err := RecordRestart(Restart{PID: 42, Cause: "middleware did not answer"}); err != nil {
+		t.Fatalf("RecordRestart failed: %v", err)
+	}
+	if err := RecordUncleanExit(); err != nil {
+		t.Fatalf("RecordUncleanExit failed: %v", err)
+	}
+
+	if err := Write(&Status{PID: 43}); err != nil {
+		t.Fatal(err)
+	}
+	if err := RecordUncleanExit(); err != nil {
+		t.Fatalf("RecordUncleanExit failed: %v", err)
+	}
+
+	restarts, err := ReadRestarts()
+	if err != nil {
+		t.Fatalf("ReadRestarts failed: %v", err)
+	}
+	if len(restarts) != 2 || restarts[0].Cause != "middleware did not answer" || restarts[1].PID != 43 || restarts[1].At.IsZero() {
+		t.Errorf("restarts = %+v, want the watchdog restart and one unclean exit", restarts)
+	}
+
+	for pid := 100; pid < 130; pid++ {
+		RecordRestart(Restart{PID: pid, Cause: "crash"})
+	}
+	restarts, _ = ReadRestarts()
+	if len(restarts) != maxRestarts || restarts[len(restarts)-1].PID != 129 {
+		t.Errorf("history has %d entries ending with %+v, want the last %d", len(restarts), restarts[len(restarts)-1], maxRestarts)
+	}
+}
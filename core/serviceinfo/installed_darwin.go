@@ -0,0 +1,38 @@
+package serviceinfo
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// installedCommandLine reads ProgramArguments from the launchd user agent.
+func installedCommandLine(name string) ([]string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(filepath.Join(home, "Library", "LaunchAgents", name+".plist"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read launch agent: %w", err)
+	}
+	return parsePlist(data)
+}
+
+// installedServiceNames lists the launchd user agents named like an instance.
+func installedServiceNames() ([]string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return nil, err
+	}
+	matches, err := filepath.Glob(filepath.Join(home, "Library", "LaunchAgents", serviceNamePrefix+"*.plist"))
+	if err != nil {
+		return nil, err
+	}
+	var names []string
+	for _, m := range matches {
+		names = append(names, strings.TrimSuffix(filepath.Base(m), ".plist"))
+	}
+	return names, nil
+}
@@ -0,0 +1,38 @@
+package serviceinfo
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// installedCommandLine reads ExecStart= from the systemd user unit.
+func installedCommandLine(name string) ([]string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(filepath.Join(home, ".config", "systemd", "user", name+".service"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read service unit: %w", err)
+	}
+	return parseUnit(data)
+}
+
+// installedServiceNames lists the systemd user units named like an instance.
+func installedServiceNames() ([]string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return nil, err
+	}
+	matches, err := filepath.Glob(filepath.Join(home, ".config", "systemd", "user", serviceNamePrefix+"*.service"))
+	if err != nil {
+		return nil, err
+	}
+	var names []string
+	for _, m := range matches {
+		names = append(names, strings.TrimSuffix(filepath.Base(m), ".service"))
+	}
+	return names, nil
+}
@@ -0,0 +1,11 @@
+//go:build !linux && !darwin && !windows
+
+package serviceinfo
+
+func installedCommandLine(name string) ([]string, error) {
+	return nil, ErrUnsupported
+}
+
+func installedServiceNames() ([]string, error) {
+	return nil, ErrUnsupported
+}
@@ -0,0 +1,45 @@
+package serviceinfo
+
+import (
+	"fmt"
+	"strings"
+
+	"golang.org/x/sys/windows"
+	"golang.org/x/sys/windows/registry"
+)
+
+// installedCommandLine reads the service's ImagePath from the registry.
+func installedCommandLine(name string) ([]string, error) {
+	key, err := registry.OpenKey(registry.LOCAL_MACHINE, `SYSTEM\CurrentControlSet\Services\`+name, registry.QUERY_VALUE)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open service registry key: %w", err)
+	}
+	defer key.Close()
+
+	imagePath, _, err := key.GetStringValue("ImagePath")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read service command line: %w", err)
+	}
+	return windows.DecomposeCommandLine(imagePath)
+}
+
+// installedServiceNames lists the services in the registry named like an instance.
+func installedServiceNames() ([]string, error) {
+	key, err := registry.OpenKey(registry.LOCAL_MACHINE, `SYSTEM\CurrentControlSet\Services`, registry.ENUMERATE_SUB_KEYS)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open services registry key: %w", err)
+	}
+	defer key.Close()
+
+	subkeys, err := key.ReadSubKeyNames(-1)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list services: %w", err)
+	}
+	var names []string
+	for _, name := range subkeys {
+		if strings.HasPrefix(strings.ToLower(name), serviceNamePrefix) {
+			names = append(names, name)
+		}
+	}
+	return names, nil
+}
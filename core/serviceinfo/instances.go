@@ -0,0 +1,173 @@
+package serviceinfo
+
+import (
+	"fmt"
+	"net"
+	"sort"
+	"strings"
+
+	"github.com/anyproto/anytype-cli/core/config"
+	"github.com/anyproto/anytype-cli/core/serverconfig"
+)
+
+const (
+	// DefaultInstance is the service installed without --name, called "anytype"
+	DefaultInstance = "default"
+
+	serviceNamePrefix = "anytype"
+)
+
+// ServiceName returns the name the service manager knows an instance by: anytype for the
+// default instance and anytype-<instance> for named ones.
+func ServiceName(instance string) string {
+	if instance == "" || instance == DefaultInstance {
+		return serviceNamePrefix
+	}
+	return serviceNamePrefix + "-" + instance
+}
+
+// InstanceName is the inverse of ServiceName. It reports false for services that are not
+// anytype instances.
+func InstanceName(serviceName string) (string, bool) {
+	if serviceName == serviceNamePrefix {
+		return DefaultInstance, true
+	}
+	name, ok := strings.CutPrefix(serviceName, serviceNamePrefix+"-")
+	if !ok || name == DefaultInstance || config.ValidateProfileName(name) != nil {
+		return "", false
+	}
+	return name, true
+}
+
+// ValidateInstanceName checks that name can be used with --name. Named instances serve the
+// profile of the same name, so the profile naming rules apply.
+func ValidateInstanceName(name string) error {
+	if name == DefaultInstance {
+		return nil
+	}
+	if err := config.ValidateProfileName(name); err != nil {
+		return fmt.Errorf("invalid instance name %q: use lowercase letters, digits, '-' and '_'", name)
+	}
+	return nil
+}
+
+// Instance is an installed service as described by its command line.
+type Instance struct {
+	Name        string `json:"name"`
+	ServiceName string `json:"serviceName"`
+	Profile     string `json:"profile"`
+	ConfigFile  string `json:"configFile,omitempty"`
+	// The addresses given as flags, in the config file or in the profile, in that order
+	GRPCAddress    string `json:"grpcAddress"`
+	GRPCWebAddress string `json:"grpcWebAddress"`
+	APIAddress     string `json:"apiAddress"`
+}
+
+// Addresses returns the gRPC, gRPC-Web and API addresses.
+func (i Instance) Addresses() []string {
+	return []string{i.GRPCAddress, i.GRPCWebAddress, i.APIAddress}
+}
+
+// ListInstances returns the installed instances sorted by name, the default instance first.
+// Services whose command line cannot be read are listed with the profile and addresses unknown.
+func ListInstances() ([]Instance, error) {
+	names, err := installedServiceNames()
+	if err != nil {
+		return nil, err
+	}
+
+	var instances []Instance
+	for _, serviceName := range names {
+		name, ok := InstanceName(serviceName)
+		if !ok {
+			continue
+		}
+		args, err := InstalledArguments(serviceName)
+		if err != nil {
+			instances = append(instances, Instance{Name: name, ServiceName: serviceName})
+			continue
+		}
+		instances = append(instances, describeInstance(name, args))
+	}
+
+	sort.Slice(instances, func(i, j int) bool {
+		if instances[i].Name == DefaultInstance || instances[j].Name == DefaultInstance {
+			return instances[i].Name == DefaultInstance
+		}
+		return instances[i].Name < instances[j].Name
+	})
+	return instances, nil
+}
+
+// describeInstance resolves an instance's profile and addresses from its `serve` arguments.
+func describeInstance(name string, args []string) Instance {
+	inst := Instance{Name: name, ServiceName: ServiceName(name), Profile: config.DefaultProfile}
+	if profile, ok := FlagValue(args, "profile"); ok {
+		inst.Profile = profile
+	}
+
+	profile, _ := config.GetProfile(inst.Profile)
+	inst.GRPCAddress = firstNonEmpty(profile.GRPCAddress, config.DefaultGRPCAddress)
+	inst.GRPCWebAddress = firstNonEmpty(profile.GRPCWebAddress, config.DefaultGRPCWebAddress)
+	inst.APIAddress = firstNonEmpty(profile.APIAddress, config.DefaultAPIAddress)
+
+	if path, ok := FlagValue(args, "config"); ok {
+		inst.ConfigFile = path
+		if cfg, err := serverconfig.Load(path); err == nil {
+			inst.GRPCAddress = firstNonEmpty(cfg.GRPCListenAddress, inst.GRPCAddress)
+			inst.GRPCWebAddress = firstNonEmpty(cfg.GRPCWebListenAddress, inst.GRPCWebAddress)
+			inst.APIAddress = firstNonEmpty(cfg.ListenAddress, inst.APIAddress)
+		}
+	}
+
+	for flag, addr := range map[string]*string{
+		"grpc-listen-address":     &inst.GRPCAddress,
+		"grpc-web-listen-address": &inst.GRPCWebAddress,
+		"listen-address":          &inst.APIAddress,
+	} {
+		if value, ok := FlagValue(args, flag); ok {
+			*addr = value
+		}
+	}
+	return inst
+}
+
+// CheckPorts returns an error naming the first of addrs whose port is used by the desktop
+// app or by an instance other than skip. Instances with unknown addresses are ignored.
+func CheckPorts(addrs []string, instances []Instance, skip string) error {
+	desktop := map[string]bool{config.DesktopGRPCPort: true, config.DesktopGRPCWebPort: true, config.DesktopAPIPort: true}
+	owners := make(map[string]string)
+	for _, inst := range instances {
+		if inst.Name == skip {
+			continue
+		}
+		for _, addr := range inst.Addresses() {
+			if _, port, err := net.SplitHostPort(addr); err == nil {
+				owners[port] = inst.Name
+			}
+		}
+	}
+
+	for _, addr := range addrs {
+		_, port, err := net.SplitHostPort(addr)
+		if err != nil {
+			continue
+		}
+		if desktop[port] {
+			return fmt.Errorf("port %s of %s is reserved for the Anytype desktop app", port, addr)
+		}
+		if owner, ok := owners[port]; ok {
+			return fmt.Errorf("port %s of %s is already used by service instance %q", port, addr, owner)
+		}
+	}
+	return nil
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
@@ -0,0 +1,152 @@
+// Package serviceinfo inspects an installed service from the outside: the arguments it was
+// installed with and whether its ports accept connections.
+package serviceinfo
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/anyproto/anytype-cli/core/portcheck"
+)
+
+// ErrUnsupported is returned where the installed arguments cannot be read on this platform.
+var ErrUnsupported = errors.New("reading the installed service is not supported on this platform")
+
+// InstalledArguments returns the arguments the service called name was installed with,
+// without the executable path.
+func InstalledArguments(name string) ([]string, error) {
+	argv, err := installedCommandLine(name)
+	if err != nil {
+		return nil, err
+	}
+	if len(argv) == 0 {
+		return nil, fmt.Errorf("service %s has no command line", name)
+	}
+	return argv[1:], nil
+}
+
+// FlagValue returns the value of a long flag in args, given as --flag value or --flag=value.
+func FlagValue(args []string, flag string) (string, bool) {
+	prefix := "--" + flag
+	for i, arg := range args {
+		if arg == prefix && i+1 < len(args) {
+			return args[i+1], true
+		}
+		if value, ok := strings.CutPrefix(arg, prefix+"="); ok {
+			return value, true
+		}
+	}
+	return "", false
+}
+
+// parseExecStart splits a systemd ExecStart= value into arguments. Arguments are separated
+// by whitespace and may be double-quoted; \" and \xNN escapes are decoded.
+func parseExecStart(line string) ([]string, error) {
+	var args []string
+	var cur strings.Builder
+	inArg, quoted := false, false
+
+	for i := 0; i < len(line); i++ {
+		c := line[i]
+		switch {
+		case c == '\\' && i+1 < len(line):
+			i++
+			switch next := line[i]; next {
+			case 'x':
+				if i+2 >= len(line) {
+					return nil, fmt.Errorf("truncated escape in %q", line)
+				}
+				var b byte
+				if _, err := fmt.Sscanf(line[i+1:i+3], "%02x", &b); err != nil {
+					return nil, fmt.Errorf("invalid escape in %q", line)
+				}
+				cur.WriteByte(b)
+				i += 2
+			case 'n':
+				cur.WriteByte('\n')
+			case 't':
+				cur.WriteByte('\t')
+			default:
+				cur.WriteByte(next)
+			}
+			inArg = true
+		case c == '"':
+			quoted = !quoted
+			inArg = true
+		case (c == ' ' || c == '\t') && !quoted:
+			if inArg {
+				args = append(args, cur.String())
+				cur.Reset()
+				inArg = false
+			}
+		default:
+			cur.WriteByte(c)
+			inArg = true
+		}
+	}
+	if quoted {
+		return nil, fmt.Errorf("unterminated quote in %q", line)
+	}
+	if inArg {
+		args = append(args, cur.String())
+	}
+	return args, nil
+}
+
+// parseUnit returns the command line of the ExecStart= entry of a systemd unit.
+func parseUnit(data []byte) ([]string, error) {
+	for _, line := range strings.Split(string(data), "\n") {
+		if value, ok := strings.CutPrefix(strings.TrimSpace(line), "ExecStart="); ok {
+			return parseExecStart(value)
+		}
+	}
+	return nil, fmt.Errorf("no ExecStart= in unit file")
+}
+
+// parsePlist returns the ProgramArguments of a launchd property list.
+func parsePlist(data []byte) ([]string, error) {
+	dec := xml.NewDecoder(bytes.NewReader(data))
+	var lastKey string
+	for {
+		tok, err := dec.Token()
+		if err != nil {
+			return nil, fmt.Errorf("no ProgramArguments in property list: %w", err)
+		}
+		start, ok := tok.(xml.StartElement)
+		if !ok {
+			continue
+		}
+		switch start.Name.Local {
+		case "key":
+			if err := dec.DecodeElement(&lastKey, &start); err != nil {
+				return nil, err
+			}
+		case "array":
+			if lastKey != "ProgramArguments" {
+				continue
+			}
+			var arr struct {
+				Strings []string `xml:"string"`
+			}
+			if err := dec.DecodeElement(&arr, &start); err != nil {
+				return nil, err
+			}
+			return arr.Strings, nil
+		}
+	}
+}
+
+// PortOpen reports whether addr accepts TCP connections within timeout.
+func PortOpen(addr string, timeout time.Duration) bool {
+	conn, err := net.DialTimeout("tcp", portcheck.DialAddr(addr), timeout)
+	if err != nil {
+		return false
+	}
+	conn.Close()
+	return true
+}
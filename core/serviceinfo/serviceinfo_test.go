@@ -0,0 +1,184 @@
+package serviceinfo
+
+import (
+	"net"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/anyproto/anytype-cli/core/config"
+)
+
+func TestParseUnit(t *testing.T) {
+	unit := `[Unit]
+Description=Anytype
+
+[Service]
+Type=notify
+ExecStart=/home/me/my\x20bin/anytype "serve" "--profile" "work" "--config" "/etc/any \"type\".yaml"
+Restart=on-failure
+`
+	got, err := parseUnit([]byte(unit))
+	if err != nil {
+		t.Fatalf("parseUnit failed: %v", err)
+	}
+	want := []string{"/home/me/my bin/anytype", "serve", "--profile", "work", "--config", `/etc/any "type".yaml`}
+	if !slices.Equal(got, want) {
+		t.Errorf("parseUnit() = %q, want %q", got, want)
+	}
+
+	if _, err := parseUnit([]byte("[Service]\nType=simple\n")); err == nil {
+		t.Error("parseUnit should fail without ExecStart")
+	}
+	if _, err := parseExecStart(`/bin/anytype "serve`); err == nil {
+		t.Error("parseExecStart should fail on an unterminated quote")
+	}
+}
+
+func TestParsePlist(t *testing.T) {
+	plist := `<?xml version="1.0" encoding="UTF-8"?>
+<!DOCTYPE plist PUBLIC "-//Apple//DTD PLIST 1.0//EN" "http://www.apple.com/DTDs/PropertyList-1.0.dtd">
+<plist version="1.0">
+<dict>
+	<key>Label</key>
+	<string>anytype</string>
+	<key>ProgramArguments</key>
+	<array>
+		<string>/usr/local/bin/anytype</string>
+		<string>serve</string>
+		<string>--listen-address</string>
+		<string>127.0.0.1:8080</string>
+	</array>
+	<key>KeepAlive</key>
+	<true/>
+</dict>
+</plist>`
+	got, err := parsePlist([]byte(plist))
+	if err != nil {
+		t.Fatalf("parsePlist failed: %v", err)
+	}
+	want := []string{"/usr/local/bin/anytype", "serve", "--listen-address", "127.0.0.1:8080"}
+	if !slices.Equal(got, want) {
+		t.Errorf("parsePlist() = %q, want %q", got, want)
+	}
+}
+
+func TestFlagValue(t *testing.T) {
+	args := []string{"serve", "--profile", "work", "--listen-address=0.0.0.0:8080", "--config"}
+	tests := []struct {
+		flag   string
+		want   string
+		wantOK bool
+	}{
+		{flag: "profile", want: "work", wantOK: true},
+		{flag: "listen-address", want: "0.0.0.0:8080", wantOK: true},
+		{flag: "config"},
+		{flag: "grpc-listen-address"},
+	}
+	for _, tt := range tests {
+		got, ok := FlagValue(args, tt.flag)
+		if got != tt.want || ok != tt.wantOK {
+			t.Errorf("FlagValue(%q) = %q, %v; want %q, %v", tt.flag, got, ok, tt.want, tt.wantOK)
+		}
+	}
+}
+
+func TestPortOpen(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := ln.Addr().String()
+
+	if !PortOpen(addr, time.Second) {
+		t.Errorf("PortOpen(%s) = false for a listening port", addr)
+	}
+	ln.Close()
+	if PortOpen(addr, time.Second) {
+		t.Errorf("PortOpen(%s) = true after the listener closed", addr)
+	}
+}
+
+func TestInstanceNames(t *testing.T) {
+	tests := []struct {
+		instance, service string
+	}{
+		{DefaultInstance, "anytype"},
+		{"bot", "anytype-bot"},
+		{"team-2", "anytype-team-2"},
+	}
+	for _, tt := range tests {
+		if got := ServiceName(tt.instance); got != tt.service {
+			t.Errorf("ServiceName(%q) = %q, want %q", tt.instance, got, tt.service)
+		}
+		if got, ok := InstanceName(tt.service); !ok || got != tt.instance {
+			t.Errorf("InstanceName(%q) = %q, %v; want %q", tt.service, got, ok, tt.instance)
+		}
+	}
+
+	for _, name := range []string{"anytype-default", "anytypeX", "anytype-Bad", "other"} {
+		if got, ok := InstanceName(name); ok {
+			t.Errorf("InstanceName(%q) = %q, want not an instance", name, got)
+		}
+	}
+}
+
+func TestDescribeInstance(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv(config.ProfileEnvVar, "")
+
+	config.CreateProfile("bot", config.Profile{})
+	cfgPath := filepath.Join(t.TempDir(), "bot.yaml")
+	os.WriteFile(cfgPath, []byte("grpcWebListenAddress: 127.0.0.1:40001\n"), 0600)
+
+	inst := describeInstance("bot", []string{"serve", "--profile", "bot", "--config", cfgPath, "--listen-address", "0.0.0.0:40002"})
+	want := Instance{
+		Name:           "bot",
+		ServiceName:    "anytype-bot",
+		Profile:        "bot",
+		ConfigFile:     cfgPath,
+		GRPCAddress:    "127.0.0.1:31013",
+		GRPCWebAddress: "127.0.0.1:40001",
+		APIAddress:     "0.0.0.0:40002",
+	}
+	if inst != want {
+		t.Errorf("describeInstance() = %+v, want %+v", inst, want)
+	}
+
+	def := describeInstance(DefaultInstance, []string{"serve"})
+	if def.Profile != config.DefaultProfile || def.APIAddress != config.DefaultAPIAddress {
+		t.Errorf("describeInstance(default) = %+v", def)
+	}
+}
+
+func TestCheckPorts(t *testing.T) {
+	instances := []Instance{
+		{Name: DefaultInstance, GRPCAddress: "127.0.0.1:31010", GRPCWebAddress: "127.0.0.1:31011", APIAddress: "127.0.0.1:31012"},
+		{Name: "bot"},
+	}
+	tests := []struct {
+		name    string
+		addrs   []string
+		skip    string
+		wantErr string
+	}{
+		{name: "free", addrs: []string{"127.0.0.1:31013", "127.0.0.1:31014"}},
+		{name: "desktop", addrs: []string{"127.0.0.1:31008"}, wantErr: "desktop"},
+		{name: "other instance", addrs: []string{"0.0.0.0:31012"}, wantErr: `instance "default"`},
+		{name: "reinstall", addrs: []string{"127.0.0.1:31012"}, skip: DefaultInstance},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := CheckPorts(tt.addrs, instances, tt.skip)
+			if tt.wantErr == "" && err != nil {
+				t.Errorf("CheckPorts() = %v, want nil", err)
+			}
+			if tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)) {
+				t.Errorf("CheckPorts() = %v, want an error mentioning %s", err, tt.wantErr)
+			}
+		})
+	}
+}
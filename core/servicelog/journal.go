@@ -0,0 +1,118 @@
+package servicelog
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"runtime"
+	"strconv"
+	"time"
+)
+
+// JournalAvailable reports whether the system runs systemd, so that the service logs to its
+// journal, and the journal can be read with journalctl.
+func JournalAvailable() bool {
+	if runtime.GOOS != "linux" {
+		return false
+	}
+	// systemd creates this directory at boot; see sd_booted(3)
+	if fi, err := os.Stat("/run/systemd/system"); err != nil || !fi.IsDir() {
+		return false
+	}
+	_, err := exec.LookPath("journalctl")
+	return err == nil
+}
+
+// journalArgs returns the journalctl arguments for the user unit, newest n entries
+// (all with n <= 0) newer than since.
+func journalArgs(unit string, n int, since time.Time, follow bool) []string {
+	args := []string{"--user", "--unit", unit, "--output", "json", "--no-pager"}
+	if !since.IsZero() {
+		args = append(args, "--since", since.Local().Format(time.DateTime))
+	}
+	if n > 0 {
+		args = append(args, "--lines", strconv.Itoa(n))
+	} else {
+		args = append(args, "--lines", "all")
+	}
+	if follow {
+		args = append(args, "--follow")
+	}
+	return args
+}
+
+// ReadJournal passes the matching journal entries of the user unit to fn, oldest first. With
+// follow it keeps streaming new entries until ctx is done. Unlike Tail, n limits the entries
+// read from the journal before the level and pattern filters apply.
+func ReadJournal(ctx context.Context, unit string, n int, follow bool, filter Filter, fn func(Entry)) error {
+	cmd := exec.CommandContext(ctx, "journalctl", journalArgs(unit, n, filter.Since, follow)...)
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return err
+	}
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("failed to run journalctl: %w", err)
+	}
+
+	if err := readJournal(stdout, filter, fn); err != nil {
+		cmd.Process.Kill()
+		cmd.Wait()
+		return err
+	}
+	if err := cmd.Wait(); err != nil && ctx.Err() == nil {
+		return fmt.Errorf("journalctl failed: %w", err)
+	}
+	return nil
+}
+
+// journalRecord holds the journal fields used; see systemd.journal-fields(7).
+type journalRecord struct {
+	Message   json.RawMessage `json:"MESSAGE"`
+	Timestamp string          `json:"__REALTIME_TIMESTAMP"`
+}
+
+func readJournal(r io.Reader, filter Filter, fn func(Entry)) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		e, ok := parseJournalRecord(scanner.Bytes())
+		if ok && filter.Match(e) {
+			fn(e)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("failed to read journal: %w", err)
+	}
+	return nil
+}
+
+func parseJournalRecord(line []byte) (Entry, bool) {
+	var rec journalRecord
+	if err := json.Unmarshal(line, &rec); err != nil {
+		return Entry{}, false
+	}
+	usec, err := strconv.ParseInt(rec.Timestamp, 10, 64)
+	if err != nil {
+		return Entry{}, false
+	}
+
+	// MESSAGE is a string, or an array of bytes if it is not valid UTF-8
+	var message string
+	if err := json.Unmarshal(rec.Message, &message); err != nil {
+		var raw []byte
+		var ints []int
+		if err := json.Unmarshal(rec.Message, &ints); err != nil {
+			return Entry{}, false
+		}
+		for _, b := range ints {
+			raw = append(raw, byte(b))
+		}
+		message = string(raw)
+	}
+
+	return Entry{Time: time.UnixMicro(usec), Level: DetectLevel(message), Message: message}, true
+}
@@ -0,0 +1,274 @@
+package servicelog
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// Levels from least to most severe.
+var Levels = []string{"DEBUG", "INFO", "WARN", "ERROR", "FATAL"}
+
+// Entry is one line of service output.
+type Entry struct {
+	Time    time.Time `json:"time"`
+	Level   string    `json:"level"`
+	Message string    `json:"message"`
+}
+
+var (
+	levelWord = regexp.MustCompile(`\b(DEBUG|INFO|WARN|WARNING|ERROR|FATAL|PANIC|DPANIC)\b`)
+	levelJSON = regexp.MustCompile(`"level"\s*:\s*"(\w+)"`)
+)
+
+// DetectLevel guesses the level of a line of output: the middleware's log lines name their
+// level, the CLI marks warnings with ⚠, and everything else is INFO.
+func DetectLevel(line string) string {
+	level := ""
+	if m := levelJSON.FindStringSubmatch(line); m != nil {
+		level = strings.ToUpper(m[1])
+	} else if m := levelWord.FindString(line); m != "" {
+		level = m
+	} else if strings.HasPrefix(line, "⚠") {
+		level = "WARN"
+	} else if strings.HasPrefix(line, "Error:") || strings.HasPrefix(line, "panic:") {
+		level = "ERROR"
+	}
+
+	switch level {
+	case "WARNING":
+		return "WARN"
+	case "PANIC", "DPANIC":
+		return "FATAL"
+	case "DEBUG", "INFO", "WARN", "ERROR", "FATAL":
+		return level
+	default:
+		return "INFO"
+	}
+}
+
+// ParseLevel validates a --level value and returns it in canonical form.
+func ParseLevel(level string) (string, error) {
+	level = strings.ToUpper(level)
+	if level == "WARNING" {
+		level = "WARN"
+	}
+	if levelRank(level) < 0 {
+		return "", fmt.Errorf("invalid log level %q: expected one of %s", level, strings.Join(Levels, ", "))
+	}
+	return level, nil
+}
+
+func levelRank(level string) int {
+	for i, l := range Levels {
+		if l == level {
+			return i
+		}
+	}
+	return -1
+}
+
+func formatEntry(e Entry) string {
+	return fmt.Sprintf("%s %-5s %s", e.Time.UTC().Format(timeLayout), e.Level, e.Message)
+}
+
+// parseEntry decodes a line written by Writer.
+func parseEntry(line string) (Entry, bool) {
+	stamp, rest, ok := strings.Cut(line, " ")
+	if !ok {
+		return Entry{}, false
+	}
+	t, err := time.Parse(timeLayout, stamp)
+	if err != nil {
+		return Entry{}, false
+	}
+	// The level is padded to five characters
+	if len(rest) < 5 {
+		return Entry{}, false
+	}
+	level := strings.TrimSpace(rest[:5])
+	message := strings.TrimPrefix(rest[5:], " ")
+	return Entry{Time: t, Level: level, Message: message}, true
+}
+
+// Filter selects entries. Zero-valued fields match everything.
+type Filter struct {
+	Since time.Time
+	// Level is the least severe level shown
+	Level string
+	Grep  *regexp.Regexp
+}
+
+// Match reports whether the entry satisfies every set criterion.
+func (f Filter) Match(e Entry) bool {
+	if !f.Since.IsZero() && e.Time.Before(f.Since) {
+		return false
+	}
+	if f.Level != "" && levelRank(e.Level) < levelRank(f.Level) {
+		return false
+	}
+	if f.Grep != nil && !f.Grep.MatchString(e.Message) {
+		return false
+	}
+	return true
+}
+
+// ReadEntries decodes all entries from r that match the filter. Lines that were not
+// written by Writer are skipped.
+func ReadEntries(r io.Reader, filter Filter) ([]Entry, error) {
+	var entries []Entry
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		e, ok := parseEntry(scanner.Text())
+		if ok && filter.Match(e) {
+			entries = append(entries, e)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return entries, fmt.Errorf("failed to read service log: %w", err)
+	}
+	return entries, nil
+}
+
+// Tail returns the last n matching entries across the active log and its backups,
+// oldest first. A non-positive n returns every matching entry.
+func Tail(path string, n int, filter Filter) ([]Entry, error) {
+	paths := []string{path}
+	for i := 1; ; i++ {
+		p := backupPath(path, i)
+		if _, err := os.Stat(p); err != nil {
+			break
+		}
+		paths = append(paths, p)
+	}
+
+	var entries []Entry
+	for i := len(paths) - 1; i >= 0; i-- {
+		f, err := os.Open(paths[i])
+		if err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+			return nil, fmt.Errorf("failed to open service log: %w", err)
+		}
+		es, err := ReadEntries(f, filter)
+		f.Close()
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, es...)
+	}
+
+	if n > 0 && len(entries) > n {
+		entries = entries[len(entries)-n:]
+	}
+	return entries, nil
+}
+
+func firstEntryTime(path string) (time.Time, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return time.Time{}, err
+	}
+	defer f.Close()
+
+	line, err := bufio.NewReader(f).ReadString('\n')
+	if err != nil && line == "" {
+		return time.Time{}, err
+	}
+	e, ok := parseEntry(strings.TrimSuffix(line, "\n"))
+	if !ok {
+		return time.Time{}, fmt.Errorf("unrecognized log line")
+	}
+	return e.Time, nil
+}
+
+// Follow polls the active log for new entries and passes matching ones to fn until stop is closed.
+// It starts at the current end of the file and reopens it when rotation is detected.
+func Follow(path string, filter Filter, interval time.Duration, stop <-chan struct{}, fn func(Entry)) error {
+	f, err := os.Open(path)
+	if err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to open service log: %w", err)
+	}
+
+	var offset int64
+	if f != nil {
+		offset, _ = f.Seek(0, io.SeekEnd)
+	}
+	defer func() {
+		if f != nil {
+			f.Close()
+		}
+	}()
+
+	var pending []byte
+	buf := make([]byte, 32*1024)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-stop:
+			return nil
+		case <-ticker.C:
+		}
+
+		info, statErr := os.Stat(path)
+		if statErr != nil {
+			continue
+		}
+
+		rotated := f == nil
+		if f != nil {
+			if cur, err := f.Stat(); err != nil || !os.SameFile(cur, info) || info.Size() < offset {
+				rotated = true
+			}
+		}
+		if rotated {
+			if f != nil {
+				// Drain whatever was appended to the old file before it was rotated away
+				pending = readAvailable(f, buf, pending, filter, fn)
+				f.Close()
+			}
+			f, err = os.Open(path)
+			if err != nil {
+				f = nil
+				continue
+			}
+			offset = 0
+			pending = nil
+		}
+
+		pending = readAvailable(f, buf, pending, filter, fn)
+		offset, _ = f.Seek(0, io.SeekCurrent)
+	}
+}
+
+func readAvailable(f *os.File, buf, pending []byte, filter Filter, fn func(Entry)) []byte {
+	for {
+		n, err := f.Read(buf)
+		if n > 0 {
+			pending = append(pending, buf[:n]...)
+			for {
+				idx := bytes.IndexByte(pending, '\n')
+				if idx < 0 {
+					break
+				}
+				if e, ok := parseEntry(string(pending[:idx])); ok && filter.Match(e) {
+					fn(e)
+				}
+				pending = pending[idx+1:]
+			}
+		}
+		if err != nil || n == 0 {
+			return pending
+		}
+	}
+}
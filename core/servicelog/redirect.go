@@ -0,0 +1,21 @@
+package servicelog
+
+import "io"
+
+// drain copies r to w until r ends. A failed write does not stop it: nothing would read the
+// pipe any more, and once it is full every write to stdout and stderr would block. Chunks w
+// fails to take go to fallback, e.g. the original stderr, if there is one.
+func drain(r io.Reader, w io.Writer, fallback io.Writer) {
+	buf := make([]byte, 32*1024)
+	for {
+		n, err := r.Read(buf)
+		if n > 0 {
+			if _, werr := w.Write(buf[:n]); werr != nil && fallback != nil {
+				fallback.Write(buf[:n])
+			}
+		}
+		if err != nil {
+			return
+		}
+	}
+}
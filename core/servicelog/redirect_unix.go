@@ -0,0 +1,65 @@
+//go:build !windows
+
+package servicelog
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"golang.org/x/sys/unix"
+)
+
+// RedirectOutput sends everything the process writes to stdout and stderr, including output
+// of the middleware and its native libraries, to w. Output w fails to take goes to the
+// original stderr. restore undoes the redirect and waits until the remaining output was copied.
+func RedirectOutput(w io.Writer) (restore func(), err error) {
+	r, pw, err := os.Pipe()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create output pipe: %w", err)
+	}
+
+	saved := make([]int, 0, 2)
+	for _, fd := range []int{1, 2} {
+		dup, err := unix.Dup(fd)
+		if err == nil {
+			err = unix.Dup2(int(pw.Fd()), fd)
+		}
+		if err != nil {
+			for i, s := range saved {
+				unix.Dup2(s, i+1)
+				unix.Close(s)
+			}
+			r.Close()
+			pw.Close()
+			return nil, fmt.Errorf("failed to redirect output: %w", err)
+		}
+		saved = append(saved, dup)
+	}
+	// fds 1 and 2 now hold the write end
+	pw.Close()
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		drain(r, w, fdWriter(saved[1]))
+	}()
+
+	return func() {
+		for i, s := range saved {
+			unix.Dup2(s, i+1)
+		}
+		<-done
+		for _, s := range saved {
+			unix.Close(s)
+		}
+		r.Close()
+	}, nil
+}
+
+// fdWriter writes to a raw file descriptor.
+type fdWriter int
+
+func (fd fdWriter) Write(p []byte) (int, error) {
+	return unix.Write(int(fd), p)
+}
@@ -0,0 +1,45 @@
+package servicelog
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"golang.org/x/sys/windows"
+)
+
+// RedirectOutput sends everything the process writes to stdout and stderr to w. Writers that
+// copied os.Stdout or os.Stderr before the call keep their old handle. Output w fails to take
+// goes to the original stderr. restore undoes the redirect and waits until the remaining output
+// was copied.
+func RedirectOutput(w io.Writer) (restore func(), err error) {
+	r, pw, err := os.Pipe()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create output pipe: %w", err)
+	}
+
+	stdout, stderr := os.Stdout, os.Stderr
+	for _, h := range []uint32{windows.STD_OUTPUT_HANDLE, windows.STD_ERROR_HANDLE} {
+		if err := windows.SetStdHandle(h, windows.Handle(pw.Fd())); err != nil {
+			r.Close()
+			pw.Close()
+			return nil, fmt.Errorf("failed to redirect output: %w", err)
+		}
+	}
+	os.Stdout, os.Stderr = pw, pw
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		drain(r, w, stderr)
+	}()
+
+	return func() {
+		windows.SetStdHandle(windows.STD_OUTPUT_HANDLE, windows.Handle(stdout.Fd()))
+		windows.SetStdHandle(windows.STD_ERROR_HANDLE, windows.Handle(stderr.Fd()))
+		os.Stdout, os.Stderr = stdout, stderr
+		pw.Close()
+		<-done
+		r.Close()
+	}, nil
+}
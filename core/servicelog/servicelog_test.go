@@ -0,0 +1,289 @@
+package servicelog
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+func newTestWriter(t *testing.T, opts Options, now *time.Time) (*Writer, string) {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), FileName)
+	w, err := NewWriter(path, opts)
+	if err != nil {
+		t.Fatalf("NewWriter failed: %v", err)
+	}
+	w.now = func() time.Time { return *now }
+	w.created = *now
+	t.Cleanup(func() { w.Close() })
+	return w, path
+}
+
+func TestWriterStampsLines(t *testing.T) {
+	now := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	w, path := newTestWriter(t, DefaultOptions(), &now)
+
+	fmt.Fprint(w, "server started\n⚠ disk almost full\n2026-01-02T03:04:05Z\tERROR\tanytype-heart\tboom\npartial")
+	entries, err := Tail(path, 0, Filter{})
+	if err != nil {
+		t.Fatalf("Tail failed: %v", err)
+	}
+	var levels []string
+	for _, e := range entries {
+		levels = append(levels, e.Level)
+		if !e.Time.Equal(now) {
+			t.Errorf("entry time = %v, want %v", e.Time, now)
+		}
+	}
+	if want := []string{"INFO", "WARN", "ERROR"}; !slices.Equal(levels, want) {
+		t.Errorf("levels = %v, want %v", levels, want)
+	}
+	if entries[0].Message != "server started" {
+		t.Errorf("message = %q", entries[0].Message)
+	}
+
+	// The partial line is written on close
+	w.Close()
+	entries, _ = Tail(path, 1, Filter{})
+	if len(entries) != 1 || entries[0].Message != "partial" {
+		t.Errorf("last entry = %+v, want the partial line", entries)
+	}
+}
+
+func TestWriterRotatesBySize(t *testing.T) {
+	now := time.Now()
+	w, path := newTestWriter(t, Options{MaxSize: 200, MaxBackups: 2}, &now)
+
+	for i := 0; i < 20; i++ {
+		fmt.Fprintf(w, "line %02d with some padding to fill the file\n", i)
+	}
+
+	if _, err := os.Stat(backupPath(path, 2)); err != nil {
+		t.Errorf("backup 2 missing: %v", err)
+	}
+	if _, err := os.Stat(backupPath(path, 3)); !os.IsNotExist(err) {
+		t.Errorf("backup 3 should not exist: %v", err)
+	}
+	if info, _ := os.Stat(path); info.Size() > 200 {
+		t.Errorf("active log is %d bytes, want at most 200", info.Size())
+	}
+
+	entries, err := Tail(path, 0, Filter{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if last := entries[len(entries)-1].Message; !strings.HasPrefix(last, "line 19") {
+		t.Errorf("last entry = %q, want line 19", last)
+	}
+}
+
+func TestWriterRotatesByAge(t *testing.T) {
+	// File times are real, so the writer's clock ends up at the current time
+	now := time.Now().Add(-2 * time.Hour)
+	w, path := newTestWriter(t, Options{MaxBackups: 5, MaxAge: time.Hour}, &now)
+
+	fmt.Fprintln(w, "first")
+	now = time.Now()
+	fmt.Fprintln(w, "second")
+
+	backup, err := os.ReadFile(backupPath(path, 1))
+	if err != nil {
+		t.Fatalf("age rotation did not create a backup: %v", err)
+	}
+	if !bytes.Contains(backup, []byte("first")) {
+		t.Errorf("backup = %q, want the first line", backup)
+	}
+
+	// Rotated files older than MaxAge are removed on the next rotation
+	old := now.Add(-3 * time.Hour)
+	os.Chtimes(backupPath(path, 1), old, old)
+	now = now.Add(2 * time.Hour)
+	fmt.Fprintln(w, "third")
+	if _, err := os.Stat(backupPath(path, 2)); !os.IsNotExist(err) {
+		t.Errorf("expired backup was kept: %v", err)
+	}
+}
+
+func TestFilter(t *testing.T) {
+	now := time.Now()
+	entries := []Entry{
+		{Time: now.Add(-2 * time.Hour), Level: "ERROR", Message: "old failure"},
+		{Time: now, Level: "INFO", Message: "login succeeded"},
+		{Time: now, Level: "WARN", Message: "login retry"},
+		{Time: now, Level: "ERROR", Message: "sync failed"},
+	}
+	tests := []struct {
+		name   string
+		filter Filter
+		want   []string
+	}{
+		{name: "all", want: []string{"old failure", "login succeeded", "login retry", "sync failed"}},
+		{name: "since", filter: Filter{Since: now.Add(-time.Hour)}, want: []string{"login succeeded", "login retry", "sync failed"}},
+		{name: "level", filter: Filter{Level: "WARN"}, want: []string{"old failure", "login retry", "sync failed"}},
+		{name: "grep", filter: Filter{Grep: regexp.MustCompile("^login")}, want: []string{"login succeeded", "login retry"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			for _, e := range entries {
+				if tt.filter.Match(e) {
+					got = append(got, e.Message)
+				}
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("matched %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDetectLevel(t *testing.T) {
+	tests := map[string]string{
+		"Server ready in 1.2s":                         "INFO",
+		`{"level":"warn","msg":"slow"}`:                "WARN",
+		"2026-01-02T03:04:05Z\tDEBUG\tcore\tdetails":   "DEBUG",
+		"⚠ Failed to notify systemd":                   "WARN",
+		"Error: failed to listen on 127.0.0.1:31010":   "ERROR",
+		"goroutine PANIC in handler":                   "FATAL",
+		"informational message with lowercase warning": "INFO",
+	}
+	for line, want := range tests {
+		if got := DetectLevel(line); got != want {
+			t.Errorf("DetectLevel(%q) = %s, want %s", line, got, want)
+		}
+	}
+}
+
+func TestParseJournalRecord(t *testing.T) {
+	e, ok := parseJournalRecord([]byte(`{"MESSAGE":"⚠ login failed","__REALTIME_TIMESTAMP":"1767323045000000"}`))
+	if !ok || e.Level != "WARN" || e.Message != "⚠ login failed" || e.Time.Unix() != 1767323045 {
+		t.Errorf("parseJournalRecord() = %+v, %v", e, ok)
+	}
+
+	e, ok = parseJournalRecord([]byte(`{"MESSAGE":[104,105],"__REALTIME_TIMESTAMP":"1"}`))
+	if !ok || e.Message != "hi" {
+		t.Errorf("binary message = %+v, %v; want hi", e, ok)
+	}
+}
+
+func TestJournalArgs(t *testing.T) {
+	got := strings.Join(journalArgs("anytype.service", 0, time.Time{}, true), " ")
+	want := "--user --unit anytype.service --output json --no-pager --lines all --follow"
+	if got != want {
+		t.Errorf("journalArgs() = %q, want %q", got, want)
+	}
+}
+
+func TestRedirectOutput(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("fd redirection is tested on unix")
+	}
+	var buf bytes.Buffer
+	restore, err := RedirectOutput(&buf)
+	if err != nil {
+		t.Fatalf("RedirectOutput failed: %v", err)
+	}
+	fmt.Fprintln(os.Stdout, "to stdout")
+	fmt.Fprintln(os.Stderr, "to stderr")
+	restore()
+
+	if got := buf.String(); got != "to stdout\nto stderr\n" {
+		t.Errorf("captured %q", got)
+	}
+}
+
+type failingWriter struct{}
+
+func (failingWriter) Write([]byte) (int, error) { return 0, errors.New("disk full") }
+
+func TestDrainSurvivesWriteErrors(t *testing.T) {
+	var fallback bytes.Buffer
+	drain(strings.NewReader("lost\n"), failingWriter{}, &fallback)
+	if fallback.String() != "lost\n" {
+		t.Errorf("fallback got %q, want the output the writer refused", fallback.String())
+	}
+}
+
+func TestWriterKeepsLoggingWhenRotationFails(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("fd redirection is tested on unix")
+	}
+	now := time.Now()
+	w, path := newTestWriter(t, Options{MaxSize: 1024, MaxBackups: 1}, &now)
+	// A non-empty directory in place of the backup makes every rotation fail
+	if err := os.MkdirAll(filepath.Join(backupPath(path, 1), "blocked"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	restore, err := RedirectOutput(w)
+	if err != nil {
+		t.Fatalf("RedirectOutput failed: %v", err)
+	}
+	// Far more than a pipe buffer, which would block the writes if nothing drained it
+	written := make(chan struct{})
+	go func() {
+		defer close(written)
+		for i := 0; i < 2000; i++ {
+			fmt.Fprintf(os.Stdout, "line %04d with some padding to fill the pipe\n", i)
+		}
+	}()
+	select {
+	case <-written:
+	case <-time.After(10 * time.Second):
+		restore()
+		t.Fatal("writes to stdout blocked after a failed rotation")
+	}
+	restore()
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), "line 1999") {
+		t.Error("the active log does not contain the last line")
+	}
+	if !strings.Contains(string(data), "failed to rotate service log") {
+		t.Error("the active log does not report the failed rotation")
+	}
+}
+
+func TestWriterCapturesCrashes(t *testing.T) {
+	if path := os.Getenv("SERVICELOG_CRASH_TEST"); path != "" {
+		// Child process: log through a redirect like the service does, then panic
+		w, err := NewWriter(path, DefaultOptions())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := RedirectOutput(w); err != nil {
+			t.Fatal(err)
+		}
+		if err := w.CaptureCrashes(); err != nil {
+			t.Fatal(err)
+		}
+		panic("service crashed")
+	}
+
+	path := filepath.Join(t.TempDir(), FileName)
+	cmd := exec.Command(os.Args[0], "-test.run=^TestWriterCapturesCrashes$")
+	cmd.Env = append(os.Environ(), "SERVICELOG_CRASH_TEST="+path)
+	if err := cmd.Run(); err == nil {
+		t.Fatal("child process should have crashed")
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("failed to read log: %v", err)
+	}
+	if !strings.Contains(string(data), "panic: service crashed") {
+		t.Errorf("log does not contain the panic:\n%s", data)
+	}
+}
@@ -0,0 +1,267 @@
+// Package servicelog writes the server's output to a rotating log file when it runs as a
+// service, and reads it back, or the systemd journal, for `service logs`.
+package servicelog
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime/debug"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/anyproto/anytype-cli/core/config"
+)
+
+const (
+	// FileName is the name of the active service log inside the logs directory
+	FileName = "anytype.log"
+
+	DefaultMaxSize    = 10 * 1024 * 1024
+	DefaultMaxBackups = 5
+	DefaultMaxAge     = 7 * 24 * time.Hour
+
+	// timeLayout prefixes every line; fixed width keeps the files easy to scan
+	timeLayout = "2006-01-02T15:04:05.000Z07:00"
+)
+
+// GetFilePath returns the path of the active service log.
+func GetFilePath() string {
+	return filepath.Join(config.GetLogsDir(), FileName)
+}
+
+// Options control when the log is rotated and how many rotated files are kept.
+type Options struct {
+	// MaxSize rotates the active file once it would grow past this many bytes
+	MaxSize int64
+	// MaxBackups is the number of rotated files kept as <name>.1 (newest) to <name>.<MaxBackups>
+	MaxBackups int
+	// MaxAge rotates the active file once it is this old and deletes rotated files older than this
+	MaxAge time.Duration
+}
+
+// DefaultOptions returns the default rotation limits.
+func DefaultOptions() Options {
+	return Options{MaxSize: DefaultMaxSize, MaxBackups: DefaultMaxBackups, MaxAge: DefaultMaxAge}
+}
+
+// Writer stamps each line written to it with the time and detected level and appends it
+// to the log file, rotating by size and age.
+type Writer struct {
+	mu      sync.Mutex
+	path    string
+	opts    Options
+	file    *os.File
+	size    int64
+	created time.Time
+	pending []byte
+	now     func() time.Time
+	// crashes is set once the runtime writes fatal errors to the active file
+	crashes bool
+	// closed is set by Close; until then a file that failed to open is opened again on Write
+	closed bool
+}
+
+// NewWriter opens (or creates) the log at path.
+func NewWriter(path string, opts Options) (*Writer, error) {
+	def := DefaultOptions()
+	if opts.MaxSize <= 0 {
+		opts.MaxSize = def.MaxSize
+	}
+	if opts.MaxBackups < 0 {
+		opts.MaxBackups = 0
+	}
+	if opts.MaxAge <= 0 {
+		opts.MaxAge = def.MaxAge
+	}
+	w := &Writer{path: path, opts: opts, now: time.Now}
+	if err := w.open(); err != nil {
+		return nil, err
+	}
+	return w, nil
+}
+
+func (w *Writer) open() error {
+	if err := os.MkdirAll(filepath.Dir(w.path), 0755); err != nil {
+		return fmt.Errorf("failed to create log directory: %w", err)
+	}
+	f, err := os.OpenFile(w.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		return fmt.Errorf("failed to open service log: %w", err)
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return fmt.Errorf("failed to stat service log: %w", err)
+	}
+	w.file = f
+	if w.crashes {
+		// The runtime keeps its own descriptor, which would still point at the rotated file
+		if err := debug.SetCrashOutput(f, debug.CrashOptions{}); err != nil {
+			fmt.Fprintf(os.Stderr, "failed to move crash output to the new service log: %v\n", err)
+		}
+	}
+	w.size = info.Size()
+	w.created = w.now()
+	if w.size > 0 {
+		// The first line tells when an existing file was started
+		if first, err := firstEntryTime(w.path); err == nil {
+			w.created = first
+		}
+	}
+	return nil
+}
+
+// CaptureCrashes makes the runtime write fatal errors, such as unhandled panics, straight to
+// the log file as well. Output redirected to the writer passes through a goroutine, which
+// dies with the process before it could copy the panic.
+func (w *Writer) CaptureCrashes() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.file == nil {
+		return fmt.Errorf("service log is closed")
+	}
+	if err := debug.SetCrashOutput(w.file, debug.CrashOptions{}); err != nil {
+		return fmt.Errorf("failed to write crash output to the service log: %w", err)
+	}
+	w.crashes = true
+	return nil
+}
+
+// Write splits p into lines and appends each complete line to the log. A trailing partial
+// line is kept until the rest arrives.
+func (w *Writer) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.closed {
+		return 0, fmt.Errorf("service log is closed")
+	}
+	if w.file == nil {
+		if err := w.open(); err != nil {
+			return 0, err
+		}
+	}
+
+	w.pending = append(w.pending, p...)
+	for {
+		idx := bytes.IndexByte(w.pending, '\n')
+		if idx < 0 {
+			break
+		}
+		line := strings.TrimRight(string(w.pending[:idx]), "\r")
+		w.pending = w.pending[idx+1:]
+		if err := w.writeLine(line); err != nil {
+			return len(p), err
+		}
+	}
+	return len(p), nil
+}
+
+func (w *Writer) writeLine(line string) error {
+	now := w.now()
+	entry := []byte(formatEntry(Entry{Time: now, Level: DetectLevel(line), Message: line}) + "\n")
+
+	if w.size > 0 && (w.size+int64(len(entry)) > w.opts.MaxSize || now.Sub(w.created) >= w.opts.MaxAge) {
+		if err := w.rotate(); err != nil {
+			if w.file == nil {
+				return err
+			}
+			// Keep appending to the active file and try again once it has grown by another
+			// MaxSize or aged by another MaxAge, rather than on every line
+			w.size, w.created = 0, now
+			warning := formatEntry(Entry{Time: now, Level: "WARN", Message: err.Error()}) + "\n"
+			if _, err := w.file.WriteString(warning); err == nil {
+				w.size += int64(len(warning))
+			}
+		}
+	}
+
+	n, err := w.file.Write(entry)
+	w.size += int64(n)
+	if err != nil {
+		return fmt.Errorf("failed to write service log: %w", err)
+	}
+	return nil
+}
+
+// rotate moves the active file to the first backup and opens a new one. If moving fails, the
+// active file is opened again, so that the writer keeps logging to it; file is only left nil
+// when no file can be opened.
+func (w *Writer) rotate() error {
+	closeErr := w.file.Close()
+	w.file = nil
+
+	err := w.shiftBackups()
+	w.removeExpired()
+	if openErr := w.open(); openErr != nil {
+		return openErr
+	}
+	if err != nil {
+		return err
+	}
+	if closeErr != nil {
+		return fmt.Errorf("failed to close service log: %w", closeErr)
+	}
+	return nil
+}
+
+// shiftBackups renames the active file to <name>.1 and older backups to the next number, or
+// removes the active file if no backups are kept.
+func (w *Writer) shiftBackups() error {
+	if w.opts.MaxBackups == 0 {
+		if err := os.Remove(w.path); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to remove service log: %w", err)
+		}
+		return nil
+	}
+	_ = os.Remove(backupPath(w.path, w.opts.MaxBackups))
+	for i := w.opts.MaxBackups - 1; i >= 1; i-- {
+		if err := os.Rename(backupPath(w.path, i), backupPath(w.path, i+1)); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to rotate service log: %w", err)
+		}
+	}
+	if err := os.Rename(w.path, backupPath(w.path, 1)); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to rotate service log: %w", err)
+	}
+	return nil
+}
+
+// removeExpired deletes rotated files that were last written more than MaxAge ago.
+func (w *Writer) removeExpired() {
+	matches, _ := filepath.Glob(w.path + ".*")
+	for _, p := range matches {
+		if info, err := os.Stat(p); err == nil && w.now().Sub(info.ModTime()) > w.opts.MaxAge {
+			os.Remove(p)
+		}
+	}
+}
+
+// Close writes any partial line and closes the file.
+func (w *Writer) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.closed = true
+	if w.file == nil {
+		return nil
+	}
+	if len(w.pending) > 0 {
+		_ = w.writeLine(string(w.pending))
+		w.pending = nil
+	}
+	if w.crashes {
+		debug.SetCrashOutput(nil, debug.CrashOptions{})
+		w.crashes = false
+	}
+	err := w.file.Close()
+	w.file = nil
+	return err
+}
+
+func backupPath(path string, n int) string {
+	return fmt.Sprintf("%s.%d", path, n)
+}
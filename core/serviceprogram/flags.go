@@ -0,0 +1,155 @@
+package serviceprogram
+
+import (
+	"strconv"
+
+	"github.com/spf13/pflag"
+
+	"github.com/anyproto/anytype-cli/core/grpcserver"
+	"github.com/anyproto/anytype-cli/core/serverconfig"
+)
+
+const (
+	flagMaxRecvMsgSize               = "grpc-max-recv-msg-size"
+	flagMaxSendMsgSize               = "grpc-max-send-msg-size"
+	flagMaxConcurrentStreams         = "grpc-max-concurrent-streams"
+	flagKeepaliveTime                = "grpc-keepalive-time"
+	flagKeepaliveTimeout             = "grpc-keepalive-timeout"
+	flagKeepaliveMinTime             = "grpc-keepalive-min-time"
+	flagKeepalivePermitWithoutStream = "grpc-keepalive-permit-without-stream"
+	flagMaxConnectionIdle            = "grpc-max-connection-idle"
+	flagWebIdleTimeout               = "grpc-web-idle-timeout"
+
+	flagStartTimeout    = "start-timeout"
+	flagReadyTimeout    = "ready-timeout"
+	flagShutdownTimeout = "shutdown-timeout"
+)
+
+// AddServerOptionFlags registers the gRPC server limit flags shared by `serve` and `service install`.
+func AddServerOptionFlags(fs *pflag.FlagSet, opts *grpcserver.Options) {
+	def := grpcserver.DefaultOptions()
+
+	fs.IntVar(&opts.MaxRecvMsgSize, flagMaxRecvMsgSize, def.MaxRecvMsgSize, "Maximum gRPC message size the server accepts, in `bytes`")
+	fs.IntVar(&opts.MaxSendMsgSize, flagMaxSendMsgSize, def.MaxSendMsgSize, "Maximum gRPC message size the server sends, in `bytes` (0 for no limit)")
+	fs.Uint32Var(&opts.MaxConcurrentStreams, flagMaxConcurrentStreams, def.MaxConcurrentStreams, "Maximum concurrent streams per gRPC connection (0 for no limit)")
+	fs.DurationVar(&opts.KeepaliveTime, flagKeepaliveTime, def.KeepaliveTime, "Idle time after which the server pings a gRPC client")
+	fs.DurationVar(&opts.KeepaliveTimeout, flagKeepaliveTimeout, def.KeepaliveTimeout, "Time to wait for a keepalive ping acknowledgement before closing the connection")
+	fs.DurationVar(&opts.KeepaliveMinTime, flagKeepaliveMinTime, def.KeepaliveMinTime, "Minimum interval allowed between client keepalive pings")
+	fs.BoolVar(&opts.KeepalivePermitWithoutStream, flagKeepalivePermitWithoutStream, def.KeepalivePermitWithoutStream, "Allow client keepalive pings when there are no active streams")
+	fs.DurationVar(&opts.MaxConnectionIdle, flagMaxConnectionIdle, def.MaxConnectionIdle, "Close gRPC connections idle for longer than this (0 for no limit)")
+	fs.DurationVar(&opts.WebIdleTimeout, flagWebIdleTimeout, def.WebIdleTimeout, "Close idle gRPC-Web keep-alive connections after this long (0 for no limit)")
+}
+
+// AddStartupFlags registers the startup and shutdown timeout flags shared by `serve` and `service install`.
+func AddStartupFlags(fs *pflag.FlagSet, opts *StartupOptions) {
+	def := DefaultStartupOptions()
+
+	fs.DurationVar(&opts.StartTimeout, flagStartTimeout, def.StartTimeout, "Time allowed for the server to open its listeners")
+	fs.DurationVar(&opts.ReadyTimeout, flagReadyTimeout, def.ReadyTimeout, "Time allowed for the middleware and API to answer after the listeners are open")
+	fs.DurationVar(&opts.ShutdownTimeout, flagShutdownTimeout, def.ShutdownTimeout, "Time allowed on stop for in-flight requests to finish and the middleware to stop")
+}
+
+// AddInstallFlags registers the service manager flags of `service install`.
+func AddInstallFlags(fs *pflag.FlagSet, opts *InstallOptions) {
+	def := DefaultInstallOptions()
+
+	fs.StringArrayVar(&opts.Env, "env", nil, "Set an environment variable of the service as `KEY=VALUE` (repeatable), e.g. DATA_PATH or ANYTYPE_LOG_LEVEL")
+	fs.StringVar(&opts.WorkingDir, "working-dir", "", "Working `directory` of the service (absolute path)")
+	fs.StringVar(&opts.Restart, "restart", def.Restart, "When the service manager restarts the server: on-failure, always or never")
+	fs.DurationVar(&opts.RestartDelay, "restart-delay", def.RestartDelay, "Time to wait before restarting the server")
+	fs.StringVar(&opts.MemoryMax, "memory-max", "", "Hard memory limit, e.g. 1G or 50% (systemd only)")
+	fs.StringVar(&opts.MemoryHigh, "memory-high", "", "Memory use above which the server is throttled, e.g. 768M (systemd only)")
+	fs.BoolVar(&opts.Hardening, "hardening", false, "Add systemd sandboxing directives to the unit (systemd only)")
+}
+
+// ApplyStartupConfig copies the timeouts set in a server config file into opts, except those given as flags in fs.
+func ApplyStartupConfig(fs *pflag.FlagSet, opts *StartupOptions, cfg serverconfig.Startup) {
+	if cfg.Timeout != nil && !fs.Changed(flagStartTimeout) {
+		opts.StartTimeout = *cfg.Timeout
+	}
+	if cfg.ReadyTimeout != nil && !fs.Changed(flagReadyTimeout) {
+		opts.ReadyTimeout = *cfg.ReadyTimeout
+	}
+}
+
+// ApplyShutdownConfig copies the shutdown timeout set in a server config file into opts, unless it was given as a flag in fs.
+func ApplyShutdownConfig(fs *pflag.FlagSet, opts *StartupOptions, cfg serverconfig.Shutdown) {
+	if cfg.Timeout != nil && !fs.Changed(flagShutdownTimeout) {
+		opts.ShutdownTimeout = *cfg.Timeout
+	}
+}
+
+// ApplyGRPCConfig copies the limits set in a server config file into opts, except those given as flags in fs.
+func ApplyGRPCConfig(fs *pflag.FlagSet, opts *grpcserver.Options, cfg serverconfig.GRPC) {
+	set := func(flag string, ok bool, apply func()) {
+		if ok && !fs.Changed(flag) {
+			apply()
+		}
+	}
+
+	set(flagMaxRecvMsgSize, cfg.MaxRecvMsgSize != nil, func() { opts.MaxRecvMsgSize = *cfg.MaxRecvMsgSize })
+	set(flagMaxSendMsgSize, cfg.MaxSendMsgSize != nil, func() { opts.MaxSendMsgSize = *cfg.MaxSendMsgSize })
+	set(flagMaxConcurrentStreams, cfg.MaxConcurrentStreams != nil, func() { opts.MaxConcurrentStreams = *cfg.MaxConcurrentStreams })
+	set(flagKeepaliveTime, cfg.KeepaliveTime != nil, func() { opts.KeepaliveTime = *cfg.KeepaliveTime })
+	set(flagKeepaliveTimeout, cfg.KeepaliveTimeout != nil, func() { opts.KeepaliveTimeout = *cfg.KeepaliveTimeout })
+	set(flagKeepaliveMinTime, cfg.KeepaliveMinTime != nil, func() { opts.KeepaliveMinTime = *cfg.KeepaliveMinTime })
+	set(flagKeepalivePermitWithoutStream, cfg.KeepalivePermitWithoutStream != nil, func() {
+		opts.KeepalivePermitWithoutStream = *cfg.KeepalivePermitWithoutStream
+	})
+	set(flagMaxConnectionIdle, cfg.MaxConnectionIdle != nil, func() { opts.MaxConnectionIdle = *cfg.MaxConnectionIdle })
+	set(flagWebIdleTimeout, cfg.WebIdleTimeout != nil, func() { opts.WebIdleTimeout = *cfg.WebIdleTimeout })
+}
+
+// serverOptionArgs renders the options that differ from the defaults as `serve` arguments.
+func serverOptionArgs(opts grpcserver.Options) []string {
+	def := grpcserver.DefaultOptions()
+	var args []string
+
+	if opts.MaxRecvMsgSize != def.MaxRecvMsgSize {
+		args = append(args, "--"+flagMaxRecvMsgSize, strconv.Itoa(opts.MaxRecvMsgSize))
+	}
+	if opts.MaxSendMsgSize != def.MaxSendMsgSize {
+		args = append(args, "--"+flagMaxSendMsgSize, strconv.Itoa(opts.MaxSendMsgSize))
+	}
+	if opts.MaxConcurrentStreams != def.MaxConcurrentStreams {
+		args = append(args, "--"+flagMaxConcurrentStreams, strconv.FormatUint(uint64(opts.MaxConcurrentStreams), 10))
+	}
+	if opts.KeepaliveTime != def.KeepaliveTime {
+		args = append(args, "--"+flagKeepaliveTime, opts.KeepaliveTime.String())
+	}
+	if opts.KeepaliveTimeout != def.KeepaliveTimeout {
+		args = append(args, "--"+flagKeepaliveTimeout, opts.KeepaliveTimeout.String())
+	}
+	if opts.KeepaliveMinTime != def.KeepaliveMinTime {
+		args = append(args, "--"+flagKeepaliveMinTime, opts.KeepaliveMinTime.String())
+	}
+	if opts.KeepalivePermitWithoutStream != def.KeepalivePermitWithoutStream {
+		args = append(args, "--"+flagKeepalivePermitWithoutStream+"="+strconv.FormatBool(opts.KeepalivePermitWithoutStream))
+	}
+	if opts.MaxConnectionIdle != def.MaxConnectionIdle {
+		args = append(args, "--"+flagMaxConnectionIdle, opts.MaxConnectionIdle.String())
+	}
+	if opts.WebIdleTimeout != def.WebIdleTimeout {
+		args = append(args, "--"+flagWebIdleTimeout, opts.WebIdleTimeout.String())
+	}
+
+	return args
+}
+
+// startupArgs renders the startup and shutdown timeouts that differ from the defaults as `serve` arguments.
+func startupArgs(opts StartupOptions) []string {
+	def := DefaultStartupOptions()
+	var args []string
+
+	if opts.StartTimeout != def.StartTimeout {
+		args = append(args, "--"+flagStartTimeout, opts.StartTimeout.String())
+	}
+	if opts.ReadyTimeout != def.ReadyTimeout {
+		args = append(args, "--"+flagReadyTimeout, opts.ReadyTimeout.String())
+	}
+	if opts.ShutdownTimeout != def.ShutdownTimeout {
+		args = append(args, "--"+flagShutdownTimeout, opts.ShutdownTimeout.String())
+	}
+
+	return args
+}
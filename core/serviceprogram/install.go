@@ -0,0 +1,275 @@
+package serviceprogram
+
+import (
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/kardianos/service"
+
+	"github.com/anyproto/anytype-cli/core/config"
+	"github.com/anyproto/anytype-cli/core/serviceinfo"
+)
+
+const (
+	RestartOnFailure = "on-failure"
+	RestartAlways    = "always"
+	RestartNever     = "never"
+
+	DefaultRestartDelay = 10 * time.Second
+)
+
+// InstallOptions are the service manager settings of the installed service: its environment,
+// working directory, restart policy and, on systemd, resource limits and sandboxing.
+type InstallOptions struct {
+	// Env holds KEY=VALUE pairs set in the service's environment
+	Env          []string
+	WorkingDir   string
+	Restart      string
+	RestartDelay time.Duration
+	// MemoryMax and MemoryHigh are systemd memory limits such as 512M or 20%
+	MemoryMax  string
+	MemoryHigh string
+	// Hardening adds systemd sandboxing directives that work in user units
+	Hardening bool
+
+	// envFile is where Env is written for systemd, which reads it with EnvironmentFile=
+	envFile string
+}
+
+// DefaultInstallOptions restarts the service 10 seconds after it fails.
+func DefaultInstallOptions() InstallOptions {
+	return InstallOptions{
+		Restart:      RestartOnFailure,
+		RestartDelay: DefaultRestartDelay,
+	}
+}
+
+var (
+	envKeyRe      = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+	memoryLimitRe = regexp.MustCompile(`^(\d+(\.\d+)?[KMGT]?|\d+(\.\d+)?%|infinity)$`)
+)
+
+// Validate checks the options. ANYTYPE_* variables that correspond to config keys are
+// validated like the keys themselves.
+func (o InstallOptions) Validate() error {
+	if _, err := o.EnvVars(); err != nil {
+		return err
+	}
+	switch o.Restart {
+	case RestartOnFailure, RestartAlways, RestartNever:
+	default:
+		return fmt.Errorf("invalid restart policy %q: expected on-failure, always or never", o.Restart)
+	}
+	if o.RestartDelay < 0 {
+		return fmt.Errorf("restart delay must not be negative")
+	}
+	if o.WorkingDir != "" && !filepath.IsAbs(o.WorkingDir) {
+		return fmt.Errorf("working directory %q must be an absolute path", o.WorkingDir)
+	}
+	for name, limit := range map[string]string{"memory-max": o.MemoryMax, "memory-high": o.MemoryHigh} {
+		if limit != "" && !memoryLimitRe.MatchString(limit) {
+			return fmt.Errorf("invalid --%s %q: expected bytes with an optional K, M, G or T suffix, a percentage or infinity", name, limit)
+		}
+	}
+	return nil
+}
+
+// EnvFilePath returns the file holding the --env variables of a systemd service instance. It
+// is only readable by the user, unlike the unit file.
+func EnvFilePath(instance string) string {
+	return filepath.Join(config.GetConfigDir(), serviceinfo.ServiceName(instance)+".env")
+}
+
+func isSystemd() bool {
+	return service.ChosenSystem() != nil && service.ChosenSystem().String() == "linux-systemd"
+}
+
+// EnvVars parses Env into a map. Later assignments of a key win.
+func (o InstallOptions) EnvVars() (map[string]string, error) {
+	if len(o.Env) == 0 {
+		return nil, nil
+	}
+	vars := make(map[string]string, len(o.Env))
+	for _, kv := range o.Env {
+		key, value, ok := strings.Cut(kv, "=")
+		if !ok || !envKeyRe.MatchString(key) {
+			return nil, fmt.Errorf("invalid environment variable %q: expected KEY=VALUE", kv)
+		}
+		for _, k := range config.Keys {
+			if k.EnvVar() == key && k.Validate != nil {
+				if err := k.Validate(value); err != nil {
+					return nil, fmt.Errorf("invalid %s: %w", key, err)
+				}
+			}
+		}
+		vars[key] = value
+	}
+	return vars, nil
+}
+
+// Unsupported lists the options that differ from the defaults but that the service manager of
+// this platform cannot apply.
+func (o InstallOptions) Unsupported() []string {
+	var unsupported []string
+	if !isSystemd() {
+		if o.MemoryMax != "" {
+			unsupported = append(unsupported, "--memory-max")
+		}
+		if o.MemoryHigh != "" {
+			unsupported = append(unsupported, "--memory-high")
+		}
+		if o.Hardening {
+			unsupported = append(unsupported, "--hardening")
+		}
+	}
+	if runtime.GOOS == "windows" && o.WorkingDir != "" {
+		unsupported = append(unsupported, "--working-dir")
+	}
+	return unsupported
+}
+
+// Warnings returns caveats of the options on this platform that do not stop the install.
+func (o InstallOptions) Warnings() []string {
+	var warnings []string
+	if len(o.Env) > 0 && !isSystemd() {
+		warnings = append(warnings, "--env values are stored in the service definition, which other users may be able to read; keep secrets in a credentials backend instead")
+	}
+	if runtime.GOOS == "windows" && o.Restart == RestartAlways {
+		warnings = append(warnings, "--restart always behaves like on-failure: the service control manager only restarts services that fail")
+	}
+	return warnings
+}
+
+// apply sets the environment, working directory and restart policy on the service config.
+func (o InstallOptions) apply(cfg *service.Config) error {
+	vars, err := o.EnvVars()
+	if err != nil {
+		return err
+	}
+	// systemd reads the variables from the private env file rather than the unit
+	if !isSystemd() {
+		cfg.EnvVars = vars
+	}
+	cfg.WorkingDirectory = o.WorkingDir
+
+	switch runtime.GOOS {
+	case "darwin":
+		cfg.Option["LaunchdConfig"] = launchdPlist(o)
+	case "windows":
+		if o.Restart == RestartNever {
+			cfg.Option["OnFailure"] = "noaction"
+		} else {
+			// The service control manager only restarts services that fail
+			cfg.Option["OnFailure"] = "restart"
+			cfg.Option["OnFailureDelayDuration"] = o.RestartDelay.String()
+		}
+	}
+	return nil
+}
+
+// WriteEnvFile writes the --env variables to the private env file the systemd unit of instance
+// reads, or removes a file left by an earlier install without them. Other platforms have no env file.
+func (o InstallOptions) WriteEnvFile(instance string) error {
+	if !isSystemd() {
+		return nil
+	}
+	vars, err := o.EnvVars()
+	if err != nil {
+		return err
+	}
+	path := EnvFilePath(instance)
+	if len(vars) == 0 {
+		return RemoveEnvFile(instance)
+	}
+	if err := config.WriteFileAtomic(path, envFileContent(vars), 0600); err != nil {
+		return fmt.Errorf("failed to write service environment file: %w", err)
+	}
+	return nil
+}
+
+// RemoveEnvFile removes the env file of a service instance, if there is one.
+func RemoveEnvFile(instance string) error {
+	if err := os.Remove(EnvFilePath(instance)); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to remove service environment file: %w", err)
+	}
+	return nil
+}
+
+// envFileContent formats vars for systemd's EnvironmentFile=, quoting every value.
+func envFileContent(vars map[string]string) []byte {
+	quote := strings.NewReplacer(`\`, `\\`, `"`, `\"`, "$", `\$`, "`", "\\`")
+	var b strings.Builder
+	for _, k := range slices.Sorted(maps.Keys(vars)) {
+		fmt.Fprintf(&b, "%s=\"%s\"\n", k, quote.Replace(vars[k]))
+	}
+	return []byte(b.String())
+}
+
+// launchdKeepAlive returns the KeepAlive value of the policy: on-failure only restarts after
+// an unsuccessful exit.
+func (o InstallOptions) launchdKeepAlive() string {
+	switch o.Restart {
+	case RestartNever:
+		return "<false/>"
+	case RestartAlways:
+		return "<true/>"
+	}
+	return "<dict>\n\t\t<key>SuccessfulExit</key>\n\t\t<false/>\n\t</dict>"
+}
+
+// launchdThrottle returns the ThrottleInterval, launchd's minimum time between restarts, in seconds.
+func (o InstallOptions) launchdThrottle() string {
+	seconds := int(o.RestartDelay.Round(time.Second).Seconds())
+	if seconds < 1 {
+		seconds = 1
+	}
+	return strconv.Itoa(seconds)
+}
+
+// systemdRestart returns the Restart= value of the policy.
+func (o InstallOptions) systemdRestart() string {
+	if o.Restart == RestartNever {
+		return "no"
+	}
+	return o.Restart
+}
+
+// hardeningDirectives restrict the server without needing privileges the user manager lacks.
+var hardeningDirectives = []string{
+	"NoNewPrivileges=yes",
+	"LockPersonality=yes",
+	"RestrictRealtime=yes",
+	"RestrictSUIDSGID=yes",
+	"SystemCallArchitectures=native",
+	"RestrictAddressFamilies=AF_UNIX AF_INET AF_INET6 AF_NETLINK",
+	"UMask=0077",
+}
+
+// systemdDirectives returns the [Service] lines for the restart policy, environment, limits and hardening.
+func (o InstallOptions) systemdDirectives() []string {
+	lines := []string{
+		"Restart=" + o.systemdRestart(),
+		fmt.Sprintf("RestartSec=%g", o.RestartDelay.Seconds()),
+	}
+	if len(o.Env) > 0 && o.envFile != "" {
+		lines = append(lines, "EnvironmentFile="+o.envFile)
+	}
+	if o.MemoryHigh != "" {
+		lines = append(lines, "MemoryHigh="+o.MemoryHigh)
+	}
+	if o.MemoryMax != "" {
+		lines = append(lines, "MemoryMax="+o.MemoryMax)
+	}
+	if o.Hardening {
+		lines = append(lines, hardeningDirectives...)
+	}
+	return lines
+}
@@ -0,0 +1,71 @@
+package serviceprogram
+
+import "strings"
+
+// launchdPlist returns the property list template for `service install` on macOS. It differs
+// from the service library's default in the restart policy: KeepAlive only restarts the agent
+// after a failure unless --restart always is given, and ThrottleInterval applies --restart-delay.
+func launchdPlist(install InstallOptions) string {
+	return strings.NewReplacer(
+		"{{keepAlive}}", install.launchdKeepAlive(),
+		"{{throttleInterval}}", install.launchdThrottle(),
+	).Replace(launchdPlistTemplate)
+}
+
+const launchdPlistTemplate = `<?xml version="1.0" encoding="UTF-8"?>
+<!DOCTYPE plist PUBLIC "-//Apple//DTD PLIST 1.0//EN" "http://www.apple.com/DTDs/PropertyList-1.0.dtd">
+<plist version="1.0">
+<dict>
+	<key>Disabled</key>
+	<false/>
+	{{- if .EnvVars}}
+	<key>EnvironmentVariables</key>
+	<dict>
+		{{- range $k, $v := .EnvVars}}
+		<key>{{html $k}}</key>
+		<string>{{html $v}}</string>
+		{{- end}}
+	</dict>
+	{{- end}}
+	<key>KeepAlive</key>
+	{{keepAlive}}
+	<key>Label</key>
+	<string>{{html .Name}}</string>
+	<key>ProgramArguments</key>
+	<array>
+		<string>{{html .Path}}</string>
+		{{- if .Config.Arguments}}
+		{{- range .Config.Arguments}}
+		<string>{{html .}}</string>
+		{{- end}}
+	{{- end}}
+	</array>
+	{{- if .ChRoot}}
+	<key>RootDirectory</key>
+	<string>{{html .ChRoot}}</string>
+	{{- end}}
+	<key>RunAtLoad</key>
+	<{{bool .RunAtLoad}}/>
+	<key>SessionCreate</key>
+	<{{bool .SessionCreate}}/>
+	{{- if .StandardErrorPath}}
+	<key>StandardErrorPath</key>
+	<string>{{html .StandardErrorPath}}</string>
+	{{- end}}
+	{{- if .StandardOutPath}}
+	<key>StandardOutPath</key>
+	<string>{{html .StandardOutPath}}</string>
+	{{- end}}
+	<key>ThrottleInterval</key>
+	<integer>{{throttleInterval}}</integer>
+	{{- if .UserName}}
+	<key>UserName</key>
+	<string>{{html .UserName}}</string>
+	{{- end}}
+	{{- if .WorkingDirectory}}
+	<key>WorkingDirectory</key>
+	<string>{{html .WorkingDirectory}}</string>
+	{{- end}}
+</dict>
+</plist>
+`
@@ -0,0 +1,53 @@
+package serviceprogram
+
+import (
+	"os"
+	"time"
+
+	"github.com/kardianos/service"
+
+	"github.com/anyproto/anytype-cli/core/output"
+	"github.com/anyproto/anytype-cli/core/servicelog"
+)
+
+// startLogFile writes the server's output to the rotating service log when it runs as a
+// service. Under systemd the output already goes to the journal, and in the foreground to the terminal.
+func (p *Program) startLogFile() {
+	if service.Interactive() || os.Getenv("JOURNAL_STREAM") != "" {
+		return
+	}
+
+	cfg := p.currentConfig().Log
+	w, err := servicelog.NewWriter(servicelog.GetFilePath(), servicelog.Options{
+		MaxSize:    int64(cfg.MaxSizeMB) * 1024 * 1024,
+		MaxBackups: logBackups(cfg.MaxBackups),
+		MaxAge:     cfg.MaxAge,
+	})
+	if err != nil {
+		output.Warning("Failed to open service log: %v", err)
+		return
+	}
+	restore, err := servicelog.RedirectOutput(w)
+	if err != nil {
+		w.Close()
+		output.Warning("Failed to redirect output to the service log: %v", err)
+		return
+	}
+	if err := w.CaptureCrashes(); err != nil {
+		output.Warning("Panics will not reach the service log: %v", err)
+	}
+
+	p.stopLogFile = func() {
+		restore()
+		w.Close()
+	}
+	output.Info("Service started at %s", time.Now().Format(time.RFC3339))
+}
+
+// logBackups returns the number of rotated files to keep; 0 is a valid setting that keeps none.
+func logBackups(n *int) int {
+	if n == nil {
+		return servicelog.DefaultMaxBackups
+	}
+	return *n
+}
@@ -0,0 +1,92 @@
+package serviceprogram
+
+import (
+	"os"
+	"os/signal"
+	"runtime"
+	"slices"
+	"strings"
+	"syscall"
+
+	"github.com/anyproto/anytype-cli/core/config"
+	"github.com/anyproto/anytype-cli/core/grpcserver"
+	"github.com/anyproto/anytype-cli/core/output"
+	"github.com/anyproto/anytype-cli/core/serverconfig"
+	"github.com/anyproto/anytype-cli/core/systemd"
+)
+
+// handleReloads re-reads the server config file on SIGHUP until the program stops.
+func (p *Program) handleReloads() {
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	defer signal.Stop(hup)
+
+	for {
+		select {
+		case <-p.ctx.Done():
+			return
+		case <-hup:
+			p.reload()
+		}
+	}
+}
+
+// reload applies the settings of the server config file that can change while running: the log
+// level, unless it was given as a flag, the TLS certificate files, webhooks and auto-login. Other
+// changes are reported and ignored until the next restart. If the file is invalid, the running
+// settings are kept.
+func (p *Program) reload() {
+	p.notify(systemd.Reloading)
+	defer p.notify(systemd.Ready)
+
+	p.configMu.RLock()
+	path, old, setByFlag := p.configFile, p.serverConfig, p.setByFlag
+	p.configMu.RUnlock()
+
+	cfg, err := serverconfig.Load(path)
+	if err != nil {
+		output.Warning("Reload failed, keeping the running config: %v", err)
+		return
+	}
+
+	if restart := serverconfig.RestartRequired(old, cfg); len(restart) > 0 {
+		output.Warning("Changes to %s take effect after a restart", strings.Join(restart, ", "))
+		// The unit's WatchdogSec is derived from the watchdog settings at install
+		if slices.Contains(restart, "watchdog") && runtime.GOOS == "linux" {
+			output.Warning("Reinstall a systemd service to apply the watchdog settings to its unit")
+		}
+	}
+
+	if cfg.Log.Level != "" && cfg.Log.Level != old.Log.Level && (setByFlag == nil || !setByFlag("logLevel")) {
+		if err := config.SetFlagValue("logLevel", cfg.Log.Level); err != nil {
+			output.Warning("Reload: %v", err)
+		} else {
+			grpcserver.SetLogLevel(config.GetLogLevel())
+		}
+	}
+
+	if cfg.TLS.Enabled() && old.TLS.Enabled() {
+		if err := p.server.ReloadAPICertificate(cfg.TLS.CertFile, cfg.TLS.KeyFile); err != nil {
+			output.Warning("Reload: keeping the current TLS certificate: %v", err)
+			cfg.TLS = old.TLS
+		}
+	}
+
+	// Settings that need a restart keep their running values until then
+	cfg.ListenAddress = old.ListenAddress
+	cfg.GRPCListenAddress = old.GRPCListenAddress
+	cfg.GRPCWebListenAddress = old.GRPCWebListenAddress
+	cfg.DataDir = old.DataDir
+	cfg.GRPC = old.GRPC
+	cfg.Startup = old.Startup
+	cfg.Shutdown = old.Shutdown
+	cfg.Watchdog = old.Watchdog
+	cfg.Log.MaxSizeMB, cfg.Log.MaxBackups, cfg.Log.MaxAge = old.Log.MaxSizeMB, old.Log.MaxBackups, old.Log.MaxAge
+	if cfg.TLS.Enabled() != old.TLS.Enabled() {
+		cfg.TLS = old.TLS
+	}
+
+	p.SetServerConfig(path, cfg)
+	output.Success("Reloaded server config from %s", path)
+	p.notifier.Notify(serverconfig.EventReloaded, map[string]string{"path": path})
+}
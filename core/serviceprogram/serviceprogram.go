@@ -3,16 +3,24 @@ package serviceprogram
 import (
 	"context"
 	"fmt"
+	"net"
 	"os"
+	"strconv"
 	"sync"
 	"time"
 
 	"github.com/kardianos/service"
 
 	"github.com/anyproto/anytype-cli/core"
+	"github.com/anyproto/anytype-cli/core/autologin"
 	"github.com/anyproto/anytype-cli/core/config"
+	"github.com/anyproto/anytype-cli/core/datalock"
 	"github.com/anyproto/anytype-cli/core/grpcserver"
 	"github.com/anyproto/anytype-cli/core/output"
+	"github.com/anyproto/anytype-cli/core/serverconfig"
+	"github.com/anyproto/anytype-cli/core/serverstatus"
+	"github.com/anyproto/anytype-cli/core/serviceinfo"
+	"github.com/anyproto/anytype-cli/core/systemd"
 )
 
 // GetService creates a service instance with default configuration.
@@ -20,6 +28,12 @@ func GetService() (service.Service, error) {
 	return GetServiceWithAddresses("", "", "")
 }
 
+// GetInstanceService creates the service of a named instance, or of the default instance for an
+// empty name or serviceinfo.DefaultInstance. Use it to control an installed service.
+func GetInstanceService(instance string) (service.Service, error) {
+	return GetServiceFromOptions(ServiceOptions{Instance: instance})
+}
+
 // GetServiceWithAddress creates a service instance with a custom API listen address.
 func GetServiceWithAddress(apiAddr string) (service.Service, error) {
 	return GetServiceWithAddresses(apiAddr, "", "")
@@ -27,8 +41,92 @@ func GetServiceWithAddress(apiAddr string) (service.Service, error) {
 
 // GetServiceWithAddresses creates a service instance with custom API and gRPC listen addresses.
 func GetServiceWithAddresses(apiAddr, grpcAddr, grpcWebAddr string) (service.Service, error) {
+	return GetServiceWithOptions(apiAddr, grpcAddr, grpcWebAddr, grpcserver.DefaultOptions())
+}
+
+// GetServiceWithOptions creates a service instance with custom listen addresses and gRPC server limits.
+// Non-default limits are passed to the installed service as `serve` flags.
+func GetServiceWithOptions(apiAddr, grpcAddr, grpcWebAddr string, serverOpts grpcserver.Options) (service.Service, error) {
+	return GetServiceFromOptions(ServiceOptions{
+		APIAddr:       apiAddr,
+		GRPCAddr:      grpcAddr,
+		GRPCWebAddr:   grpcWebAddr,
+		ServerOptions: serverOpts,
+		Startup:       DefaultStartupOptions(),
+	})
+}
+
+// ServiceOptions describes the `serve` invocation of the installed service.
+type ServiceOptions struct {
+	// Instance names the service; empty for the default instance
+	Instance      string
+	APIAddr       string
+	GRPCAddr      string
+	GRPCWebAddr   string
+	ServerOptions grpcserver.Options
+	// ConfigFile is an absolute path passed to the service as `serve --config`
+	ConfigFile string
+	// Watchdog holds the watchdog settings of ConfigFile, from which the unit's WatchdogSec is derived
+	Watchdog serverconfig.Watchdog
+	Startup  StartupOptions
+	Install  InstallOptions
+}
+
+const (
+	DefaultStartTimeout = 30 * time.Second
+	DefaultReadyTimeout = time.Minute
+
+	// readyGrace is how long Start waits past the ready timeout for run to report
+	readyGrace = 5 * time.Second
+)
+
+// StartupOptions bounds the startup phases, each with its own deadline: opening the listeners,
+// then waiting until the middleware and the API gateway answer requests. ShutdownTimeout bounds
+// how long in-flight requests may take to finish when the server stops.
+type StartupOptions struct {
+	StartTimeout    time.Duration
+	ReadyTimeout    time.Duration
+	ShutdownTimeout time.Duration
+}
+
+// DefaultStartupOptions returns the default startup and shutdown timeouts.
+func DefaultStartupOptions() StartupOptions {
+	return StartupOptions{
+		StartTimeout:    DefaultStartTimeout,
+		ReadyTimeout:    DefaultReadyTimeout,
+		ShutdownTimeout: grpcserver.DefaultShutdownTimeout,
+	}
+}
+
+// Validate checks that the timeouts are positive.
+func (o StartupOptions) Validate() error {
+	if o.StartTimeout <= 0 || o.ReadyTimeout <= 0 {
+		return fmt.Errorf("start and ready timeouts must be positive")
+	}
+	if o.ShutdownTimeout <= 0 {
+		return fmt.Errorf("shutdown timeout must be positive")
+	}
+	return nil
+}
+
+// GetServiceFromOptions creates a service instance from opts. Empty addresses use the active profile's.
+func GetServiceFromOptions(opts ServiceOptions) (service.Service, error) {
+	if opts.Startup == (StartupOptions{}) {
+		opts.Startup = DefaultStartupOptions()
+	}
+	if opts.Install.Restart == "" {
+		// The flags always set a policy, so options without one were not configured
+		def := DefaultInstallOptions()
+		opts.Install.Restart = def.Restart
+		opts.Install.RestartDelay = def.RestartDelay
+	}
+
+	opts.Install.envFile = EnvFilePath(opts.Instance)
+
 	options := service.KeyValue{
-		"UserService": true,
+		"UserService":   true,
+		"SystemdScript": systemdUnit(opts.Startup, opts.Install, opts.Watchdog),
+		"ReloadSignal":  "HUP",
 	}
 
 	logDir := config.GetLogsDir()
@@ -38,73 +136,216 @@ func GetServiceWithAddresses(apiAddr, grpcAddr, grpcWebAddr string) (service.Ser
 		}
 	}
 
-	effectiveAPIAddr := apiAddr
+	effectiveAPIAddr := opts.APIAddr
 	if effectiveAPIAddr == "" {
-		effectiveAPIAddr = config.DefaultAPIAddress
+		effectiveAPIAddr = config.GetAPIAddress()
 	}
 
-	effectiveGRPCAddr := grpcAddr
+	effectiveGRPCAddr := opts.GRPCAddr
 	if effectiveGRPCAddr == "" {
-		effectiveGRPCAddr = config.DefaultGRPCAddress
+		effectiveGRPCAddr = config.GetGRPCAddress()
 	}
 
-	effectiveGRPCWebAddr := grpcWebAddr
+	effectiveGRPCWebAddr := opts.GRPCWebAddr
 	if effectiveGRPCWebAddr == "" {
-		effectiveGRPCWebAddr = config.DefaultGRPCWebAddress
+		effectiveGRPCWebAddr = config.GetGRPCWebAddress()
 	}
 
+	// The service reads its profile's config itself, so only flag and environment overrides are passed on
 	args := []string{"serve"}
-	if effectiveAPIAddr != config.DefaultAPIAddress {
+	if profile := config.ActiveProfile(); profile != config.DefaultProfile {
+		args = append(args, "--profile", profile)
+	}
+	if opts.ConfigFile != "" {
+		args = append(args, "--config", opts.ConfigFile)
+	}
+	if effectiveAPIAddr != config.StoredValue("listenAddress") {
 		args = append(args, "--listen-address", effectiveAPIAddr)
 	}
-	if effectiveGRPCAddr != config.DefaultGRPCAddress {
+	if effectiveGRPCAddr != config.StoredValue("grpcListenAddress") {
 		args = append(args, "--grpc-listen-address", effectiveGRPCAddr)
 	}
-	if effectiveGRPCWebAddr != config.DefaultGRPCWebAddress {
+	if effectiveGRPCWebAddr != config.StoredValue("grpcWebListenAddress") {
 		args = append(args, "--grpc-web-listen-address", effectiveGRPCWebAddr)
 	}
+	args = append(args, serverOptionArgs(opts.ServerOptions)...)
+	args = append(args, startupArgs(opts.Startup)...)
+
+	displayName := "Anytype"
+	if opts.Instance != "" && opts.Instance != serviceinfo.DefaultInstance {
+		displayName = fmt.Sprintf("Anytype (%s)", opts.Instance)
+	}
 
 	svcConfig := &service.Config{
-		Name:        "anytype",
-		DisplayName: "Anytype",
-		Description: "Anytype",
+		Name:        serviceinfo.ServiceName(opts.Instance),
+		DisplayName: displayName,
+		Description: displayName,
 		Arguments:   args,
 		Option:      options,
 	}
+	if err := opts.Install.apply(svcConfig); err != nil {
+		return nil, err
+	}
 
 	prg := New(effectiveAPIAddr, effectiveGRPCAddr, effectiveGRPCWebAddr)
+	prg.SetServerOptions(opts.ServerOptions)
+	prg.SetStartupOptions(opts.Startup)
 	return service.New(prg, svcConfig)
 }
 
 type Program struct {
-	server        *grpcserver.Server
-	ctx           context.Context
-	cancel        context.CancelFunc
-	wg            sync.WaitGroup
-	startErr      error
-	startCh       chan struct{}
-	apiListenAddr string
-	grpcListenAddr string
+	server   *grpcserver.Server
+	ctx      context.Context
+	cancel   context.CancelFunc
+	wg       sync.WaitGroup
+	startErr error
+	startCh  chan struct{}
+	// listeningCh is closed once the server has opened its listeners
+	listeningCh       chan struct{}
+	apiListenAddr     string
+	grpcListenAddr    string
 	grpcWebListenAddr string
+	serverOptions     grpcserver.Options
+	startupOptions    StartupOptions
+
+	configMu     sync.RWMutex
+	configFile   string
+	serverConfig *serverconfig.Config
+	notifier     *serverconfig.Notifier
+	// setByFlag reports the config keys given on the command line, which reloads keep
+	setByFlag func(key string) bool
+
+	// stopLogFile restores the output redirected by startLogFile
+	stopLogFile func()
+
+	// activated holds the listeners passed by systemd socket activation, by systemd listener name
+	activated map[string]net.Listener
+	// preset holds the gRPC, gRPC-Web and API listeners opened by the caller
+	preset [3]net.Listener
+
+	// dataLock keeps other servers out of the data directory while this one runs
+	dataLock *datalock.Lock
 }
 
 func New(apiListenAddr, grpcListenAddr, grpcWebListenAddr string) *Program {
 	return &Program{
 		startCh:           make(chan struct{}),
+		listeningCh:       make(chan struct{}),
 		apiListenAddr:     apiListenAddr,
 		grpcListenAddr:    grpcListenAddr,
 		grpcWebListenAddr: grpcWebListenAddr,
+		serverOptions:     grpcserver.DefaultOptions(),
+		startupOptions:    DefaultStartupOptions(),
+		serverConfig:      serverconfig.Default(),
+		notifier:          serverconfig.NewNotifier(nil),
 	}
 }
 
+// SetServerOptions overrides the gRPC server limits used when the program starts.
+func (p *Program) SetServerOptions(opts grpcserver.Options) {
+	p.serverOptions = opts
+}
+
+// SetListeners makes the server use listeners the caller already opened, e.g. on ports it
+// selected, instead of listening itself. Socket activation takes precedence. Nil listeners are
+// opened by the server.
+func (p *Program) SetListeners(grpcListener, grpcWebListener, apiListener net.Listener) {
+	p.preset = [3]net.Listener{grpcListener, grpcWebListener, apiListener}
+}
+
+// SetStartupOptions overrides the startup timeouts.
+func (p *Program) SetStartupOptions(opts StartupOptions) {
+	p.startupOptions = opts
+}
+
+// SetServerConfig sets the server config file the program was started with. Its TLS, webhook and
+// auto-login settings are used on start, and the file is re-read on SIGHUP.
+func (p *Program) SetServerConfig(path string, cfg *serverconfig.Config) {
+	p.configMu.Lock()
+	defer p.configMu.Unlock()
+	p.configFile = path
+	p.serverConfig = cfg
+	p.notifier.SetWebhooks(cfg.Webhooks)
+}
+
+// SetFlagOverrides tells the program which config keys were given on the command line. Those
+// take precedence over the server config file, also when it is reloaded.
+func (p *Program) SetFlagOverrides(setByFlag func(key string) bool) {
+	p.configMu.Lock()
+	defer p.configMu.Unlock()
+	p.setByFlag = setByFlag
+}
+
+func (p *Program) currentConfig() *serverconfig.Config {
+	p.configMu.RLock()
+	defer p.configMu.RUnlock()
+	return p.serverConfig
+}
+
 func (p *Program) Start(s service.Service) error {
 	p.ctx, p.cancel = context.WithCancel(context.Background())
-	p.server = grpcserver.NewServer()
+	p.startLogFile()
+	if err := p.start(); err != nil {
+		p.unlockDataDir()
+		// Restore the output so that the error is reported where the service manager looks
+		if p.stopLogFile != nil {
+			p.stopLogFile()
+			p.stopLogFile = nil
+		}
+		return err
+	}
+	return nil
+}
+
+func (p *Program) start() error {
+	p.server = grpcserver.NewServerWithOptions(p.serverOptions)
+	p.server.SetShutdownTimeout(p.startupOptions.ShutdownTimeout)
+
+	activated, err := systemd.Listeners()
+	if err != nil {
+		return fmt.Errorf("socket activation: %w", err)
+	}
+	if len(activated) > 0 {
+		p.activated = activated
+		p.server.SetListeners(activated[systemd.ListenerGRPC], activated[systemd.ListenerGRPCWeb], activated[systemd.ListenerAPI])
+		output.Info("Using %d socket(s) passed by systemd", len(activated))
+	} else {
+		p.server.SetListeners(p.preset[0], p.preset[1], p.preset[2])
+	}
+	if tls := p.currentConfig().TLS; tls.Enabled() {
+		p.server.SetAPITLS(tls.CertFile, tls.KeyFile)
+	}
+	if err := p.lockDataDir(); err != nil {
+		return err
+	}
 
 	p.wg.Add(1)
 	go p.run()
 
-	// Wait for server to start or fail
+	// Each phase has its own deadline: opening the listeners, then becoming ready
+	select {
+	case <-p.listeningCh:
+	case <-p.startCh:
+		p.cancel()
+		p.wg.Wait()
+		return p.startErr
+	case <-time.After(p.startupOptions.StartTimeout):
+		// Server.Start cannot be interrupted, so run is not waited for; it stops the server
+		// once Start returns, as the context is cancelled. The store may still be opening until
+		// then, so the data directory stays locked until run returns or the process exits.
+		p.cancel()
+		lock := p.dataLock
+		p.dataLock = nil
+		go func() {
+			p.wg.Wait()
+			if err := lock.Release(); err != nil {
+				output.Info("Error releasing data directory lock: %v", err)
+			}
+		}()
+		return fmt.Errorf("timeout waiting for the server to open its listeners after %s", p.startupOptions.StartTimeout)
+	}
+
+	// run enforces the ready timeout itself; the grace period lets it report its own error first
 	select {
 	case <-p.startCh:
 		if p.startErr != nil {
@@ -112,16 +353,19 @@ func (p *Program) Start(s service.Service) error {
 			p.wg.Wait()
 			return p.startErr
 		}
-	case <-time.After(5 * time.Second):
+	case <-time.After(p.startupOptions.ReadyTimeout + readyGrace):
 		p.cancel()
 		p.wg.Wait()
-		return fmt.Errorf("timeout waiting for server to start")
+		return fmt.Errorf("timeout waiting for the server to become ready after %s", p.startupOptions.ReadyTimeout)
 	}
 
 	return nil
 }
 
 func (p *Program) Stop(s service.Service) error {
+	p.notify(systemd.Stopping)
+	p.notifier.Notify(serverconfig.EventStopping, nil)
+
 	if p.cancel != nil {
 		p.cancel()
 	}
@@ -133,6 +377,14 @@ func (p *Program) Stop(s service.Service) error {
 	}
 
 	p.wg.Wait()
+	if err := serverstatus.Remove(); err != nil {
+		output.Info("Error removing server status: %v", err)
+	}
+	p.unlockDataDir()
+	p.notifier.Wait()
+	if p.stopLogFile != nil {
+		p.stopLogFile()
+	}
 	return nil
 }
 
@@ -140,52 +392,243 @@ func (p *Program) run() {
 	defer p.wg.Done()
 	defer close(p.startCh)
 
-	grpcAddr := p.grpcListenAddr
-	if grpcAddr == "" {
-		grpcAddr = config.DefaultGRPCAddress
-	}
+	grpcAddr, grpcWebAddr, apiAddr := p.listenAddresses()
 
-	grpcWebAddr := p.grpcWebListenAddr
-	if grpcWebAddr == "" {
-		grpcWebAddr = config.DefaultGRPCWebAddress
+	started := time.Now()
+	if err := p.server.Start(grpcAddr, grpcWebAddr, apiAddr); err != nil {
+		p.startErr = err
+		return
 	}
+	close(p.listeningCh)
+	output.Info("Listeners started in %s", time.Since(started).Round(time.Millisecond))
 
-	if err := p.server.Start(grpcAddr, grpcWebAddr); err != nil {
+	heartVersion, err := p.waitReady()
+	if err != nil {
 		p.startErr = err
+		if stopErr := p.server.Stop(); stopErr != nil {
+			output.Info("Error stopping server: %v", stopErr)
+		}
 		return
 	}
+	output.Info("Server ready in %s", time.Since(started).Round(time.Millisecond))
 
-	// Signal successful start
-	p.startCh <- struct{}{}
+	// A status file left behind means the previous server did not stop cleanly
+	if err := serverstatus.RecordUncleanExit(); err != nil {
+		output.Warning("Failed to record the previous server's exit: %v", err)
+	}
+	now := time.Now().UTC().Truncate(time.Second)
+	err = serverstatus.Write(&serverstatus.Status{
+		PID:            os.Getpid(),
+		Profile:        config.ActiveProfile(),
+		StartedAt:      now,
+		GRPCAddress:    grpcAddr,
+		GRPCWebAddress: grpcWebAddr,
+		APIAddress:     apiAddr,
+		CLIVersion:     core.GetVersion(),
+		HeartVersion:   heartVersion,
+		Auth:           serverstatus.Auth{State: serverstatus.AuthIdle, Since: now},
+	})
+	if err != nil {
+		output.Warning("Failed to write server status: %v", err)
+	}
+
+	// Signal that the server is ready, unless Start already gave up on it
+	select {
+	case p.startCh <- struct{}{}:
+	case <-p.ctx.Done():
+		if stopErr := p.server.Stop(); stopErr != nil {
+			output.Info("Error stopping server: %v", stopErr)
+		}
+		return
+	}
+	p.notify(systemd.Ready, systemd.Status("Serving gRPC on %s", grpcAddr))
 
-	// Wait a moment for server to be ready
-	time.Sleep(2 * time.Second)
+	if interval, err := systemd.WatchdogInterval(); err != nil {
+		output.Warning("Watchdog disabled: %v", err)
+	} else if interval > 0 {
+		go p.runWatchdog(interval)
+	}
+	go p.superviseMiddleware()
+
+	p.notifier.Notify(serverconfig.EventStarted, map[string]string{
+		"grpcAddress":    grpcAddr,
+		"grpcWebAddress": grpcWebAddr,
+		"apiAddress":     apiAddr,
+	})
+
+	p.configMu.RLock()
+	configFile := p.configFile
+	p.configMu.RUnlock()
+	if configFile != "" {
+		go p.handleReloads()
+	}
 
 	go p.attemptAutoLogin()
 
 	<-p.ctx.Done()
 }
 
+// listenAddresses returns the gRPC, gRPC-Web and API addresses the server listens on.
+func (p *Program) listenAddresses() (grpcAddr, grpcWebAddr, apiAddr string) {
+	grpcAddr = p.grpcListenAddr
+	if grpcAddr == "" {
+		grpcAddr = config.GetGRPCAddress()
+	}
+
+	grpcWebAddr = p.grpcWebListenAddr
+	if grpcWebAddr == "" {
+		grpcWebAddr = config.GetGRPCWebAddress()
+	}
+
+	apiAddr = p.apiListenAddr
+	if apiAddr == "" {
+		apiAddr = config.GetAPIAddress()
+	}
+
+	// Activated sockets are bound to the addresses in the socket unit
+	for name, addr := range map[string]*string{
+		systemd.ListenerGRPC:    &grpcAddr,
+		systemd.ListenerGRPCWeb: &grpcWebAddr,
+		systemd.ListenerAPI:     &apiAddr,
+	} {
+		if ln, ok := p.activated[name]; ok {
+			*addr = ln.Addr().String()
+		}
+	}
+	return grpcAddr, grpcWebAddr, apiAddr
+}
+
+// lockDataDir takes the data directory lock, so that a second server using the same directory
+// fails instead of corrupting it.
+func (p *Program) lockDataDir() error {
+	host, _ := os.Hostname()
+	grpcAddr, grpcWebAddr, apiAddr := p.listenAddresses()
+	lock, stale, err := datalock.Acquire(config.GetDataDir(), datalock.Owner{
+		PID:            os.Getpid(),
+		Host:           host,
+		Profile:        config.ActiveProfile(),
+		GRPCAddress:    grpcAddr,
+		GRPCWebAddress: grpcWebAddr,
+		APIAddress:     apiAddr,
+		StartedAt:      time.Now().UTC().Truncate(time.Second),
+	})
+	if err != nil {
+		return err
+	}
+	if stale != nil {
+		output.Info("Removed stale data directory lock left by PID %d on %s", stale.PID, stale.Host)
+	}
+	p.dataLock = lock
+	return nil
+}
+
+func (p *Program) unlockDataDir() {
+	if err := p.dataLock.Release(); err != nil {
+		output.Info("Error releasing data directory lock: %v", err)
+	}
+	p.dataLock = nil
+}
+
+// waitReady probes the middleware and then the API gateway until both answer, logging how long
+// each took. It returns the middleware version.
+func (p *Program) waitReady() (string, error) {
+	ctx, cancel := context.WithTimeout(p.ctx, p.startupOptions.ReadyTimeout)
+	defer cancel()
+
+	phase := time.Now()
+	version, err := p.server.WaitMiddlewareReady(ctx)
+	if err != nil {
+		return "", fmt.Errorf("server did not become ready within %s: %w", p.startupOptions.ReadyTimeout, err)
+	}
+	output.Info("Middleware %s ready in %s", version, time.Since(phase).Round(time.Millisecond))
+
+	phase = time.Now()
+	if err := p.server.WaitAPIReady(ctx); err != nil {
+		return "", fmt.Errorf("server did not become ready within %s: %w", p.startupOptions.ReadyTimeout, err)
+	}
+	output.Info("API gateway ready in %s", time.Since(phase).Round(time.Millisecond))
+	return version, nil
+}
+
+// checkAPI verifies that the account's API answers through the gateway after a login.
+func (p *Program) checkAPI() {
+	ctx, cancel := context.WithTimeout(p.ctx, p.startupOptions.ReadyTimeout)
+	defer cancel()
+	if err := p.server.WaitAPIReady(ctx); err != nil && p.ctx.Err() == nil {
+		output.Warning("The API does not answer after login: %v", err)
+	}
+}
+
+// attemptAutoLogin logs in with the stored account key, retrying with backoff until it
+// succeeds, and records every state in the server status file.
 func (p *Program) attemptAutoLogin() {
+	if !p.currentConfig().AutoLogin.IsEnabled() {
+		output.Info("Auto-login is disabled in the server config")
+		p.setAuth(serverstatus.Auth{State: serverstatus.AuthIdle, Reason: "auto-login is disabled"})
+		return
+	}
+
 	accountKey, _, err := core.GetStoredAccountKey()
 	if err != nil || accountKey == "" {
 		output.Info("No stored account key found, skipping auto-login")
+		p.setAuth(serverstatus.Auth{State: serverstatus.AuthIdle, Reason: "no stored account key"})
+		return
+	}
+	if err := core.ValidateAccountKey(accountKey); err != nil {
+		output.Warning("Stored account key is invalid, skipping auto-login: %v", err)
+		p.setAuth(serverstatus.Auth{State: serverstatus.AuthFailed, Reason: err.Error()})
 		return
 	}
 
 	output.Info("Found stored account key, attempting auto-login...")
 
-	maxRetries := 3
-	for i := 0; i < maxRetries; i++ {
-		if err := core.Authenticate(accountKey, "", p.apiListenAddr); err != nil {
-			if i < maxRetries-1 {
-				time.Sleep(2 * time.Second)
-				continue
+	runner := &autologin.Runner{
+		Login: func(ctx context.Context) (string, error) {
+			if err := core.Authenticate(accountKey, "", p.apiListenAddr); err != nil {
+				return "", err
 			}
-			output.Info("Failed to auto-login with account key after %d attempts: %v", maxRetries, err)
+			accountId, _ := config.GetAccountIdFromConfig()
+			return accountId, nil
+		},
+		Settings: func() serverconfig.AutoLogin {
+			return p.currentConfig().AutoLogin
+		},
+		LoggedIn: func() bool {
+			s, err := serverstatus.Read()
+			return err == nil && s.Auth.State == serverstatus.AuthLoggedIn
+		},
+		OnChange: p.onAuthChange,
+	}
+	runner.Run(p.ctx)
+}
+
+func (p *Program) onAuthChange(auth serverstatus.Auth) {
+	p.setAuth(auth)
+	p.notify(systemd.Status("Account: %s", auth.Describe()))
+
+	switch auth.State {
+	case serverstatus.AuthLoggedIn:
+		output.Success("Successfully logged in using stored account key")
+		go p.checkAPI()
+		p.notifier.Notify(serverconfig.EventLoginSucceeded, map[string]string{"accountId": auth.AccountId})
+	case serverstatus.AuthFailed:
+		if auth.RetryAt.IsZero() {
+			output.Info("Failed to auto-login with account key after %d attempts: %s", auth.Attempts, auth.Reason)
 		} else {
-			output.Success("Successfully logged in using stored account key")
-			return
+			output.Info("Auto-login attempt %d failed: %s; retrying at %s", auth.Attempts, auth.Reason, auth.RetryAt.Local().Format("15:04:05"))
+		}
+		// Retries continue indefinitely, so only the first and the final failure are announced
+		if auth.Attempts == 1 || auth.RetryAt.IsZero() {
+			p.notifier.Notify(serverconfig.EventLoginFailed, map[string]string{
+				"error":    auth.Reason,
+				"attempts": strconv.Itoa(auth.Attempts),
+			})
 		}
 	}
 }
+
+func (p *Program) setAuth(auth serverstatus.Auth) {
+	if err := serverstatus.SetAuth(auth); err != nil {
+		output.Warning("Failed to record auth state: %v", err)
+	}
+}
@@ -1,9 +1,13 @@
 package serviceprogram
 
 import (
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/anyproto/anytype-cli/core/config"
+	"github.com/anyproto/anytype-cli/core/grpcserver"
+	"github.com/anyproto/anytype-cli/core/serverconfig"
 )
 
 func TestNew(t *testing.T) {
@@ -69,6 +73,9 @@ func TestNew(t *testing.T) {
 			if prg.startCh == nil {
 				t.Error("startCh should be initialized")
 			}
+			if prg.listeningCh == nil {
+				t.Error("listeningCh should be initialized")
+			}
 		})
 	}
 }
@@ -116,3 +123,141 @@ func TestGetServiceWithAddress(t *testing.T) {
 		})
 	}
 }
+
+func TestServerOptionArgs(t *testing.T) {
+	if args := serverOptionArgs(grpcserver.DefaultOptions()); len(args) != 0 {
+		t.Errorf("serverOptionArgs(defaults) = %v, want no args", args)
+	}
+
+	opts := grpcserver.DefaultOptions()
+	opts.MaxRecvMsgSize = 64 * 1024 * 1024
+	opts.MaxConcurrentStreams = 100
+	opts.KeepaliveMinTime = 10 * time.Second
+	opts.KeepalivePermitWithoutStream = true
+
+	want := []string{
+		"--grpc-max-recv-msg-size", "67108864",
+		"--grpc-max-concurrent-streams", "100",
+		"--grpc-keepalive-min-time", "10s",
+		"--grpc-keepalive-permit-without-stream=true",
+	}
+	got := serverOptionArgs(opts)
+	if strings.Join(got, " ") != strings.Join(want, " ") {
+		t.Errorf("serverOptionArgs() = %v, want %v", got, want)
+	}
+}
+
+func TestStartupArgs(t *testing.T) {
+	if args := startupArgs(DefaultStartupOptions()); len(args) != 0 {
+		t.Errorf("startupArgs(defaults) = %v, want no args", args)
+	}
+
+	opts := DefaultStartupOptions()
+	opts.ReadyTimeout = 5 * time.Minute
+	opts.ShutdownTimeout = time.Minute
+
+	want := []string{"--ready-timeout", "5m0s", "--shutdown-timeout", "1m0s"}
+	got := startupArgs(opts)
+	if strings.Join(got, " ") != strings.Join(want, " ") {
+		t.Errorf("startupArgs() = %v, want %v", got, want)
+	}
+}
+
+func TestSystemdUnit(t *testing.T) {
+	unit := systemdUnit(DefaultStartupOptions(), DefaultInstallOptions(), serverconfig.Watchdog{})
+	for _, want := range []string{"Type=notify", "WatchdogSec=150", "TimeoutStartSec=100", "TimeoutStopSec=40", "Restart=on-failure\nRestartSec=10\n", "WantedBy=default.target"} {
+		if !strings.Contains(unit, want) {
+			t.Errorf("unit does not contain %q:\n%s", want, unit)
+		}
+	}
+
+	install := InstallOptions{Restart: RestartNever, RestartDelay: 1500 * time.Millisecond, MemoryMax: "1G", Hardening: true}
+	unit = systemdUnit(DefaultStartupOptions(), install, serverconfig.Watchdog{})
+	for _, want := range []string{"Restart=no\nRestartSec=1.5\nMemoryMax=1G\nNoNewPrivileges=yes", "UMask=0077"} {
+		if !strings.Contains(unit, want) {
+			t.Errorf("unit does not contain %q:\n%s", want, unit)
+		}
+	}
+	if strings.Contains(unit, "MemoryHigh") {
+		t.Errorf("unit sets MemoryHigh although it was not given:\n%s", unit)
+	}
+
+	install = DefaultInstallOptions()
+	install.Env = []string{"DATA_PATH=/srv/anytype"}
+	install.envFile = "/home/user/.anytype/anytype.env"
+	unit = systemdUnit(DefaultStartupOptions(), install, serverconfig.Watchdog{})
+	if !strings.Contains(unit, "EnvironmentFile=/home/user/.anytype/anytype.env\n") {
+		t.Errorf("unit does not read the env file:\n%s", unit)
+	}
+
+	unit = systemdUnit(DefaultStartupOptions(), DefaultInstallOptions(), serverconfig.Watchdog{Interval: time.Minute, Failures: 2})
+	if !strings.Contains(unit, "WatchdogSec=170\n") {
+		t.Errorf("unit does not derive WatchdogSec from the watchdog settings:\n%s", unit)
+	}
+	disabled := false
+	unit = systemdUnit(DefaultStartupOptions(), DefaultInstallOptions(), serverconfig.Watchdog{Enabled: &disabled})
+	if !strings.Contains(unit, "WatchdogSec=0\n") {
+		t.Errorf("unit keeps systemd's watchdog although the server's is disabled:\n%s", unit)
+	}
+}
+
+func TestEnvFileContent(t *testing.T) {
+	got := string(envFileContent(map[string]string{
+		"DATA_PATH": "/srv/anytype",
+		"TOKEN":     `a"b\c$d`,
+	}))
+	want := "DATA_PATH=\"/srv/anytype\"\nTOKEN=\"a\\\"b\\\\c\\$d\"\n"
+	if got != want {
+		t.Errorf("envFileContent() = %q, want %q", got, want)
+	}
+}
+
+func TestLaunchdPlist(t *testing.T) {
+	tests := []struct {
+		restart string
+		delay   time.Duration
+		want    []string
+	}{
+		{RestartOnFailure, DefaultRestartDelay, []string{"<key>KeepAlive</key>\n\t<dict>\n\t\t<key>SuccessfulExit</key>\n\t\t<false/>", "<integer>10</integer>"}},
+		{RestartAlways, 30 * time.Second, []string{"<key>KeepAlive</key>\n\t<true/>", "<integer>30</integer>"}},
+		{RestartNever, 0, []string{"<key>KeepAlive</key>\n\t<false/>", "<integer>1</integer>"}},
+	}
+	for _, tt := range tests {
+		plist := launchdPlist(InstallOptions{Restart: tt.restart, RestartDelay: tt.delay})
+		for _, want := range tt.want {
+			if !strings.Contains(plist, want) {
+				t.Errorf("%s: plist does not contain %q:\n%s", tt.restart, want, plist)
+			}
+		}
+	}
+}
+
+func TestInstallOptionsValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		modify  func(*InstallOptions)
+		wantErr bool
+	}{
+		{name: "defaults", modify: func(o *InstallOptions) {}},
+		{name: "env", modify: func(o *InstallOptions) {
+			o.Env = []string{"DATA_PATH=/srv/anytype", "ANYTYPE_LOG_LEVEL=debug", "ANYTYPE_GRPC_NO_DEBUG_TIMEOUT=1"}
+		}},
+		{name: "env without value", modify: func(o *InstallOptions) { o.Env = []string{"DATA_PATH"} }, wantErr: true},
+		{name: "invalid env key", modify: func(o *InstallOptions) { o.Env = []string{"1KEY=x"} }, wantErr: true},
+		{name: "invalid config value", modify: func(o *InstallOptions) { o.Env = []string{"ANYTYPE_LOG_LEVEL=loud"} }, wantErr: true},
+		{name: "restart policy", modify: func(o *InstallOptions) { o.Restart = "sometimes" }, wantErr: true},
+		{name: "negative delay", modify: func(o *InstallOptions) { o.RestartDelay = -time.Second }, wantErr: true},
+		{name: "relative working dir", modify: func(o *InstallOptions) { o.WorkingDir = "data" }, wantErr: true},
+		{name: "memory limits", modify: func(o *InstallOptions) { o.MemoryMax = "512M"; o.MemoryHigh = "40%" }},
+		{name: "invalid memory limit", modify: func(o *InstallOptions) { o.MemoryMax = "lots\nExecStartPre=/bin/true" }, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := DefaultInstallOptions()
+			tt.modify(&opts)
+			if err := opts.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
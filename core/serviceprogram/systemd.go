@@ -0,0 +1,100 @@
+package serviceprogram
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/anyproto/anytype-cli/core/output"
+	"github.com/anyproto/anytype-cli/core/serverconfig"
+	"github.com/anyproto/anytype-cli/core/systemd"
+)
+
+// systemdWatchdogMargin is how much longer systemd's watchdog waits than the server's own, which
+// records why it restarted the server.
+const systemdWatchdogMargin = 30 * time.Second
+
+// systemdUnit returns the unit template for `service install` on systemd. The server reports
+// readiness and sends watchdog keep-alives, so systemd only considers it started once it
+// answers requests and restarts it if it stops responding. ExecReload re-reads --config.
+func systemdUnit(startup StartupOptions, install InstallOptions, watchdog serverconfig.Watchdog) string {
+	// Leave the server's own timeouts room to report a failure before systemd gives up
+	timeout := int((startup.StartTimeout + startup.ReadyTimeout + 10*time.Second).Seconds())
+	// Draining requests and stopping the middleware share the shutdown timeout
+	stopTimeout := int((startup.ShutdownTimeout + 10*time.Second).Seconds())
+	// systemd is the fallback for a server too wedged for its own watchdog, which is disabled with it
+	watchdogSec := 0
+	if watchdog.IsEnabled() {
+		watchdogSec = int((watchdog.TripTime() + systemdWatchdogMargin).Seconds())
+	}
+	return strings.NewReplacer(
+		"{{watchdogSec}}", strconv.Itoa(watchdogSec),
+		"{{timeoutStartSec}}", strconv.Itoa(timeout),
+		"{{timeoutStopSec}}", strconv.Itoa(stopTimeout),
+		"{{serviceDirectives}}", strings.Join(install.systemdDirectives(), "\n"),
+	).Replace(systemdUnitTemplate)
+}
+
+const systemdUnitTemplate = `[Unit]
+Description={{.Description}}
+ConditionFileIsExecutable={{.Path|cmdEscape}}
+{{range $i, $dep := .Dependencies}}
+{{$dep}} {{end}}
+
+[Service]
+Type=notify
+NotifyAccess=main
+WatchdogSec={{watchdogSec}}
+TimeoutStartSec={{timeoutStartSec}}
+TimeoutStopSec={{timeoutStopSec}}
+StartLimitInterval=5
+StartLimitBurst=10
+ExecStart={{.Path|cmdEscape}}{{range .Arguments}} {{.|cmd}}{{end}}
+{{if .WorkingDirectory}}WorkingDirectory={{.WorkingDirectory|cmdEscape}}{{end}}
+{{if .ReloadSignal}}ExecReload=/bin/kill -{{.ReloadSignal}} "$MAINPID"{{end}}
+{{if and .LogOutput .HasOutputFileSupport -}}
+StandardOutput=file:{{.LogDirectory}}/{{.Name}}.out
+StandardError=file:{{.LogDirectory}}/{{.Name}}.err
+{{- end}}
+{{serviceDirectives}}
+
+{{range $k, $v := .EnvVars -}}
+Environment={{printf "%s=%s" $k $v | cmd}}
+{{end -}}
+
+[Install]
+WantedBy=default.target
+`
+
+// notify passes states to systemd when the server runs as a systemd service.
+func (p *Program) notify(states ...string) {
+	if _, err := systemd.Notify(states...); err != nil {
+		output.Warning("Failed to notify systemd: %v", err)
+	}
+}
+
+// runWatchdog sends systemd keep-alives at half the watchdog interval for as long as the
+// middleware answers liveness probes, so that systemd restarts a server that hangs.
+func (p *Program) runWatchdog(interval time.Duration) {
+	ticker := time.NewTicker(interval / 2)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-p.ctx.Done():
+			return
+		case <-ticker.C:
+			ctx, cancel := context.WithTimeout(p.ctx, interval/2)
+			_, err := p.server.Liveness(ctx)
+			cancel()
+			if err != nil {
+				if p.ctx.Err() == nil {
+					output.Warning("Middleware is not responding, skipping watchdog keep-alive: %v", err)
+				}
+				continue
+			}
+			p.notify(systemd.Watchdog)
+		}
+	}
+}
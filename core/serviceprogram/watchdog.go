@@ -0,0 +1,72 @@
+package serviceprogram
+
+import (
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/anyproto/anytype-cli/core/config"
+	"github.com/anyproto/anytype-cli/core/output"
+	"github.com/anyproto/anytype-cli/core/serverconfig"
+	"github.com/anyproto/anytype-cli/core/serverstatus"
+	"github.com/anyproto/anytype-cli/core/watchdog"
+)
+
+// superviseMiddleware exits the process when the middleware stops answering, so that the
+// service manager restarts it. Every failed check writes a goroutine dump to the logs directory;
+// the dumps and the cause are recorded in the restart history.
+func (p *Program) superviseMiddleware() {
+	var dumps []string
+	supervisor := &watchdog.Supervisor{
+		Probe: p.server.Liveness,
+		Settings: func() serverconfig.Watchdog {
+			return p.currentConfig().Watchdog
+		},
+		OnFailure: func(failures int, err error) {
+			output.Warning("Watchdog check %d failed: %v", failures, err)
+			if failures == 1 {
+				dumps = nil
+			}
+			path, dumpErr := watchdog.WriteGoroutineDump(config.GetLogsDir(), time.Now())
+			if dumpErr != nil {
+				output.Warning("Failed to write goroutine dump: %v", dumpErr)
+				return
+			}
+			output.Info("Wrote goroutine dump to %s", path)
+			dumps = append(dumps, path)
+		},
+		OnTrip: func(cause string) {
+			p.restartForWatchdog(cause, dumps)
+		},
+	}
+	supervisor.Run(p.ctx)
+}
+
+// restartForWatchdog records the cause and exits without the usual shutdown, which would
+// likely block on the wedged middleware.
+func (p *Program) restartForWatchdog(cause string, dumps []string) {
+	output.Warning("Restarting: %s", cause)
+	err := serverstatus.RecordRestart(serverstatus.Restart{PID: os.Getpid(), Cause: cause, Dumps: dumps})
+	if err != nil {
+		output.Warning("Failed to record restart: %v", err)
+	}
+
+	p.notifier.Notify(serverconfig.EventWatchdog, map[string]string{
+		"cause": cause,
+		"dumps": strconv.Itoa(len(dumps)),
+	})
+	done := make(chan struct{})
+	go func() {
+		p.notifier.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+	}
+
+	if p.stopLogFile != nil {
+		p.stopLogFile()
+	}
+	os.Exit(watchdog.ExitCode)
+}
@@ -0,0 +1,142 @@
+// Package systemd implements the parts of the systemd service protocol the server uses:
+// readiness and status notifications, watchdog keep-alives and socket activation.
+// Outside systemd the environment variables are unset and every function is a no-op.
+package systemd
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Notification states, see sd_notify(3).
+const (
+	Ready     = "READY=1"
+	Reloading = "RELOADING=1"
+	Stopping  = "STOPPING=1"
+	Watchdog  = "WATCHDOG=1"
+)
+
+// Names of the sockets in LISTEN_FDNAMES, set with FileDescriptorName= in the socket unit.
+const (
+	ListenerGRPC    = "grpc"
+	ListenerGRPCWeb = "grpc-web"
+	ListenerAPI     = "api"
+)
+
+// listenFdsStart is the first file descriptor passed by systemd.
+const listenFdsStart = 3
+
+// Status returns a STATUS= notification with a free-form description of the service state.
+func Status(format string, args ...any) string {
+	return "STATUS=" + strings.ReplaceAll(fmt.Sprintf(format, args...), "\n", " ")
+}
+
+// Notify sends the newline-separated states to the service manager over $NOTIFY_SOCKET.
+// It reports false without an error when the process was not started with a notify socket.
+func Notify(states ...string) (bool, error) {
+	socket := os.Getenv("NOTIFY_SOCKET")
+	if socket == "" {
+		return false, nil
+	}
+	// Abstract socket names are passed with a leading '@'
+	if strings.HasPrefix(socket, "@") {
+		socket = "\x00" + socket[1:]
+	}
+
+	conn, err := net.DialUnix("unixgram", nil, &net.UnixAddr{Name: socket, Net: "unixgram"})
+	if err != nil {
+		return false, fmt.Errorf("failed to connect to notify socket: %w", err)
+	}
+	defer conn.Close()
+
+	if _, err := conn.Write([]byte(strings.Join(states, "\n"))); err != nil {
+		return false, fmt.Errorf("failed to send notification: %w", err)
+	}
+	return true, nil
+}
+
+// WatchdogInterval returns how often the service manager expects WATCHDOG=1, from
+// $WATCHDOG_USEC. It returns 0 if the watchdog is disabled or meant for another process.
+func WatchdogInterval() (time.Duration, error) {
+	usec := os.Getenv("WATCHDOG_USEC")
+	if usec == "" {
+		return 0, nil
+	}
+	if pid := os.Getenv("WATCHDOG_PID"); pid != "" && pid != strconv.Itoa(os.Getpid()) {
+		return 0, nil
+	}
+	n, err := strconv.ParseInt(usec, 10, 64)
+	if err != nil || n <= 0 {
+		return 0, fmt.Errorf("invalid WATCHDOG_USEC %q", usec)
+	}
+	return time.Duration(n) * time.Microsecond, nil
+}
+
+// Listeners returns the sockets passed by socket activation ($LISTEN_FDS), keyed by their
+// name in $LISTEN_FDNAMES. Sockets without one of the listener names are keyed by position: the gRPC, gRPC-Web and
+// API listener, in that order. The variables are cleared so child processes don't inherit them.
+func Listeners() (map[string]net.Listener, error) {
+	defer func() {
+		os.Unsetenv("LISTEN_PID")
+		os.Unsetenv("LISTEN_FDS")
+		os.Unsetenv("LISTEN_FDNAMES")
+	}()
+
+	if pid := os.Getenv("LISTEN_PID"); pid == "" || pid != strconv.Itoa(os.Getpid()) {
+		return nil, nil
+	}
+	count, err := strconv.Atoi(os.Getenv("LISTEN_FDS"))
+	if err != nil || count <= 0 {
+		return nil, nil
+	}
+
+	files := make([]*os.File, count)
+	for i := range files {
+		files[i] = os.NewFile(uintptr(listenFdsStart+i), "LISTEN_FD_"+strconv.Itoa(listenFdsStart+i))
+	}
+	return fileListeners(files, strings.Split(os.Getenv("LISTEN_FDNAMES"), ":"))
+}
+
+// fileListeners turns passed sockets into listeners and closes the files.
+func fileListeners(files []*os.File, names []string) (map[string]net.Listener, error) {
+	positional := []string{ListenerGRPC, ListenerGRPCWeb, ListenerAPI}
+	defer func() {
+		for _, f := range files {
+			f.Close()
+		}
+	}()
+
+	listeners := make(map[string]net.Listener, len(files))
+	for i, f := range files {
+		// systemd names sockets after their unit unless FileDescriptorName= is set
+		name := ""
+		if i < len(names) && slices.Contains(positional, names[i]) {
+			name = names[i]
+		} else if i < len(positional) {
+			name = positional[i]
+		}
+		if _, ok := listeners[name]; ok || name == "" {
+			closeAll(listeners)
+			return nil, fmt.Errorf("socket %s has a missing or duplicate name %q", f.Name(), name)
+		}
+
+		ln, err := net.FileListener(f)
+		if err != nil {
+			closeAll(listeners)
+			return nil, fmt.Errorf("socket %s (%s) is not a listening socket: %w", f.Name(), name, err)
+		}
+		listeners[name] = ln
+	}
+	return listeners, nil
+}
+
+func closeAll(listeners map[string]net.Listener) {
+	for _, ln := range listeners {
+		ln.Close()
+	}
+}
@@ -0,0 +1,162 @@
+package systemd
+
+import (
+	"net"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func listenNotifySocket(t *testing.T) *net.UnixConn {
+	t.Helper()
+	if runtime.GOOS == "windows" {
+		t.Skip("unixgram sockets are not supported on windows")
+	}
+	path := filepath.Join(t.TempDir(), "notify.sock")
+	conn, err := net.ListenUnixgram("unixgram", &net.UnixAddr{Name: path, Net: "unixgram"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	t.Setenv("NOTIFY_SOCKET", path)
+	return conn
+}
+
+func TestNotify(t *testing.T) {
+	conn := listenNotifySocket(t)
+
+	sent, err := Notify(Ready, Status("Serving on %s\nlogged in", "127.0.0.1:31010"))
+	if err != nil || !sent {
+		t.Fatalf("Notify() = %v, %v; want sent", sent, err)
+	}
+
+	buf := make([]byte, 1024)
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	n, err := conn.Read(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "READY=1\nSTATUS=Serving on 127.0.0.1:31010 logged in"
+	if got := string(buf[:n]); got != want {
+		t.Errorf("received %q, want %q", got, want)
+	}
+}
+
+func TestNotifyWithoutSocket(t *testing.T) {
+	t.Setenv("NOTIFY_SOCKET", "")
+	if sent, err := Notify(Ready); sent || err != nil {
+		t.Errorf("Notify() = %v, %v; want a no-op", sent, err)
+	}
+}
+
+func TestWatchdogInterval(t *testing.T) {
+	pid := strconv.Itoa(os.Getpid())
+	tests := []struct {
+		name    string
+		usec    string
+		pid     string
+		want    time.Duration
+		wantErr bool
+	}{
+		{name: "disabled"},
+		{name: "enabled", usec: "30000000", want: 30 * time.Second},
+		{name: "this process", usec: "1000000", pid: pid, want: time.Second},
+		{name: "other process", usec: "1000000", pid: "1"},
+		{name: "invalid", usec: "soon", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("WATCHDOG_USEC", tt.usec)
+			t.Setenv("WATCHDOG_PID", tt.pid)
+			got, err := WatchdogInterval()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("WatchdogInterval() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("WatchdogInterval() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestListenersOtherProcess(t *testing.T) {
+	t.Setenv("LISTEN_PID", "1")
+	t.Setenv("LISTEN_FDS", "3")
+	listeners, err := Listeners()
+	if err != nil || listeners != nil {
+		t.Errorf("Listeners() = %v, %v; want none", listeners, err)
+	}
+	if os.Getenv("LISTEN_FDS") != "" {
+		t.Error("LISTEN_FDS should be cleared")
+	}
+}
+
+func socketFile(t *testing.T) (*os.File, string) {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	f, err := ln.(*net.TCPListener).File()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return f, ln.Addr().String()
+}
+
+func TestFileListeners(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("socket activation is not supported on windows")
+	}
+
+	tests := []struct {
+		name    string
+		names   []string
+		want    []string
+		wantErr string
+	}{
+		{name: "named", names: []string{"api", "grpc"}, want: []string{ListenerAPI, ListenerGRPC}},
+		{name: "by position", names: []string{"anytype.socket", "anytype.socket"}, want: []string{ListenerGRPC, ListenerGRPCWeb}},
+		{name: "duplicate", names: []string{"grpc", "grpc"}, wantErr: "duplicate"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var files []*os.File
+			var addrs []string
+			for range tt.names {
+				f, addr := socketFile(t)
+				files = append(files, f)
+				addrs = append(addrs, addr)
+			}
+
+			listeners, err := fileListeners(files, tt.names)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("fileListeners failed: %v", err)
+			}
+			defer closeAll(listeners)
+
+			for i, name := range tt.want {
+				ln, ok := listeners[name]
+				if !ok {
+					t.Fatalf("listener %q missing in %v", name, listeners)
+				}
+				if ln.Addr().String() != addrs[i] {
+					t.Errorf("listener %q on %s, want %s", name, ln.Addr(), addrs[i])
+				}
+			}
+		})
+	}
+}
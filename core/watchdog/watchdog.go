@@ -0,0 +1,148 @@
+// Package watchdog detects a middleware that stopped answering, e.g. because it deadlocked,
+// so that the server can exit and be restarted by its service manager.
+package watchdog
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime/pprof"
+	"sort"
+	"time"
+
+	"github.com/anyproto/anytype-cli/core/serverconfig"
+)
+
+const (
+	// ExitCode is the status the server exits with when the watchdog trips
+	ExitCode = 3
+
+	dumpPrefix = "goroutines-"
+	// maxDumps is the number of goroutine dumps kept in the logs directory
+	maxDumps = 10
+)
+
+// Supervisor calls Probe at the configured interval. A probe fails if it returns an error or
+// reports a latency above the limit; a probe that does not return within the limit is not waited for.
+type Supervisor struct {
+	// Probe performs one cheap request to the middleware and returns how long it took
+	Probe func(ctx context.Context) (time.Duration, error)
+	// Settings returns the current watchdog settings, which may change between checks
+	Settings func() serverconfig.Watchdog
+	// OnFailure is called for every failed check with the number of consecutive failures
+	OnFailure func(failures int, err error)
+	// OnTrip is called once when the failure limit is reached, after which Run returns
+	OnTrip func(cause string)
+
+	after func(time.Duration) <-chan time.Time
+}
+
+// Run checks the middleware until the watchdog trips or ctx is done.
+func (s *Supervisor) Run(ctx context.Context) {
+	after := s.after
+	if after == nil {
+		after = time.After
+	}
+
+	failures := 0
+	for {
+		settings := s.Settings()
+		select {
+		case <-ctx.Done():
+			return
+		case <-after(settings.CheckInterval()):
+		}
+
+		settings = s.Settings()
+		if !settings.IsEnabled() {
+			failures = 0
+			continue
+		}
+
+		err := s.check(ctx, settings.LatencyLimit())
+		if ctx.Err() != nil {
+			return
+		}
+		if err == nil {
+			failures = 0
+			continue
+		}
+
+		failures++
+		if s.OnFailure != nil {
+			s.OnFailure(failures, err)
+		}
+		if failures >= settings.MaxFailures() {
+			s.OnTrip(fmt.Sprintf("watchdog: %d consecutive checks failed, last: %v", failures, err))
+			return
+		}
+	}
+}
+
+// check runs one probe bounded by limit.
+func (s *Supervisor) check(parent context.Context, limit time.Duration) error {
+	ctx, cancel := context.WithTimeout(parent, limit)
+	defer cancel()
+
+	type result struct {
+		latency time.Duration
+		err     error
+	}
+	done := make(chan result, 1)
+	go func() {
+		latency, err := s.Probe(ctx)
+		done <- result{latency, err}
+	}()
+
+	select {
+	case r := <-done:
+		if r.latency > limit {
+			return fmt.Errorf("middleware answered after %s, limit is %s", r.latency.Round(time.Millisecond), limit)
+		}
+		if r.err != nil {
+			return fmt.Errorf("middleware request failed: %w", r.err)
+		}
+		return nil
+	case <-ctx.Done():
+		if parent.Err() != nil {
+			return parent.Err()
+		}
+		return fmt.Errorf("middleware did not answer within %s", limit)
+	}
+}
+
+// WriteGoroutineDump writes the stacks of all goroutines to a new file in dir and removes
+// the oldest dumps beyond the number kept. It returns the path of the new dump.
+func WriteGoroutineDump(dir string, now time.Time) (string, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", fmt.Errorf("failed to create dump directory: %w", err)
+	}
+	path := filepath.Join(dir, dumpPrefix+now.UTC().Format("20060102T150405.000Z")+".txt")
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0600)
+	if err != nil {
+		return "", fmt.Errorf("failed to create goroutine dump: %w", err)
+	}
+	err = pprof.Lookup("goroutine").WriteTo(f, 2)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to write goroutine dump: %w", err)
+	}
+
+	pruneDumps(dir, maxDumps)
+	return path, nil
+}
+
+// pruneDumps keeps the newest keep dumps in dir. Their names sort by time.
+func pruneDumps(dir string, keep int) {
+	matches, _ := filepath.Glob(filepath.Join(dir, dumpPrefix+"*.txt"))
+	if len(matches) <= keep {
+		return
+	}
+	sort.Strings(matches)
+	for _, p := range matches[:len(matches)-keep] {
+		os.Remove(p)
+	}
+}
@@ -0,0 +1,128 @@
+package watchdog
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/anyproto/anytype-cli/core/serverconfig"
+)
+
+func immediately(time.Duration) <-chan time.Time {
+	ch := make(chan time.Time, 1)
+	ch <- time.Time{}
+	return ch
+}
+
+func TestSupervisorTripsAfterConsecutiveFailures(t *testing.T) {
+	hang := make(chan struct{})
+	defer close(hang)
+
+	// Check 1 fails, check 2 succeeds and resets the count, check 3 answers too slowly and from
+	// check 4 the middleware hangs
+	var calls atomic.Int32
+	var failures []int
+	var errs []error
+	var cause string
+	s := &Supervisor{
+		Probe: func(ctx context.Context) (time.Duration, error) {
+			switch n := calls.Add(1); {
+			case n == 2:
+				return time.Millisecond, nil
+			case n == 3:
+				return time.Second, nil
+			case n >= 4:
+				// A wedged middleware never answers, even after the deadline
+				<-hang
+				return 0, nil
+			default:
+				return time.Millisecond, errors.New("unavailable")
+			}
+		},
+		Settings: func() serverconfig.Watchdog {
+			return serverconfig.Watchdog{Timeout: 20 * time.Millisecond, Failures: 3}
+		},
+		OnFailure: func(n int, err error) {
+			failures = append(failures, n)
+			errs = append(errs, err)
+		},
+		OnTrip: func(c string) { cause = c },
+		after:  immediately,
+	}
+
+	done := make(chan struct{})
+	go func() {
+		s.Run(context.Background())
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("supervisor did not trip")
+	}
+
+	if want := []int{1, 1, 2, 3}; !slices.Equal(failures, want) {
+		t.Errorf("failure counts = %v, want %v", failures, want)
+	}
+	if len(errs) > 1 && !strings.Contains(errs[1].Error(), "answered after 1s, limit is 20ms") {
+		t.Errorf("slow check error = %v", errs[1])
+	}
+	if !strings.Contains(cause, "3 consecutive checks failed") || !strings.Contains(cause, "did not answer within 20ms") {
+		t.Errorf("cause = %q", cause)
+	}
+}
+
+func TestSupervisorDisabled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	disabled := false
+	checks := 0
+	s := &Supervisor{
+		Probe: func(ctx context.Context) (time.Duration, error) { return 0, errors.New("unavailable") },
+		Settings: func() serverconfig.Watchdog {
+			checks++
+			if checks > 20 {
+				cancel()
+			}
+			return serverconfig.Watchdog{Enabled: &disabled}
+		},
+		OnTrip: func(string) { t.Error("a disabled watchdog tripped") },
+		after:  immediately,
+	}
+	s.Run(ctx)
+}
+
+func TestWriteGoroutineDump(t *testing.T) {
+	dir := t.TempDir()
+	start := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	var last string
+	for i := 0; i < maxDumps+2; i++ {
+		path, err := WriteGoroutineDump(dir, start.Add(time.Duration(i)*time.Second))
+		if err != nil {
+			t.Fatalf("WriteGoroutineDump failed: %v", err)
+		}
+		last = path
+	}
+
+	data, err := os.ReadFile(last)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), "goroutine ") || !strings.Contains(string(data), "TestWriteGoroutineDump") {
+		t.Errorf("dump does not contain the goroutine stacks:\n%s", data)
+	}
+
+	matches, _ := filepath.Glob(filepath.Join(dir, dumpPrefix+"*"))
+	if len(matches) != maxDumps {
+		t.Errorf("%d dumps kept, want %d", len(matches), maxDumps)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "goroutines-20260102T030405.000Z.txt")); !os.IsNotExist(err) {
+		t.Errorf("oldest dump was kept: %v", err)
+	}
+}